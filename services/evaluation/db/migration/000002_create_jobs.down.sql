@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS jobs;
+
+DROP TYPE IF EXISTS job_state;
+
+COMMIT;
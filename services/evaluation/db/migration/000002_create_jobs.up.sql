@@ -0,0 +1,34 @@
+BEGIN;
+
+-- Создаем enum для состояния задачи в очереди
+CREATE TYPE job_state AS ENUM (
+    'queued',                   -- ожидает выполнения
+    'running',                  -- выполняется воркером
+    'completed',                -- успешно выполнена
+    'failed'                    -- завершилась с ошибкой
+);
+
+-- Создаем таблицу jobs - персистентная очередь фоновых задач
+-- Воркеры захватывают задачи через SELECT ... FOR UPDATE SKIP LOCKED,
+-- поэтому одну очередь могут разделять несколько реплик сервиса
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    kind VARCHAR(64) NOT NULL,
+    payload JSONB DEFAULT '{}' NOT NULL,
+    state job_state DEFAULT 'queued' NOT NULL,
+    attempts INTEGER DEFAULT 0 NOT NULL,
+    run_after TIMESTAMP DEFAULT NOW() NOT NULL,
+    locked_by VARCHAR(255),
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+-- Индекс для выборки задач, готовых к выполнению
+CREATE INDEX jobs_state_run_after_idx ON jobs (state, run_after);
+
+-- Индекс для выборки задач проекта
+CREATE INDEX jobs_project_id_idx ON jobs (project_id);
+
+COMMIT;
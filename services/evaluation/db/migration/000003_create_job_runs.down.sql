@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS job_runs;
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS finished_at;
+ALTER TABLE jobs DROP COLUMN IF EXISTS last_error;
+
+COMMIT;
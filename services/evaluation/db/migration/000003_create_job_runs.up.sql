@@ -0,0 +1,25 @@
+BEGIN;
+
+-- Добавляем в jobs итог последнего выполнения
+ALTER TABLE jobs ADD COLUMN last_error TEXT;
+ALTER TABLE jobs ADD COLUMN finished_at TIMESTAMP;
+
+-- Создаем таблицу job_runs - история выполнения задач (одна запись на попытку)
+CREATE TABLE job_runs (
+    id SERIAL PRIMARY KEY,
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    kind VARCHAR(64) NOT NULL,
+    attempt INTEGER NOT NULL,
+    worker_id VARCHAR(255) NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    duration_ms BIGINT NOT NULL,
+    success BOOLEAN NOT NULL,
+    error TEXT
+);
+
+-- Индекс для выборки истории задачи
+CREATE INDEX job_runs_job_id_idx ON job_runs (job_id);
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+-- Создаем таблицу project_status_resets - журнал принудительных сбросов статуса проекта в 'ready'
+-- (восстановление после падения сервиса или ручной сброс администратором)
+CREATE TABLE project_status_resets (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    previous_status project_status NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+-- Индекс для выборки сбросов проекта
+CREATE INDEX project_status_resets_project_id_idx ON project_status_resets (project_id);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Добавляем состояние для задач, отмененных пользователем
+ALTER TYPE job_state ADD VALUE IF NOT EXISTS 'cancelled';
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+-- Значение enum нельзя удалить, поэтому пересоздаем тип без 'dead'
+UPDATE jobs SET state = 'failed' WHERE state = 'dead';
+
+ALTER TYPE job_state RENAME TO job_state_old;
+
+CREATE TYPE job_state AS ENUM (
+    'queued',
+    'running',
+    'completed',
+    'failed',
+    'cancelled'
+);
+
+ALTER TABLE jobs ALTER COLUMN state DROP DEFAULT;
+ALTER TABLE jobs ALTER COLUMN state TYPE job_state USING state::text::job_state;
+ALTER TABLE jobs ALTER COLUMN state SET DEFAULT 'queued';
+
+DROP TYPE job_state_old;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Добавляем состояние для задач, исчерпавших все попытки повтора (dead-letter)
+ALTER TYPE job_state ADD VALUE IF NOT EXISTS 'dead';
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS priority;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+-- Приоритет задачи: меньше = выше приоритет
+-- При выборке приоритет повышается по мере ожидания задачи, чтобы низкоприоритетные задачи не голодали
+ALTER TABLE jobs ADD COLUMN priority INTEGER DEFAULT 1 NOT NULL;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS projects_status_changed ON projects;
+DROP FUNCTION IF EXISTS notify_project_status_changed();
+
+COMMIT;
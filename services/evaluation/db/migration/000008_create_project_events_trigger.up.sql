@@ -0,0 +1,23 @@
+BEGIN;
+
+-- Уведомляем подписчиков канала project_events о смене статуса проекта
+-- Сообщения о прогрессе задач публикуются в тот же канал из приложения (PublishProjectEvent)
+CREATE FUNCTION notify_project_status_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('project_events', json_build_object(
+        'project_id', NEW.id,
+        'type', 'status',
+        'status', NEW.status,
+        'created_at', NOW()
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER projects_status_changed
+    AFTER UPDATE OF status ON projects
+    FOR EACH ROW
+    WHEN (OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION notify_project_status_changed();
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS jobs_one_running_per_project_idx;
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+-- У проекта может выполняться не больше одной задачи одновременно
+-- Лишние выполняющиеся задачи возвращаются в очередь, чтобы можно было создать индекс
+UPDATE jobs
+SET state = 'queued',
+    locked_by = NULL,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE state = 'running'
+  AND id NOT IN (
+    SELECT DISTINCT ON (project_id) id
+    FROM jobs
+    WHERE state = 'running'
+    ORDER BY project_id, updated_at DESC
+  );
+
+-- Уникальный индекс защищает от одновременного захвата двух задач проекта разными воркерами
+CREATE UNIQUE INDEX jobs_one_running_per_project_idx ON jobs (project_id) WHERE state = 'running';
+
+COMMIT;
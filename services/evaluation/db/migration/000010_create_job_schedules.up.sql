@@ -0,0 +1,22 @@
+BEGIN;
+
+-- Создаем таблицу job_schedules - периодический запуск задач проекта по cron-выражению
+-- next_run_at хранится с часовым поясом: сервис вычисляет его по cron-выражению в UTC
+CREATE TABLE job_schedules (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    kind VARCHAR(64) NOT NULL,
+    cron_expr VARCHAR(255) NOT NULL,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+-- Индекс для выборки наступивших расписаний
+CREATE INDEX job_schedules_next_run_at_idx ON job_schedules (next_run_at);
+
+-- Индекс для выборки расписаний проекта
+CREATE INDEX job_schedules_project_id_idx ON job_schedules (project_id);
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+CREATE TABLE project_status_resets (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    previous_status project_status NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX project_status_resets_project_id_idx ON project_status_resets (project_id);
+
+-- Возвращаем в журнал сбросов переходы в ready, выполненные восстановлением и администратором
+INSERT INTO project_status_resets (project_id, previous_status, reason, created_at)
+SELECT project_id, from_status, reason, created_at
+FROM project_status_history
+WHERE to_status = 'ready' AND actor IN ('recovery', 'admin')
+ORDER BY id;
+
+DROP TABLE IF EXISTS project_status_history;
+
+COMMIT;
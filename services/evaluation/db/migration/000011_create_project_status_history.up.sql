@@ -0,0 +1,31 @@
+BEGIN;
+
+-- Создаем таблицу project_status_history - журнал всех переходов статуса проекта
+-- actor - кто выполнил переход (user, worker, scheduler, recovery, admin)
+CREATE TABLE project_status_history (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    from_status project_status NOT NULL,
+    to_status project_status NOT NULL,
+    actor VARCHAR(50) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+-- Индекс для выборки истории проекта в хронологическом порядке
+CREATE INDEX project_status_history_project_id_idx ON project_status_history (project_id, created_at);
+
+-- Переносим журнал сбросов статуса: теперь сбросы - обычные переходы в ready
+INSERT INTO project_status_history (project_id, from_status, to_status, actor, reason, created_at)
+SELECT project_id,
+       previous_status,
+       'ready',
+       CASE WHEN reason LIKE 'processing interrupted:%' THEN 'recovery' ELSE 'admin' END,
+       reason,
+       created_at
+FROM project_status_resets
+ORDER BY id;
+
+DROP TABLE project_status_resets;
+
+COMMIT;
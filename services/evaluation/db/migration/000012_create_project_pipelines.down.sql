@@ -0,0 +1,48 @@
+BEGIN;
+
+DROP INDEX IF EXISTS jobs_one_running_per_pipeline_idx;
+
+-- Лишние выполняющиеся задачи проекта возвращаются в очередь, чтобы можно было создать индекс
+UPDATE jobs
+SET state = 'queued',
+    locked_by = NULL,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE state = 'running'
+  AND id NOT IN (
+    SELECT DISTINCT ON (project_id) id
+    FROM jobs
+    WHERE state = 'running'
+    ORDER BY project_id, updated_at DESC
+  );
+
+CREATE UNIQUE INDEX jobs_one_running_per_project_idx ON jobs (project_id) WHERE state = 'running';
+
+DROP TRIGGER IF EXISTS project_pipelines_status_changed ON project_pipelines;
+DROP FUNCTION IF EXISTS notify_pipeline_status_changed();
+DROP TRIGGER IF EXISTS project_pipelines_sync_project_status ON project_pipelines;
+DROP FUNCTION IF EXISTS sync_project_status();
+
+CREATE FUNCTION notify_project_status_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('project_events', json_build_object(
+        'project_id', NEW.id,
+        'type', 'status',
+        'status', NEW.status,
+        'created_at', NOW()
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER projects_status_changed
+    AFTER UPDATE OF status ON projects
+    FOR EACH ROW
+    WHEN (OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION notify_project_status_changed();
+
+ALTER TABLE project_status_history DROP COLUMN IF EXISTS pipeline;
+
+DROP TABLE IF EXISTS project_pipelines;
+
+COMMIT;
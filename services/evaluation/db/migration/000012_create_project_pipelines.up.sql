@@ -0,0 +1,89 @@
+BEGIN;
+
+-- Создаем таблицу project_pipelines - состояние независимых конвейеров обработки проекта
+-- pipeline совпадает с типом задачи (remarks, checklist, final_report),
+-- status - ready либо статус обработки этого конвейера
+CREATE TABLE project_pipelines (
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    pipeline VARCHAR(64) NOT NULL,
+    status project_status DEFAULT 'ready' NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (project_id, pipeline)
+);
+
+-- Создаем конвейеры существующих проектов, переносим в них текущий статус обработки
+INSERT INTO project_pipelines (project_id, pipeline, status)
+SELECT p.id,
+       pipeline.name,
+       CASE WHEN p.status = pipeline.status THEN p.status ELSE 'ready' END
+FROM projects p
+CROSS JOIN (VALUES
+    ('remarks', 'processing_remarks'::project_status),
+    ('checklist', 'processing_checklist'::project_status),
+    ('final_report', 'generating_final_report'::project_status)
+) AS pipeline (name, status);
+
+-- История переходов ведется по конвейерам
+ALTER TABLE project_status_history ADD COLUMN pipeline VARCHAR(64);
+
+UPDATE project_status_history
+SET pipeline = CASE
+    WHEN 'processing_remarks' IN (from_status, to_status) THEN 'remarks'
+    WHEN 'processing_checklist' IN (from_status, to_status) THEN 'checklist'
+    ELSE 'final_report'
+END;
+
+ALTER TABLE project_status_history ALTER COLUMN pipeline SET NOT NULL;
+
+-- projects.status становится сводным: статус последнего запущенного конвейера или ready
+CREATE FUNCTION sync_project_status() RETURNS trigger AS $$
+DECLARE
+    summary project_status;
+BEGIN
+    SELECT pp.status INTO summary
+    FROM project_pipelines pp
+    WHERE pp.project_id = NEW.project_id AND pp.status <> 'ready'
+    ORDER BY pp.updated_at DESC
+    LIMIT 1;
+
+    UPDATE projects
+    SET status = COALESCE(summary, 'ready')
+    WHERE id = NEW.project_id AND status <> COALESCE(summary, 'ready');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER project_pipelines_sync_project_status
+    AFTER UPDATE OF status ON project_pipelines
+    FOR EACH ROW
+    WHEN (OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION sync_project_status();
+
+-- События о смене статуса теперь публикуются по конвейерам
+DROP TRIGGER projects_status_changed ON projects;
+DROP FUNCTION notify_project_status_changed();
+
+CREATE FUNCTION notify_pipeline_status_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('project_events', json_build_object(
+        'project_id', NEW.project_id,
+        'type', 'status',
+        'pipeline', NEW.pipeline,
+        'status', NEW.status,
+        'created_at', NOW()
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER project_pipelines_status_changed
+    AFTER UPDATE OF status ON project_pipelines
+    FOR EACH ROW
+    WHEN (OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION notify_pipeline_status_changed();
+
+-- Запрещаем только одновременный запуск одного конвейера: разные конвейеры проекта выполняются параллельно
+DROP INDEX jobs_one_running_per_project_idx;
+CREATE UNIQUE INDEX jobs_one_running_per_pipeline_idx ON jobs (project_id, kind) WHERE state = 'running';
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS project_report_templates;
+DROP TABLE IF EXISTS report_templates;
+
+COMMIT;
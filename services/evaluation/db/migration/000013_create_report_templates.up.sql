@@ -0,0 +1,30 @@
+BEGIN;
+
+-- Создаем таблицу report_templates - шаблоны оформления отчетов, загружаемые администраторами
+-- Тексты введения и заключения - шаблоны Go text/template; пустой текст берется из шаблона сервиса
+-- Шаблон с is_default используется для проектов организации, которым шаблон не выбран
+CREATE TABLE report_templates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    organization VARCHAR(255) NOT NULL DEFAULT '',
+    remarks_intro TEXT NOT NULL DEFAULT '',
+    remarks_conclusion TEXT NOT NULL DEFAULT '',
+    final_report_intro TEXT NOT NULL DEFAULT '',
+    final_report_conclusion TEXT NOT NULL DEFAULT '',
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+-- Создаем таблицу project_report_templates - шаблон отчетов, выбранный для проекта
+-- При удалении шаблона проект возвращается к шаблону по умолчанию
+CREATE TABLE project_report_templates (
+    project_id INTEGER PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    template_id INTEGER NOT NULL REFERENCES report_templates(id) ON DELETE CASCADE,
+    updated_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+-- Индекс для удаления выбора шаблона вместе с шаблоном
+CREATE INDEX project_report_templates_template_id_idx ON project_report_templates (template_id);
+
+COMMIT;
@@ -0,0 +1,25 @@
+BEGIN;
+
+-- Создаем таблицу document_index - индекс файлов документации для проверки чек-листа
+-- Чанки файла хранятся одной строкой и заменяются целиком, поэтому обновление индекса атомарно
+-- content_hash (SHA-256 содержимого) позволяет переиспользовать индекс повторно загруженного файла,
+-- chunk_size и chunk_overlap - параметры разбиения, при смене которых файл индексируется заново
+-- embedding_model - модель эмбеддингов чанков, пустая строка - чанки без эмбеддингов
+CREATE TABLE document_index (
+    project_file_id INTEGER PRIMARY KEY REFERENCES project_files(id) ON DELETE CASCADE,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    content_hash VARCHAR(64) NOT NULL,
+    chunk_size INTEGER NOT NULL,
+    chunk_overlap INTEGER NOT NULL,
+    embedding_model VARCHAR(255) NOT NULL DEFAULT '',
+    chunks JSONB NOT NULL DEFAULT '[]',
+    indexed_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+-- Индекс для загрузки индекса документации проекта
+CREATE INDEX document_index_project_id_idx ON document_index (project_id);
+
+-- Индекс для поиска уже проиндексированного файла с тем же содержимым
+CREATE INDEX document_index_content_hash_idx ON document_index (content_hash);
+
+COMMIT;
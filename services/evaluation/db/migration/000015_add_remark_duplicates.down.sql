@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE remarks DROP COLUMN IF EXISTS duplicates;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+-- duplicates - количество исходных замечаний, объединенных в синтезированное замечание
+-- Замечания, сохраненные до появления столбца, считаются необъединенными
+ALTER TABLE remarks ADD COLUMN duplicates INTEGER NOT NULL DEFAULT 1;
+
+COMMIT;
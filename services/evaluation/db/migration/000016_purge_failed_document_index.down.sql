@@ -0,0 +1,5 @@
+BEGIN;
+
+-- Удаленные записи индекса восстанавливаются повторной индексацией, откатывать нечего
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+-- Удаляем записи индекса без чанков: так сохранялись файлы, текст которых не удалось извлечь,
+-- и они переиспользовались для файлов с тем же содержимым без повторного извлечения
+-- Файлы без текста будут один раз проиндексированы заново при следующей проверке чек-листа
+DELETE FROM document_index WHERE chunks = '[]'::jsonb;
+
+COMMIT;
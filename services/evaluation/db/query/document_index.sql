@@ -0,0 +1,23 @@
+-- name: ListDocumentIndex :many
+SELECT project_file_id, project_id, content_hash, chunk_size, chunk_overlap, embedding_model, chunks, indexed_at
+FROM document_index
+WHERE project_id = $1;
+
+-- name: FindDocumentIndexByHash :one
+-- Находит индекс файла с тем же содержимым и параметрами разбиения, предпочитая индекс с эмбеддингами нужной модели
+SELECT project_file_id, project_id, content_hash, chunk_size, chunk_overlap, embedding_model, chunks, indexed_at
+FROM document_index
+WHERE content_hash = $1 AND chunk_size = $2 AND chunk_overlap = $3
+ORDER BY embedding_model = sqlc.arg(embedding_model) DESC, indexed_at DESC
+LIMIT 1;
+
+-- name: UpsertDocumentIndex :exec
+INSERT INTO document_index (project_file_id, project_id, content_hash, chunk_size, chunk_overlap, embedding_model, chunks)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (project_file_id) DO UPDATE
+SET content_hash = EXCLUDED.content_hash,
+    chunk_size = EXCLUDED.chunk_size,
+    chunk_overlap = EXCLUDED.chunk_overlap,
+    embedding_model = EXCLUDED.embedding_model,
+    chunks = EXCLUDED.chunks,
+    indexed_at = NOW();
@@ -0,0 +1,10 @@
+-- name: CreateJobRun :one
+INSERT INTO job_runs (job_id, project_id, kind, attempt, worker_id, started_at, finished_at, duration_ms, success, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, job_id, project_id, kind, attempt, worker_id, started_at, finished_at, duration_ms, success, error;
+
+-- name: ListJobRuns :many
+SELECT id, job_id, project_id, kind, attempt, worker_id, started_at, finished_at, duration_ms, success, error
+FROM job_runs
+WHERE job_id = $1
+ORDER BY started_at;
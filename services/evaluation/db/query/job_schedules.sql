@@ -0,0 +1,30 @@
+-- name: CreateJobSchedule :one
+INSERT INTO job_schedules (project_id, kind, cron_expr, next_run_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, project_id, kind, cron_expr, next_run_at, last_run_at, created_at, updated_at;
+
+-- name: ListJobSchedulesByProject :many
+SELECT id, project_id, kind, cron_expr, next_run_at, last_run_at, created_at, updated_at
+FROM job_schedules
+WHERE project_id = $1
+ORDER BY id;
+
+-- name: DeleteJobSchedule :execrows
+DELETE FROM job_schedules
+WHERE id = $1 AND project_id = $2;
+
+-- name: ListDueJobSchedules :many
+-- Получает расписания, время запуска которых наступило
+SELECT id, project_id, kind, cron_expr, next_run_at, last_run_at, created_at, updated_at
+FROM job_schedules
+WHERE next_run_at <= NOW()
+ORDER BY next_run_at;
+
+-- name: AdvanceJobSchedule :execrows
+-- Переносит расписание на следующий запуск, если его еще не перенес другой экземпляр сервиса
+-- Запуск выполняет только тот экземпляр, чье обновление затронуло строку
+UPDATE job_schedules
+SET next_run_at = sqlc.arg(next_run_at),
+    last_run_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND next_run_at = sqlc.arg(expected_run_at);
@@ -0,0 +1,155 @@
+-- name: EnqueueJob :one
+-- Добавляет задачу в очередь; задача становится доступной воркерам через delay_ms миллисекунд
+INSERT INTO jobs (id, project_id, kind, payload, priority, run_after)
+VALUES ($1, $2, $3, $4, $5, NOW() + sqlc.arg(delay_ms)::bigint * INTERVAL '1 millisecond')
+RETURNING id, project_id, kind, payload, state, attempts, run_after, locked_by, locked_until, created_at, updated_at, last_error, finished_at, priority;
+
+-- name: ClaimJob :one
+-- Захватывает одну готовую к выполнению задачу, а также задачи,
+-- блокировка которых истекла (воркер упал во время выполнения)
+-- Задачи выбираются по приоритету, который повышается на 1 за каждые aging_seconds ожидания,
+-- при равном приоритете - в порядке постановки в очередь
+-- Пропускаются задачи типов excluded_kinds и задачи, конвейер которых уже выполняется у проекта
+UPDATE jobs
+SET state = 'running',
+    attempts = attempts + 1,
+    locked_by = sqlc.arg(worker_id)::varchar,
+    locked_until = NOW() + sqlc.arg(lock_seconds)::int * INTERVAL '1 second',
+    updated_at = NOW()
+WHERE id = (
+    SELECT candidate.id
+    FROM jobs candidate
+    WHERE ((candidate.state = 'queued' AND candidate.run_after <= NOW())
+       OR (candidate.state = 'running' AND candidate.locked_until < NOW()))
+      AND NOT (candidate.kind = ANY(sqlc.arg(excluded_kinds)::text[]))
+      AND NOT EXISTS (
+        SELECT 1
+        FROM jobs active
+        WHERE active.project_id = candidate.project_id
+          AND active.kind = candidate.kind
+          AND active.id <> candidate.id
+          AND active.state = 'running'
+      )
+    ORDER BY candidate.priority - FLOOR(EXTRACT(EPOCH FROM NOW() - candidate.run_after) / sqlc.arg(aging_seconds)::int), candidate.created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, project_id, kind, payload, state, attempts, run_after, locked_by, locked_until, created_at, updated_at, last_error, finished_at, priority;
+
+-- name: ExtendJobLock :execrows
+-- Продлевает блокировку выполняющейся задачи (heartbeat воркера)
+UPDATE jobs
+SET locked_until = NOW() + sqlc.arg(lock_seconds)::int * INTERVAL '1 second',
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND state = 'running' AND locked_by = sqlc.arg(worker_id)::varchar;
+
+-- name: CompleteJob :execrows
+UPDATE jobs
+SET state = 'completed',
+    locked_by = NULL,
+    locked_until = NULL,
+    last_error = NULL,
+    finished_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND state = 'running' AND locked_by = sqlc.arg(worker_id)::varchar;
+
+-- name: FailJob :execrows
+UPDATE jobs
+SET state = 'failed',
+    locked_by = NULL,
+    locked_until = NULL,
+    last_error = sqlc.arg(last_error)::text,
+    finished_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND state = 'running' AND locked_by = sqlc.arg(worker_id)::varchar;
+
+-- name: RetryJob :execrows
+-- Возвращает проваленную задачу в очередь с отложенным запуском (повтор после backoff)
+UPDATE jobs
+SET state = 'queued',
+    locked_by = NULL,
+    locked_until = NULL,
+    last_error = sqlc.arg(last_error)::text,
+    run_after = NOW() + sqlc.arg(delay_ms)::bigint * INTERVAL '1 millisecond',
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND state = 'running' AND locked_by = sqlc.arg(worker_id)::varchar;
+
+-- name: MarkJobDead :execrows
+-- Переводит задачу, исчерпавшую попытки повтора, в dead-letter
+UPDATE jobs
+SET state = 'dead',
+    locked_by = NULL,
+    locked_until = NULL,
+    last_error = sqlc.arg(last_error)::text,
+    finished_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND state = 'running' AND locked_by = sqlc.arg(worker_id)::varchar;
+
+-- name: ListDeadJobs :many
+SELECT id, project_id, kind, payload, state, attempts, run_after, locked_by, locked_until, created_at, updated_at, last_error, finished_at, priority
+FROM jobs
+WHERE state = 'dead'
+ORDER BY finished_at DESC;
+
+-- name: RequeueDeadJob :one
+-- Возвращает задачу из dead-letter в очередь со сброшенным счетчиком попыток
+UPDATE jobs
+SET state = 'queued',
+    attempts = 0,
+    run_after = NOW(),
+    finished_at = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND state = 'dead'
+RETURNING id, project_id, kind, payload, state, attempts, run_after, locked_by, locked_until, created_at, updated_at, last_error, finished_at, priority;
+
+-- name: CountJobsByState :one
+SELECT COUNT(*)
+FROM jobs
+WHERE state = $1;
+
+-- name: GetQueueDepth :one
+-- Возвращает глубину общей очереди: готовые и отложенные задачи, выполняющиеся и dead-letter,
+-- а также сколько секунд ждет самая старая готовая к выполнению задача
+SELECT COUNT(*) FILTER (WHERE state = 'queued' AND run_after <= NOW()) AS ready,
+       COUNT(*) FILTER (WHERE state = 'queued' AND run_after > NOW()) AS delayed,
+       COUNT(*) FILTER (WHERE state = 'running') AS running,
+       COUNT(*) FILTER (WHERE state = 'dead') AS dead,
+       COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(run_after) FILTER (WHERE state = 'queued' AND run_after <= NOW())), 0)::float8 AS oldest_wait_seconds
+FROM jobs;
+
+-- name: GetJob :one
+SELECT id, project_id, kind, payload, state, attempts, run_after, locked_by, locked_until, created_at, updated_at, last_error, finished_at, priority
+FROM jobs
+WHERE id = $1;
+
+-- name: ListJobsByProject :many
+SELECT id, project_id, kind, payload, state, attempts, run_after, locked_by, locked_until, created_at, updated_at, last_error, finished_at, priority
+FROM jobs
+WHERE project_id = $1
+ORDER BY created_at DESC;
+
+-- name: FailProjectJobs :execrows
+-- Помечает все активные задачи проекта как проваленные (принудительный сброс проекта)
+UPDATE jobs
+SET state = 'failed',
+    last_error = sqlc.arg(last_error)::text,
+    locked_by = NULL,
+    locked_until = NULL,
+    finished_at = NOW(),
+    updated_at = NOW()
+WHERE project_id = sqlc.arg(project_id)
+  AND state IN ('queued', 'running');
+
+-- name: CancelJob :one
+-- Отменяет задачу, которая еще ожидает выполнения или выполняется
+-- Воркер, выполняющий задачу, узнает об отмене при продлении блокировки
+UPDATE jobs
+SET state = 'cancelled',
+    last_error = sqlc.arg(reason)::text,
+    locked_by = NULL,
+    locked_until = NULL,
+    finished_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND state IN ('queued', 'running')
+RETURNING id, project_id, kind, payload, state, attempts, run_after, locked_by, locked_until, created_at, updated_at, last_error, finished_at, priority;
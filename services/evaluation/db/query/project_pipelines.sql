@@ -0,0 +1,46 @@
+-- name: GetProjectPipeline :one
+SELECT project_id, pipeline, status, updated_at
+FROM project_pipelines
+WHERE project_id = $1 AND pipeline = $2;
+
+-- name: ListProjectPipelines :many
+SELECT project_id, pipeline, status, updated_at
+FROM project_pipelines
+WHERE project_id = $1
+ORDER BY pipeline;
+
+-- name: ListStuckPipelines :many
+-- Возвращает конвейеры в статусе обработки, для которых в очереди нет активной задачи
+-- Конвейеры, статус которых изменился менее grace_seconds назад, пропускаются:
+-- задача только что запущенной обработки может быть еще не поставлена в очередь
+SELECT pp.project_id, pp.pipeline, pp.status, pp.updated_at
+FROM project_pipelines pp
+WHERE pp.status <> 'ready'
+  AND pp.updated_at < NOW() - sqlc.arg(grace_seconds)::int * INTERVAL '1 second'
+  AND NOT EXISTS (
+    SELECT 1
+    FROM jobs j
+    WHERE j.project_id = pp.project_id
+      AND j.kind = pp.pipeline
+      AND j.state IN ('queued', 'running')
+  )
+ORDER BY pp.project_id, pp.pipeline;
+
+-- name: TransitionPipelineStatus :one
+-- Атомарно переводит конвейер проекта из статуса from_status в to_status и записывает переход в историю
+-- Возвращает ошибку, если статус конвейера уже отличается от ожидаемого
+WITH transitioned AS (
+    UPDATE project_pipelines
+    SET status = sqlc.arg(to_status),
+        updated_at = NOW()
+    WHERE project_pipelines.project_id = sqlc.arg(project_id)
+      AND project_pipelines.pipeline = sqlc.arg(pipeline)
+      AND project_pipelines.status = sqlc.arg(from_status)
+    RETURNING project_pipelines.project_id, project_pipelines.pipeline, project_pipelines.status, project_pipelines.updated_at
+), logged AS (
+    INSERT INTO project_status_history (project_id, pipeline, from_status, to_status, actor, reason)
+    SELECT transitioned.project_id, transitioned.pipeline, sqlc.arg(from_status), sqlc.arg(to_status), sqlc.arg(actor)::text, sqlc.arg(reason)::text
+    FROM transitioned
+)
+SELECT project_id, pipeline, status, updated_at
+FROM transitioned;
@@ -9,20 +9,40 @@ FROM projects
 ORDER BY created_at DESC;
 
 -- name: CreateProject :one
-INSERT INTO projects (name, status)
-VALUES ($1, $2)
-RETURNING id, name, created_at, status;
+-- Создает проект вместе с его конвейерами обработки в статусе "ready"
+WITH project AS (
+    INSERT INTO projects (name, status)
+    VALUES (sqlc.arg(name), sqlc.arg(status))
+    RETURNING id, name, created_at, status
+), pipelines AS (
+    INSERT INTO project_pipelines (project_id, pipeline)
+    SELECT project.id, pipeline
+    FROM project, unnest(sqlc.arg(pipelines)::text[]) AS pipeline
+)
+SELECT id, name, created_at, status
+FROM project;
+
+-- name: CreateRemark :one
+INSERT INTO remarks (project_id, direction, section, subsection, content, duplicates)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, project_id, direction, section, subsection, content, created_at, duplicates;
+
+-- name: DeleteRemarksByProject :exec
+DELETE FROM remarks
+WHERE project_id = $1;
+
+-- name: GetRemarksByProject :many
+SELECT id, project_id, direction, section, subsection, content, created_at, duplicates
+FROM remarks
+WHERE project_id = $1
+ORDER BY created_at DESC, id DESC;
 
--- name: UpdateProjectStatus :one
-UPDATE projects 
-SET status = $2
-WHERE id = $1
-RETURNING id, name, created_at, status;
+-- name: PublishProjectEvent :exec
+-- Публикует событие проекта (прогресс задачи) в канал project_events
+SELECT pg_notify('project_events', sqlc.arg(payload)::text);
 
--- name: CheckAndUpdateProjectStatus :one
--- Атомарно проверяет статус проекта и обновляет его, если он "ready"
--- Возвращает ошибку, если статус не "ready"
-UPDATE projects 
-SET status = $2
-WHERE id = $1 AND status = 'ready'
-RETURNING id, name, created_at, status;
+-- name: ListProjectStatusHistory :many
+SELECT id, project_id, from_status, to_status, actor, reason, created_at, pipeline
+FROM project_status_history
+WHERE project_id = $1
+ORDER BY created_at, id;
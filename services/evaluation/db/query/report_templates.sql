@@ -0,0 +1,68 @@
+-- name: CreateReportTemplate :one
+INSERT INTO report_templates (name, organization, remarks_intro, remarks_conclusion, final_report_intro, final_report_conclusion)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, name, organization, remarks_intro, remarks_conclusion, final_report_intro, final_report_conclusion, is_default, created_at, updated_at;
+
+-- name: GetReportTemplate :one
+SELECT id, name, organization, remarks_intro, remarks_conclusion, final_report_intro, final_report_conclusion, is_default, created_at, updated_at
+FROM report_templates
+WHERE id = $1;
+
+-- name: ListReportTemplates :many
+SELECT id, name, organization, remarks_intro, remarks_conclusion, final_report_intro, final_report_conclusion, is_default, created_at, updated_at
+FROM report_templates
+ORDER BY name;
+
+-- name: UpdateReportTemplate :one
+UPDATE report_templates
+SET name = $2,
+    organization = $3,
+    remarks_intro = $4,
+    remarks_conclusion = $5,
+    final_report_intro = $6,
+    final_report_conclusion = $7,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, organization, remarks_intro, remarks_conclusion, final_report_intro, final_report_conclusion, is_default, created_at, updated_at;
+
+-- name: DeleteReportTemplate :execrows
+DELETE FROM report_templates
+WHERE id = $1;
+
+-- name: SetDefaultReportTemplate :execrows
+-- Делает шаблон шаблоном по умолчанию, снимая признак с предыдущего одним запросом
+UPDATE report_templates
+SET is_default = (id = $1),
+    updated_at = NOW()
+WHERE is_default OR id = $1;
+
+-- name: UnsetDefaultReportTemplate :exec
+UPDATE report_templates
+SET is_default = FALSE,
+    updated_at = NOW()
+WHERE id = $1 AND is_default;
+
+-- name: SetProjectReportTemplate :exec
+INSERT INTO project_report_templates (project_id, template_id)
+VALUES ($1, $2)
+ON CONFLICT (project_id) DO UPDATE
+SET template_id = EXCLUDED.template_id,
+    updated_at = NOW();
+
+-- name: ClearProjectReportTemplate :exec
+DELETE FROM project_report_templates
+WHERE project_id = $1;
+
+-- name: GetProjectReportTemplateID :one
+SELECT template_id
+FROM project_report_templates
+WHERE project_id = $1;
+
+-- name: GetEffectiveReportTemplate :one
+-- Получает шаблон, выбранный для проекта, а если он не выбран - шаблон по умолчанию
+SELECT t.id, t.name, t.organization, t.remarks_intro, t.remarks_conclusion, t.final_report_intro, t.final_report_conclusion, t.is_default, t.created_at, t.updated_at
+FROM report_templates t
+LEFT JOIN project_report_templates p ON p.template_id = t.id AND p.project_id = $1
+WHERE p.project_id IS NOT NULL OR t.is_default
+ORDER BY p.project_id IS NULL
+LIMIT 1;
@@ -4,6 +4,7 @@ import (
 	"context"
 	"evaluation/internal/config"
 	"evaluation/internal/postgres"
+	"evaluation/internal/reports"
 	"evaluation/internal/repository"
 	"evaluation/internal/server"
 	"evaluation/internal/services"
@@ -15,12 +16,15 @@ import (
 )
 
 type App struct {
-	Config      *config.Config
-	PgClient    *postgres.Client
-	Repo        *repository.Repository
-	Server      *server.Server
-	Storage     storage.FileStorage
-	TaskManager tasks.TaskManager
+	Config          *config.Config
+	PgClient        *postgres.Client
+	Repo            *repository.Repository
+	Server          *server.Server
+	Storage         storage.FileStorage
+	TaskManager     tasks.TaskManager
+	RecoveryService services.RecoveryService
+	EventService    services.EventService
+	EventListener   *postgres.Listener
 }
 
 func New() (*App, error) {
@@ -51,30 +55,109 @@ func New() (*App, error) {
 	// Создаем репозиторий
 	repo := repository.New(pgClient)
 
-	// Создаем TaskManager
-	taskManager := tasks.NewTaskManager(1) // Один воркер для последовательного выполнения
+	// Подписываемся на события проектов, опубликованные любым экземпляром сервиса
+	eventListener, err := postgres.NewListener(&cfg.Postgres, "project_events")
+	if err != nil {
+		return nil, err
+	}
+	eventService := services.NewEventService(repo, eventListener)
+
+	// Создаем TaskManager поверх персистентной очереди в PostgreSQL
+	taskManager := tasks.NewTaskManager(repo, tasks.ManagerConfig{
+		WorkerCount:      cfg.Tasks.WorkerCount,
+		PollInterval:     cfg.Tasks.PollInterval,
+		LockTimeout:      cfg.Tasks.LockTimeout,
+		PriorityAging:    cfg.Tasks.PriorityAging,
+		KindConcurrency:  cfg.Tasks.KindConcurrency,
+		ScheduleInterval: cfg.Tasks.ScheduleInterval,
+	})
+
+	// Загружаем шаблон оформления отчетов
+	reportTemplate, err := reports.LoadTemplate(cfg.Reports.TemplateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверяем конфигурацию RAG при старте, а не при первой генерации чек-листа
+	ragConfig := tasks.DefaultRAGConfig()
+	ragConfig.LLMAPIURL = cfg.RAG.LLMAPIURL
+	ragConfig.LLMModelName = cfg.RAG.LLMModel
+	ragConfig.TopK = cfg.RAG.TopK
+	ragConfig.MaxChunkSize = cfg.RAG.MaxChunkSize
+	ragConfig.ChunkOverlap = cfg.RAG.ChunkOverlap
+	ragConfig.Retriever = cfg.RAG.Retriever
+	ragConfig.EmbeddingAPIURL = cfg.RAG.EmbeddingAPIURL
+	ragConfig.EmbeddingModel = cfg.RAG.EmbeddingModel
+	ragConfig.EmbeddingAPIKey = cfg.RAG.EmbeddingAPIKey
+	if _, err := tasks.NewRetriever(ragConfig); err != nil {
+		return nil, fmt.Errorf("invalid RAG configuration: %w", err)
+	}
+
+	// Регистрируем фабрики для восстановления задач из очереди
+	processorFactory := tasks.NewProjectProcessorTaskFactory(repo, fileStorage, eventService, reportTemplate, ragConfig)
+	taskManager.RegisterTaskFactory(tasks.TaskKindRemarks, processorFactory)
+	taskManager.RegisterTaskFactory(tasks.TaskKindChecklist, processorFactory)
+	taskManager.RegisterTaskFactory(tasks.TaskKindFinalReport, processorFactory)
+	taskManager.RegisterTaskFactory(tasks.TaskKindDocumentIndex, tasks.NewDocumentIndexTaskFactory(repo, fileStorage, ragConfig))
+
+	// Задачи обработки проекта повторяются при временных ошибках внешних сервисов
+	// Чек-лист повторяется не больше одного раза: он делает десятки долгих запросов к LLM
+	retryPolicy := tasks.RetryPolicy{
+		MaxAttempts:    cfg.Tasks.Retry.MaxAttempts,
+		InitialBackoff: cfg.Tasks.Retry.InitialBackoff,
+		MaxBackoff:     cfg.Tasks.Retry.MaxBackoff,
+		Multiplier:     2,
+	}
+	checklistRetryPolicy := retryPolicy
+	checklistRetryPolicy.MaxAttempts = min(retryPolicy.MaxAttempts, 2)
+	taskManager.SetRetryPolicy(tasks.TaskKindRemarks, retryPolicy)
+	taskManager.SetRetryPolicy(tasks.TaskKindChecklist, checklistRetryPolicy)
+	taskManager.SetRetryPolicy(tasks.TaskKindFinalReport, retryPolicy)
+	taskManager.SetRetryPolicy(tasks.TaskKindDocumentIndex, retryPolicy)
 
 	// Создаем сервисы
 	projectService := services.NewProjectService(repo)
 	fileService := services.NewFileService(repo, fileStorage, taskManager, pgClient)
 	healthService := services.NewHealthService(pgClient)
+	jobService := services.NewJobService(repo, taskManager)
+	recoveryService := services.NewRecoveryService(repo, fileStorage, taskManager, cfg.Tasks.RecoveryMode, cfg.Tasks.LockTimeout)
+	scheduleService := services.NewScheduleService(repo, fileService)
+	reportTemplateService := services.NewReportTemplateService(repo)
+
+	// Задачи по расписанию занимают проект так же, как запущенные пользователем
+	taskManager.SetScheduleHandler(scheduleService.RunSchedule)
 
 	// Создаем HTTP сервер
-	srv := server.New(cfg, projectService, fileService, healthService, taskManager)
+	srv := server.New(cfg, projectService, fileService, healthService, jobService, recoveryService, eventService, scheduleService, reportTemplateService, taskManager)
 
 	return &App{
-		Config:      cfg,
-		PgClient:    pgClient,
-		Repo:        repo,
-		Server:      srv,
-		Storage:     fileStorage,
-		TaskManager: taskManager,
+		Config:          cfg,
+		PgClient:        pgClient,
+		Repo:            repo,
+		Server:          srv,
+		Storage:         fileStorage,
+		TaskManager:     taskManager,
+		RecoveryService: recoveryService,
+		EventService:    eventService,
+		EventListener:   eventListener,
 	}, nil
 }
 
 func (a *App) Start() error {
-	// Запускаем TaskManager
 	ctx := context.Background()
+
+	// Восстанавливаем конвейеры проектов, оставшиеся в статусе обработки после падения сервиса
+	recovered, err := a.RecoveryService.RecoverStuckProjects(ctx)
+	if err != nil {
+		log.Printf("Failed to recover stuck projects: %v", err)
+	} else if recovered > 0 {
+		log.Printf("Recovered %d stuck project pipelines", recovered)
+	}
+
+	// Запускаем рассылку событий проектов
+	a.EventService.Start(ctx)
+
+	// Запускаем TaskManager
 	if err := a.TaskManager.Start(ctx); err != nil {
 		return fmt.Errorf("failed to start task manager: %w", err)
 	}
@@ -91,5 +174,9 @@ func (a *App) Close() error {
 		log.Printf("Failed to stop task manager: %v", err)
 	}
 
+	if err := a.EventListener.Close(); err != nil {
+		log.Printf("Failed to close event listener: %v", err)
+	}
+
 	return a.PgClient.Close()
 }
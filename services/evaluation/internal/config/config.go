@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,9 @@ type Config struct {
 	Postgres PostgresConfig `yaml:"postgresql"`
 	Logging  LoggingConfig  `yaml:"logging"`
 	MinIO    MinIOConfig    `yaml:"minio"`
+	Tasks    TasksConfig    `yaml:"tasks"`
+	Reports  ReportsConfig  `yaml:"reports"`
+	RAG      RAGConfig      `yaml:"rag"`
 }
 
 type ServerConfig struct {
@@ -45,6 +49,47 @@ type MinIOConfig struct {
 	Region     string `yaml:"region"`
 }
 
+type TasksConfig struct {
+	WorkerCount   int           `yaml:"worker_count"`
+	PollInterval  time.Duration `yaml:"poll_interval"`
+	LockTimeout   time.Duration `yaml:"lock_timeout"`
+	PriorityAging time.Duration `yaml:"priority_aging"` // за это время ожидания приоритет задачи повышается на 1
+	RecoveryMode  string        `yaml:"recovery_mode"`  // reset | requeue - что делать с зависшими проектами при старте
+	Retry         RetryConfig   `yaml:"retry"`
+	// KindConcurrency ограничение одновременно выполняемых задач по типу, например checklist=1
+	KindConcurrency map[string]int `yaml:"kind_concurrency"`
+	// ScheduleInterval интервал проверки наступивших расписаний задач
+	ScheduleInterval time.Duration `yaml:"schedule_interval"`
+}
+
+// RetryConfig политика повтора задач при временных ошибках
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+// ReportsConfig оформление генерируемых отчетов
+type ReportsConfig struct {
+	// TemplateFile JSON файл шаблона отчетов, пустое значение - шаблон по умолчанию
+	TemplateFile string `yaml:"template_file"`
+}
+
+// RAGConfig проверка документации по чек-листу: LLM и поиск по документации
+type RAGConfig struct {
+	LLMAPIURL    string `yaml:"llm_api_url"`
+	LLMModel     string `yaml:"llm_model"`
+	TopK         int    `yaml:"top_k"`
+	MaxChunkSize int    `yaml:"max_chunk_size"`
+	ChunkOverlap int    `yaml:"chunk_overlap"`
+	// Retriever способ поиска чанков: keyword (BM25), embedding или hybrid (keyword + embedding)
+	Retriever string `yaml:"retriever"`
+	// EmbeddingAPIURL адрес метода эмбеддингов OpenAI (/v1/embeddings) или Ollama (/api/embed), нужен для embedding и hybrid
+	EmbeddingAPIURL string `yaml:"embedding_api_url"`
+	EmbeddingModel  string `yaml:"embedding_model"`
+	EmbeddingAPIKey string `yaml:"embedding_api_key"`
+}
+
 type LoggingConfig struct {
 	Level string `yaml:"level"`
 }
@@ -81,6 +126,36 @@ func Load() (*Config, error) {
 			UseSSL:     getEnvAsBool("MINIO_USE_SSL", false),
 			Region:     getEnv("MINIO_REGION", "us-east-1"),
 		},
+		Tasks: TasksConfig{
+			WorkerCount:   getEnvAsInt("TASK_WORKER_COUNT", 4),
+			PollInterval:  getEnvAsDuration("TASK_POLL_INTERVAL", 2*time.Second),
+			LockTimeout:   getEnvAsDuration("TASK_LOCK_TIMEOUT", 2*time.Minute),
+			PriorityAging: getEnvAsDuration("TASK_PRIORITY_AGING", time.Minute),
+			RecoveryMode:  getEnv("TASK_RECOVERY_MODE", "reset"),
+			KindConcurrency: getEnvAsIntMap("TASK_KIND_CONCURRENCY", map[string]int{
+				"checklist": 1,
+			}),
+			ScheduleInterval: getEnvAsDuration("TASK_SCHEDULE_INTERVAL", 30*time.Second),
+			Retry: RetryConfig{
+				MaxAttempts:    getEnvAsInt("TASK_RETRY_MAX_ATTEMPTS", 3),
+				InitialBackoff: getEnvAsDuration("TASK_RETRY_INITIAL_BACKOFF", 10*time.Second),
+				MaxBackoff:     getEnvAsDuration("TASK_RETRY_MAX_BACKOFF", 5*time.Minute),
+			},
+		},
+		Reports: ReportsConfig{
+			TemplateFile: getEnv("REPORT_TEMPLATE_FILE", ""),
+		},
+		RAG: RAGConfig{
+			LLMAPIURL:       getEnv("RAG_LLM_API_URL", "http://89.108.116.240:11434/api/chat"),
+			LLMModel:        getEnv("RAG_LLM_MODEL", "qwen3-8b:latest"),
+			TopK:            getEnvAsInt("RAG_TOP_K", 5),
+			MaxChunkSize:    getEnvAsInt("RAG_MAX_CHUNK_SIZE", 700),
+			ChunkOverlap:    getEnvAsInt("RAG_CHUNK_OVERLAP", 150),
+			Retriever:       getEnv("RAG_RETRIEVER", "keyword"),
+			EmbeddingAPIURL: getEnv("RAG_EMBEDDING_API_URL", ""),
+			EmbeddingModel:  getEnv("RAG_EMBEDDING_MODEL", "bge-m3"),
+			EmbeddingAPIKey: getEnv("RAG_EMBEDDING_API_KEY", ""),
+		},
 		Logging: LoggingConfig{
 			Level: getEnv("LOG_LEVEL", "info"),
 		},
@@ -114,6 +189,29 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// getEnvAsIntMap разбирает значение вида "key1=1,key2=2"
+// Некорректные элементы пропускаются
+func getEnvAsIntMap(key string, defaultValue map[string]int) map[string]int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	result := make(map[string]int)
+	for _, pair := range strings.Split(value, ",") {
+		name, number, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		intValue, err := strconv.Atoi(strings.TrimSpace(number))
+		if err != nil {
+			continue
+		}
+		result[strings.TrimSpace(name)] = intValue
+	}
+	return result
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
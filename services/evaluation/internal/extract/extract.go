@@ -0,0 +1,118 @@
+// Package extract извлечение текста из файлов документации для проверки по чек-листу
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrUnsupportedFormat формат файла не поддерживается ни одним извлекателем
+var ErrUnsupportedFormat = errors.New("unsupported document format")
+
+// Section фрагмент текста документа и его место в документе
+type Section struct {
+	// Page номер страницы PDF или слайда PPTX, начиная с 1; 0 - документ без деления на страницы
+	Page int
+	// Location подпись места для источников, например "стр. 3", "слайд 2" или "лист Запасы"
+	Location string
+	Text     string
+}
+
+// Extractor извлекает текст из содержимого файла
+type Extractor interface {
+	Extract(content []byte) ([]Section, error)
+}
+
+// ExtractorFunc позволяет использовать функцию как Extractor
+type ExtractorFunc func(content []byte) ([]Section, error)
+
+// Extract вызывает f(content)
+func (f ExtractorFunc) Extract(content []byte) ([]Section, error) {
+	return f(content)
+}
+
+// Registry извлекатели текста по расширению файла
+type Registry struct {
+	extractors map[string]Extractor
+}
+
+// NewRegistry создает реестр с извлекателями для текстовых файлов, HTML, PDF, DOCX, PPTX и XLSX
+func NewRegistry() *Registry {
+	r := &Registry{extractors: make(map[string]Extractor)}
+	for _, ext := range []string{".txt", ".md", ".csv"} {
+		r.Register(ext, ExtractorFunc(extractPlainText))
+	}
+	r.Register(".html", ExtractorFunc(extractHTML))
+	r.Register(".htm", ExtractorFunc(extractHTML))
+	r.Register(".pdf", ExtractorFunc(extractPDF))
+	r.Register(".docx", ExtractorFunc(extractDOCX))
+	r.Register(".pptx", ExtractorFunc(extractPPTX))
+	r.Register(".xlsx", ExtractorFunc(extractXLSX))
+	return r
+}
+
+// Register задает извлекатель для расширения, заменяя зарегистрированный ранее
+func (r *Registry) Register(ext string, extractor Extractor) {
+	r.extractors[normalizeExt(ext)] = extractor
+}
+
+// Supports сообщает, есть ли извлекатель для расширения файла
+func (r *Registry) Supports(filename string) bool {
+	_, ok := r.extractors[normalizeExt(filepath.Ext(filename))]
+	return ok
+}
+
+// Extract извлекает текст из файла извлекателем, выбранным по расширению имени файла
+// Пустые фрагменты отбрасываются
+func (r *Registry) Extract(filename string, file io.Reader) ([]Section, error) {
+	ext := normalizeExt(filepath.Ext(filename))
+	extractor, ok := r.extractors[ext]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, ext)
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	sections, err := extractor.Extract(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract text from %s: %w", filename, err)
+	}
+
+	result := sections[:0]
+	for _, section := range sections {
+		section.Text = strings.TrimSpace(section.Text)
+		if section.Text != "" {
+			result = append(result, section)
+		}
+	}
+	return result, nil
+}
+
+// normalizeExt приводит расширение к виду ".ext" в нижнем регистре
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// extractPlainText возвращает текстовый файл целиком, некорректные UTF-8 последовательности отбрасываются
+func extractPlainText(content []byte) ([]Section, error) {
+	return []Section{{Text: strings.ToValidUTF8(string(content), "")}}, nil
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// extractHTML возвращает текст HTML файла без тегов
+func extractHTML(content []byte) ([]Section, error) {
+	text := htmlTagPattern.ReplaceAllString(strings.ToValidUTF8(string(content), ""), "")
+	return []Section{{Text: text}}, nil
+}
@@ -0,0 +1,137 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"evaluation/internal/utils"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestRegistry_PlainTextAndHTML(t *testing.T) {
+	registry := NewRegistry()
+
+	sections, err := registry.Extract("notes.TXT", strings.NewReader("  Пояснительная записка\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []Section{{Text: "Пояснительная записка"}}, sections)
+
+	sections, err = registry.Extract("page.html", strings.NewReader("<p>Запасы <b>нефти</b></p>"))
+	require.NoError(t, err)
+	assert.Equal(t, []Section{{Text: "Запасы нефти"}}, sections)
+}
+
+func TestRegistry_UnsupportedFormat(t *testing.T) {
+	registry := NewRegistry()
+
+	assert.False(t, registry.Supports("model.bin"))
+	_, err := registry.Extract("model.bin", bytes.NewReader([]byte{0x00, 0x01}))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = registry.Extract("model", strings.NewReader("text"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("LAS", ExtractorFunc(func(content []byte) ([]Section, error) {
+		return []Section{{Location: "кривая GR", Text: string(content)}, {Text: "  "}}, nil
+	}))
+
+	assert.True(t, registry.Supports("well.las"))
+	sections, err := registry.Extract("well.las", strings.NewReader("данные ГИС"))
+	require.NoError(t, err)
+	// Пустые фрагменты отбрасываются
+	assert.Equal(t, []Section{{Location: "кривая GR", Text: "данные ГИС"}}, sections)
+}
+
+func TestExtractPDF_Pages(t *testing.T) {
+	// Встроенный шрифт: ToUnicode шрифтов gofpdf с диапазоном <0000> <FFFF> библиотека разбирает неверно
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 12)
+	for _, text := range []string{"Technical specification", "Field appraisal program"} {
+		pdf.AddPage()
+		pdf.Cell(0, 10, text)
+	}
+	var buffer bytes.Buffer
+	require.NoError(t, pdf.Output(&buffer))
+
+	sections, err := NewRegistry().Extract("doc.pdf", &buffer)
+	require.NoError(t, err)
+	require.Len(t, sections, 2)
+	assert.Equal(t, 1, sections[0].Page)
+	assert.Equal(t, "стр. 1", sections[0].Location)
+	assert.Contains(t, sections[0].Text, "Technical specification")
+	assert.Equal(t, 2, sections[1].Page)
+	assert.Equal(t, "стр. 2", sections[1].Location)
+	assert.Contains(t, sections[1].Text, "Field appraisal program")
+}
+
+func TestExtractPDF_Malformed(t *testing.T) {
+	_, err := NewRegistry().Extract("broken.pdf", strings.NewReader("%PDF-1.4 not really a pdf"))
+	assert.Error(t, err)
+}
+
+func TestExtractDOCX_ParagraphsAndTables(t *testing.T) {
+	doc := utils.NewDocxDocument()
+	doc.Heading(1, "Проектная документация")
+	doc.Paragraph("Раздел 1.\nИсходные данные")
+	doc.Table([]string{"Показатель", "Значение"}, nil, [][]string{{"Запасы", "120 млн т"}, {"", ""}})
+	buffer, err := doc.Bytes()
+	require.NoError(t, err)
+
+	sections, err := NewRegistry().Extract("project.docx", buffer)
+	require.NoError(t, err)
+	require.Len(t, sections, 1)
+	assert.Equal(t, "Проектная документация\nРаздел 1.\nИсходные данные\nПоказатель | Значение\nЗапасы | 120 млн т", sections[0].Text)
+}
+
+func TestExtractPPTX_Slides(t *testing.T) {
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	// Номера в именах файлов не обязаны идти подряд, слайды нумеруются по порядку
+	for _, slide := range []struct {
+		name  string
+		lines []string
+	}{
+		{"ppt/slides/slide10.xml", []string{"Выводы"}},
+		{"ppt/slides/slide2.xml", []string{"Геологическая модель", "Структурные карты"}},
+		{"ppt/slides/_rels/slide2.xml.rels", nil},
+	} {
+		f, err := archive.Create(slide.name)
+		require.NoError(t, err)
+		var body strings.Builder
+		for _, line := range slide.lines {
+			fmt.Fprintf(&body, `<a:p><a:r><a:t>%s</a:t></a:r></a:p>`, line)
+		}
+		fmt.Fprintf(f, `<p:sld xmlns:p="p" xmlns:a="a"><p:txBody>%s</p:txBody></p:sld>`, body.String())
+	}
+	require.NoError(t, archive.Close())
+
+	sections, err := NewRegistry().Extract("slides.pptx", &buffer)
+	require.NoError(t, err)
+	assert.Equal(t, []Section{
+		{Page: 1, Location: "слайд 1", Text: "Геологическая модель\nСтруктурные карты"},
+		{Page: 2, Location: "слайд 2", Text: "Выводы"},
+	}, sections)
+}
+
+func TestExtractXLSX_Sheets(t *testing.T) {
+	f := excelize.NewFile()
+	require.NoError(t, f.SetSheetRow("Sheet1", "A1", &[]string{"Скважина", "", "Дебит"}))
+	require.NoError(t, f.SetSheetRow("Sheet1", "A2", &[]any{"101", "", 35}))
+	_, err := f.NewSheet("Пустой")
+	require.NoError(t, err)
+	buffer, err := f.WriteToBuffer()
+	require.NoError(t, err)
+
+	sections, err := NewRegistry().Extract("wells.xlsx", buffer)
+	require.NoError(t, err)
+	assert.Equal(t, []Section{{Location: "лист Sheet1", Text: "Скважина | Дебит\n101 | 35"}}, sections)
+}
@@ -0,0 +1,227 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// extractDOCX извлекает абзацы и таблицы документа Word
+// Строка таблицы становится строкой текста с ячейками через " | "
+func extractDOCX(content []byte) ([]Section, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DOCX: %w", err)
+	}
+
+	document, err := readZipFile(archive, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := docxText(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DOCX: %w", err)
+	}
+	return []Section{{Text: text}}, nil
+}
+
+// docxTable строка и ячейка таблицы, собираемые при разборе
+type docxTable struct {
+	row  []string
+	cell []string
+}
+
+// docxText собирает текст document.xml: абзацы вне таблиц - отдельные строки,
+// строки вложенных таблиц попадают в ячейку внешней таблицы
+func docxText(document []byte) (string, error) {
+	var (
+		lines     []string
+		paragraph strings.Builder
+		tables    []*docxTable
+		inText    bool
+	)
+
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				paragraph.WriteString("\t")
+			case "br", "cr":
+				paragraph.WriteString("\n")
+			case "tbl":
+				tables = append(tables, &docxTable{})
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text := strings.TrimSpace(paragraph.String())
+				paragraph.Reset()
+				if text == "" {
+					continue
+				}
+				if len(tables) > 0 {
+					table := tables[len(tables)-1]
+					table.cell = append(table.cell, text)
+				} else {
+					lines = append(lines, text)
+				}
+			case "tc":
+				if len(tables) > 0 {
+					table := tables[len(tables)-1]
+					table.row = append(table.row, strings.Join(table.cell, " "))
+					table.cell = nil
+				}
+			case "tr":
+				if len(tables) == 0 {
+					continue
+				}
+				table := tables[len(tables)-1]
+				row := strings.Join(table.row, " | ")
+				table.row = nil
+				if strings.Trim(row, " |") == "" {
+					continue
+				}
+				if len(tables) > 1 {
+					parent := tables[len(tables)-2]
+					parent.cell = append(parent.cell, row)
+				} else {
+					lines = append(lines, row)
+				}
+			case "tbl":
+				if len(tables) > 0 {
+					tables = tables[:len(tables)-1]
+				}
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// extractPPTX извлекает текст слайдов презентации, каждый слайд - отдельный фрагмент
+func extractPPTX(content []byte) ([]Section, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PPTX: %w", err)
+	}
+
+	// Слайды хранятся как ppt/slides/slideN.xml, порядок определяется номером N
+	type slide struct {
+		number int
+		name   string
+	}
+	var slides []slide
+	for _, f := range archive.File {
+		dir, name := path.Split(f.Name)
+		if dir != "ppt/slides/" || !strings.HasPrefix(name, "slide") || path.Ext(name) != ".xml" {
+			continue
+		}
+		number, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "slide"), ".xml"))
+		if err != nil {
+			continue
+		}
+		slides = append(slides, slide{number: number, name: f.Name})
+	}
+	sort.Slice(slides, func(i, j int) bool { return slides[i].number < slides[j].number })
+
+	sections := make([]Section, 0, len(slides))
+	for i, s := range slides {
+		data, err := readZipFile(archive, s.name)
+		if err != nil {
+			return nil, err
+		}
+		text, err := drawingMLText(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse slide %d: %w", s.number, err)
+		}
+		sections = append(sections, Section{Page: i + 1, Location: fmt.Sprintf("слайд %d", i+1), Text: text})
+	}
+	return sections, nil
+}
+
+// drawingMLText собирает текст абзацев a:p слайда, каждый абзац - отдельная строка
+func drawingMLText(data []byte) (string, error) {
+	var (
+		lines     []string
+		paragraph strings.Builder
+		inText    bool
+	)
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "br":
+				paragraph.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				if text := strings.TrimSpace(paragraph.String()); text != "" {
+					lines = append(lines, text)
+				}
+				paragraph.Reset()
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// readZipFile читает файл из архива Office Open XML
+func readZipFile(archive *zip.Reader, name string) ([]byte, error) {
+	f, err := archive.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,37 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// extractPDF извлекает текст PDF постранично, каждая страница - отдельный фрагмент
+func extractPDF(content []byte) (sections []Section, err error) {
+	// Разбор поврежденных файлов может паниковать внутри библиотеки
+	defer func() {
+		if r := recover(); r != nil {
+			sections, err = nil, fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+
+		text, err := page.GetPlainText(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PDF page %d: %w", i, err)
+		}
+		sections = append(sections, Section{Page: i, Location: fmt.Sprintf("стр. %d", i), Text: text})
+	}
+	return sections, nil
+}
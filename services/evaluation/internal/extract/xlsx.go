@@ -0,0 +1,42 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// extractXLSX извлекает строки листов книги Excel, каждый лист - отдельный фрагмент
+// Строка листа становится строкой текста с непустыми ячейками через " | "
+func extractXLSX(content []byte) ([]Section, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX: %w", err)
+	}
+	defer f.Close()
+
+	var sections []Section
+	for _, sheet := range f.GetSheetList() {
+		rows, err := f.GetRows(sheet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet %s: %w", sheet, err)
+		}
+
+		lines := make([]string, 0, len(rows))
+		for _, row := range rows {
+			cells := make([]string, 0, len(row))
+			for _, cell := range row {
+				if cell = strings.TrimSpace(cell); cell != "" {
+					cells = append(cells, cell)
+				}
+			}
+			if len(cells) > 0 {
+				lines = append(lines, strings.Join(cells, " | "))
+			}
+		}
+		sections = append(sections, Section{Location: "лист " + sheet, Text: strings.Join(lines, "\n")})
+	}
+	return sections, nil
+}
@@ -15,22 +15,37 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/health": {
+        "/admin/jobs/dead": {
             "get": {
-                "description": "Проверка состояния сервиса и подключения к базе данных",
+                "description": "Get jobs that exhausted their retry attempts, newest first",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Health check",
-                "operationId": "health",
+                "summary": "List dead-letter jobs",
+                "operationId": "listDeadJobs",
                 "responses": {
                     "200": {
-                        "description": "Service health status",
+                        "description": "List of dead jobs",
                         "schema": {
-                            "$ref": "#/definitions/handler.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.JobResponse"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "500": {
@@ -42,65 +57,59 @@ const docTemplate = `{
                 }
             }
         },
-        "/projects": {
-            "get": {
-                "description": "Get list of all projects",
+        "/admin/jobs/{job_id}/requeue": {
+            "post": {
+                "description": "Return a job from dead-letter to the queue with a reset attempt counter. The project is switched back to the processing status of the job",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get all projects",
-                "operationId": "listProjects",
+                "summary": "Requeue dead-letter job",
+                "operationId": "requeueJob",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID (UUID)",
+                        "name": "job_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "List of projects",
+                        "description": "Job requeued",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/db.Project"
-                            }
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "$ref": "#/definitions/models.JobResponse"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "400": {
+                        "description": "Bad request - invalid job ID",
                         "schema": {
                             "$ref": "#/definitions/handler.Error"
                         }
-                    }
-                }
-            },
-            "post": {
-                "description": "Create a new project with name",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "summary": "Create new project",
-                "operationId": "createProject",
-                "parameters": [
-                    {
-                        "description": "Project data",
-                        "name": "project",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CreateProjectRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Project created successfully",
+                    },
+                    "404": {
+                        "description": "Job not found",
                         "schema": {
-                            "$ref": "#/definitions/db.Project"
+                            "$ref": "#/definitions/handler.Error"
                         }
                     },
-                    "400": {
-                        "description": "Bad request - invalid input data",
+                    "409": {
+                        "description": "Job is not in dead-letter or project is already being processed",
                         "schema": {
                             "$ref": "#/definitions/handler.Error"
                         }
@@ -114,17 +123,17 @@ const docTemplate = `{
                 }
             }
         },
-        "/projects/{id}": {
-            "get": {
-                "description": "Get specific project by its ID",
+        "/admin/projects/{id}/reset": {
+            "post": {
+                "description": "Return a project stuck in a processing status to ready. Active jobs of the project are marked as failed, the reason is recorded",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get project by ID",
-                "operationId": "getProject",
+                "summary": "Force-reset project status",
+                "operationId": "resetProject",
                 "parameters": [
                     {
                         "type": "integer",
@@ -132,17 +141,25 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Reset reason",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ResetProjectRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Project found",
+                        "description": "Project reset to ready",
                         "schema": {
                             "$ref": "#/definitions/db.Project"
                         }
                     },
                     "400": {
-                        "description": "Bad request - invalid project ID",
+                        "description": "Bad request - invalid project ID or body",
                         "schema": {
                             "$ref": "#/definitions/handler.Error"
                         }
@@ -153,6 +170,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.Error"
                         }
                     },
+                    "409": {
+                        "description": "Project status changed during reset",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -162,49 +185,37 @@ const docTemplate = `{
                 }
             }
         },
-        "/projects/{id}/checklist": {
+        "/admin/report_templates": {
             "get": {
-                "description": "Get checklist result for a specific project",
+                "description": "Get all report templates ordered by name",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get project checklist",
-                "operationId": "getChecklist",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Project ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                "summary": "List report templates",
+                "operationId": "listReportTemplates",
                 "responses": {
                     "200": {
-                        "description": "Checklist result",
-                        "schema": {
-                            "$ref": "#/definitions/handler.Response"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request - invalid project ID",
-                        "schema": {
-                            "$ref": "#/definitions/handler.Error"
-                        }
-                    },
-                    "404": {
-                        "description": "Project not found",
+                        "description": "List of report templates",
                         "schema": {
-                            "$ref": "#/definitions/handler.Error"
-                        }
-                    },
-                    "409": {
-                        "description": "Checklist is still being generated",
-                        "schema": {
-                            "$ref": "#/definitions/handler.Error"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.ReportTemplateResponse"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "500": {
@@ -216,45 +227,53 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Generate checklist for a specific project",
+                "description": "Upload a report template. Intro and conclusion texts are Go text/template sources with project placeholders: {{.ProjectName}}, {{date .GeneratedAt}}, {{date .ProjectCreatedAt}}, {{.Groups}}, {{.Remarks}}, {{.ChecklistCriteria}} and {{range .Sections}}{{.Title}}: {{.Groups}} / {{.Remarks}}{{end}}. Empty texts are taken from the service template. A default template is used for projects without a selected template",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Generate checklist for project",
-                "operationId": "generateChecklist",
+                "summary": "Create report template",
+                "operationId": "createReportTemplate",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Project ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Report template",
+                        "name": "template",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportTemplateRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Checklist generation started",
+                    "201": {
+                        "description": "Report template created",
                         "schema": {
-                            "$ref": "#/definitions/handler.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "$ref": "#/definitions/models.ReportTemplateResponse"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "Bad request - invalid project ID",
-                        "schema": {
-                            "$ref": "#/definitions/handler.Error"
-                        }
-                    },
-                    "404": {
-                        "description": "Project not found",
+                        "description": "Bad request - empty name or invalid template text",
                         "schema": {
                             "$ref": "#/definitions/handler.Error"
                         }
                     },
                     "409": {
-                        "description": "Project is not in ready status",
+                        "description": "Report template with this name already exists",
                         "schema": {
                             "$ref": "#/definitions/handler.Error"
                         }
@@ -268,54 +287,53 @@ const docTemplate = `{
                 }
             }
         },
-        "/projects/{id}/documentation": {
-            "post": {
-                "description": "Upload a documentation file to a specific project (max 50MB)",
+        "/admin/report_templates/{template_id}": {
+            "get": {
+                "description": "Get a report template by ID",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Upload documentation file to project",
-                "operationId": "uploadDocumentation",
+                "summary": "Get report template",
+                "operationId": "getReportTemplate",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Project ID",
-                        "name": "id",
+                        "description": "Report template ID",
+                        "name": "template_id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "Documentation file to upload",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Documentation file uploaded successfully",
+                    "200": {
+                        "description": "Report template",
                         "schema": {
-                            "$ref": "#/definitions/db.ProjectFile"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "$ref": "#/definitions/models.ReportTemplateResponse"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "Bad request - invalid input data",
+                        "description": "Bad request - invalid template ID",
                         "schema": {
                             "$ref": "#/definitions/handler.Error"
                         }
                     },
                     "404": {
-                        "description": "Project not found",
-                        "schema": {
-                            "$ref": "#/definitions/handler.Error"
-                        }
-                    },
-                    "409": {
-                        "description": "Project is not in ready status",
+                        "description": "Report template not found",
                         "schema": {
                             "$ref": "#/definitions/handler.Error"
                         }
@@ -327,49 +345,68 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/projects/{id}/final_report": {
-            "get": {
-                "description": "Get final report result for a specific project",
+            },
+            "put": {
+                "description": "Replace name, texts and default flag of a report template. Reports generated earlier are not regenerated",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get final report for project",
-                "operationId": "getFinalReport",
+                "summary": "Replace report template",
+                "operationId": "updateReportTemplate",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Project ID",
-                        "name": "id",
+                        "description": "Report template ID",
+                        "name": "template_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Report template",
+                        "name": "template",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportTemplateRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Final report result",
+                        "description": "Report template updated",
                         "schema": {
-                            "$ref": "#/definitions/handler.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "$ref": "#/definitions/models.ReportTemplateResponse"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "Bad request - invalid project ID",
+                        "description": "Bad request - invalid template ID, empty name or invalid template text",
                         "schema": {
                             "$ref": "#/definitions/handler.Error"
                         }
                     },
                     "404": {
-                        "description": "Project not found",
+                        "description": "Report template not found",
                         "schema": {
                             "$ref": "#/definitions/handler.Error"
                         }
                     },
                     "409": {
-                        "description": "Final report is still being generated",
+                        "description": "Report template with this name already exists",
                         "schema": {
                             "$ref": "#/definitions/handler.Error"
                         }
@@ -382,46 +419,37 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
-                "description": "Generate final report for a specific project",
+            "delete": {
+                "description": "Delete a report template. Projects that selected it return to the default template",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Generate final report for project",
-                "operationId": "generateFinalReport",
+                "summary": "Delete report template",
+                "operationId": "deleteReportTemplate",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Project ID",
-                        "name": "id",
+                        "description": "Report template ID",
+                        "name": "template_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Final report generation started",
-                        "schema": {
-                            "$ref": "#/definitions/handler.Response"
-                        }
+                    "204": {
+                        "description": "Report template deleted"
                     },
                     "400": {
-                        "description": "Bad request - invalid project ID",
+                        "description": "Bad request - invalid template ID",
                         "schema": {
                             "$ref": "#/definitions/handler.Error"
                         }
                     },
                     "404": {
-                        "description": "Project not found",
-                        "schema": {
-                            "$ref": "#/definitions/handler.Error"
-                        }
-                    },
-                    "409": {
-                        "description": "Project is already being processed",
+                        "description": "Report template not found",
                         "schema": {
                             "$ref": "#/definitions/handler.Error"
                         }
@@ -435,73 +463,210 @@ const docTemplate = `{
                 }
             }
         },
-        "/projects/{id}/remarks": {
-            "post": {
-                "description": "Upload a remarks file to a specific project (max 50MB)",
+        "/admin/tasks": {
+            "get": {
+                "description": "Get depth of the shared job queue and stats of this service instance: running jobs with elapsed time, per-kind outcome counts and latency percentiles over recent runs",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Upload remarks file to project",
-                "operationId": "uploadRemarks",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Project ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "Remarks file to upload",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
-                    }
-                ],
+                "summary": "Task manager stats",
+                "operationId": "getTaskStats",
                 "responses": {
-                    "202": {
-                        "description": "Remarks file uploaded successfully",
-                        "schema": {
-                            "$ref": "#/definitions/db.ProjectFile"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request - invalid input data",
-                        "schema": {
-                            "$ref": "#/definitions/handler.Error"
-                        }
-                    },
-                    "404": {
-                        "description": "Project not found",
-                        "schema": {
-                            "$ref": "#/definitions/handler.Error"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
+                    "200": {
+                        "description": "Task manager stats",
                         "schema": {
-                            "$ref": "#/definitions/handler.Error"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "$ref": "#/definitions/tasks.TaskStats"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     }
                 }
             }
         },
-        "/projects/{id}/remarks_clustered": {
+        "/health": {
             "get": {
-                "description": "Get clustered remarks result for a specific project",
+                "description": "Проверка состояния сервиса и подключения к базе данных",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get clustered remarks for project",
-                "operationId": "getRemarksClustered",
-                "parameters": [
+                "summary": "Health check",
+                "operationId": "health",
+                "responses": {
+                    "200": {
+                        "description": "Service health status",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{job_id}": {
+            "get": {
+                "description": "Get background job state together with its run history",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get job by ID",
+                "operationId": "getJob",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID (UUID)",
+                        "name": "job_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Job found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "$ref": "#/definitions/models.JobResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid job ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects": {
+            "get": {
+                "description": "Get list of all projects",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all projects",
+                "operationId": "listProjects",
+                "responses": {
+                    "200": {
+                        "description": "List of projects",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/db.Project"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new project with name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create new project",
+                "operationId": "createProject",
+                "parameters": [
+                    {
+                        "description": "Project data",
+                        "name": "project",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateProjectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Project created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/db.Project"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid input data",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}": {
+            "get": {
+                "description": "Get specific project by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get project by ID",
+                "operationId": "getProject",
+                "parameters": [
                     {
                         "type": "integer",
                         "description": "Project ID",
@@ -512,7 +677,55 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Clustered remarks result",
+                        "description": "Project found",
+                        "schema": {
+                            "$ref": "#/definitions/db.Project"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/checklist": {
+            "get": {
+                "description": "Get checklist result for a specific project",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get project checklist",
+                "operationId": "getChecklist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Checklist result",
                         "schema": {
                             "$ref": "#/definitions/handler.Response"
                         }
@@ -530,7 +743,59 @@ const docTemplate = `{
                         }
                     },
                     "409": {
-                        "description": "Remarks are still being processed",
+                        "description": "Checklist is still being generated",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Generate checklist for a specific project",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Generate checklist for project",
+                "operationId": "generateChecklist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Checklist generation started",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Project is not in ready status",
                         "schema": {
                             "$ref": "#/definitions/handler.Error"
                         }
@@ -543,111 +808,1508 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "db.FileType": {
-            "type": "string",
-            "enum": [
-                "documentation",
-                "checklist",
-                "remarks",
-                "remarks_clustered",
-                "final_report"
-            ],
-            "x-enum-varnames": [
-                "FileTypeDocumentation",
-                "FileTypeChecklist",
-                "FileTypeRemarks",
-                "FileTypeRemarksClustered",
-                "FileTypeFinalReport"
-            ]
         },
-        "db.Project": {
+        "/projects/{id}/documentation": {
+            "post": {
+                "description": "Upload a documentation file to a specific project (max 50MB)",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Upload documentation file to project",
+                "operationId": "uploadDocumentation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Documentation file to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Documentation file uploaded successfully",
+                        "schema": {
+                            "$ref": "#/definitions/db.ProjectFile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid input data",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Project is not in ready status",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/events": {
+            "get": {
+                "description": "Server-Sent Events stream of pipeline status transitions (event \"status\") and job progress messages (event \"progress\"). The current status of every project pipeline is sent first",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "summary": "Stream project events",
+                "operationId": "streamProjectEvents",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of project events",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProjectEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error - streaming is not supported",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/final_report": {
+            "get": {
+                "description": "Get final report result for a specific project",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get final report for project",
+                "operationId": "getFinalReport",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Final report result",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Final report is still being generated",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Generate final report for a specific project",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Generate final report for project",
+                "operationId": "generateFinalReport",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pdf",
+                            "docx"
+                        ],
+                        "type": "string",
+                        "default": "pdf",
+                        "description": "Final report format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Final report generation started",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project ID or report format",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Project is already being processed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/history": {
+            "get": {
+                "description": "Get all pipeline status transitions of a project in chronological order with actor and reason",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get project status history",
+                "operationId": "getProjectHistory",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status transitions",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.StatusTransitionResponse"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/jobs": {
+            "get": {
+                "description": "Get background jobs of a specific project, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List project jobs",
+                "operationId": "listProjectJobs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of jobs",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.JobResponse"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/jobs/{job_id}/cancel": {
+            "post": {
+                "description": "Cancel a queued or running job of a project. A running job is interrupted, the project returns to ready",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Cancel project job",
+                "operationId": "cancelJob",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Job ID (UUID)",
+                        "name": "job_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Job cancelled",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "$ref": "#/definitions/models.JobResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project or job ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Job is already finished",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/pipelines": {
+            "get": {
+                "description": "Get the status of every processing pipeline of a project (remarks, checklist, final_report); pipelines run independently of each other",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get project pipelines",
+                "operationId": "getProjectPipelines",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pipeline states",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.PipelineResponse"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/remarks": {
+            "post": {
+                "description": "Upload a remarks file to a specific project (max 50MB)",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Upload remarks file to project",
+                "operationId": "uploadRemarks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Remarks file to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pdf",
+                            "docx"
+                        ],
+                        "type": "string",
+                        "default": "pdf",
+                        "description": "Remarks report format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Remarks file uploaded successfully",
+                        "schema": {
+                            "$ref": "#/definitions/db.ProjectFile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid input data or report format",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/remarks_clustered": {
+            "get": {
+                "description": "Get clustered remarks result for a specific project.\nWith the format parameter the latest report in that format is downloaded as a file.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/octet-stream"
+                ],
+                "summary": "Get clustered remarks for project",
+                "operationId": "getRemarksClustered",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "xlsx",
+                            "pdf",
+                            "docx"
+                        ],
+                        "type": "string",
+                        "description": "Download the latest report file in this format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Clustered remarks result",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project ID or format",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Remarks are still being processed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/report_template": {
+            "get": {
+                "description": "Get the report template selected for a project and the template its reports are generated with. Without a selected template the default template applies, without a default template - the service template",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get project report template",
+                "operationId": "getProjectReportTemplate",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Project report template",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "$ref": "#/definitions/models.ProjectReportTemplateResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Select the report template of a project, null template_id returns the project to the default template. Applies to reports generated afterwards",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Select project report template",
+                "operationId": "setProjectReportTemplate",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Report template selection",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetProjectReportTemplateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Project report template",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "$ref": "#/definitions/models.ProjectReportTemplateResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project ID or body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project or report template not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/schedules": {
+            "get": {
+                "description": "Get recurring job schedules of a specific project",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List project schedules",
+                "operationId": "listSchedules",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of schedules",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.ScheduleResponse"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Schedule a recurring job of a project by a cron expression (5 fields or @hourly/@daily/@weekly/@monthly, evaluated in UTC). Supported kinds: checklist, final_report. A run is skipped if the project is being processed at that time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create project schedule",
+                "operationId": "createSchedule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Schedule data",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Schedule created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "body": {
+                                            "$ref": "#/definitions/models.ScheduleResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project ID, job kind or cron expression",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/schedules/{schedule_id}": {
+            "delete": {
+                "description": "Delete a recurring job schedule of a project. Jobs already started by the schedule are not affected",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete project schedule",
+                "operationId": "deleteSchedule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Schedule ID",
+                        "name": "schedule_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Schedule deleted"
+                    },
+                    "400": {
+                        "description": "Bad request - invalid project or schedule ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/remarks": {
+            "post": {
+                "description": "Get remarks for specific project and forward to external service",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "СТАРАЯ РУЧКА",
+                "operationId": "sendProjectRemarks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success response",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "project not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Error"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "db.FileType": {
+            "type": "string",
+            "enum": [
+                "documentation",
+                "checklist",
+                "remarks",
+                "remarks_clustered",
+                "final_report"
+            ],
+            "x-enum-varnames": [
+                "FileTypeDocumentation",
+                "FileTypeChecklist",
+                "FileTypeRemarks",
+                "FileTypeRemarksClustered",
+                "FileTypeFinalReport"
+            ]
+        },
+        "db.Project": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/db.ProjectStatus"
+                }
+            }
+        },
+        "db.ProjectFile": {
+            "type": "object",
+            "properties": {
+                "extension": {
+                    "type": "string"
+                },
+                "file_path": {
+                    "type": "string"
+                },
+                "file_size": {
+                    "type": "integer"
+                },
+                "file_type": {
+                    "$ref": "#/definitions/db.FileType"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "original_name": {
+                    "type": "string"
+                },
+                "project_id": {
+                    "type": "integer"
+                },
+                "uploaded_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "db.ProjectStatus": {
+            "type": "string",
+            "enum": [
+                "ready",
+                "processing_remarks",
+                "processing_checklist",
+                "generating_final_report"
+            ],
+            "x-enum-varnames": [
+                "ProjectStatusReady",
+                "ProjectStatusProcessingRemarks",
+                "ProjectStatusProcessingChecklist",
+                "ProjectStatusGeneratingFinalReport"
+            ]
+        },
+        "handler.Error": {
+            "type": "object",
+            "properties": {
+                "error": {}
+            }
+        },
+        "handler.Response": {
+            "type": "object",
+            "properties": {
+                "body": {}
+            }
+        },
+        "models.CreateProjectRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "models.CreateScheduleRequest": {
+            "type": "object",
+            "properties": {
+                "cron": {
+                    "type": "string",
+                    "example": "0 3 * * *"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "checklist"
+                }
+            }
+        },
+        "models.JobResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "locked_by": {
+                    "type": "string"
+                },
+                "locked_until": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "project_id": {
+                    "type": "integer"
+                },
+                "run_after": {
+                    "type": "string"
+                },
+                "runs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.JobRunResponse"
+                    }
+                },
+                "state": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.JobRunResponse": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "worker_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PipelineResponse": {
+            "type": "object",
+            "properties": {
+                "pipeline": {
+                    "type": "string",
+                    "example": "checklist"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "processing_checklist"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProjectEvent": {
             "type": "object",
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "id": {
+                "current": {
                     "type": "integer"
                 },
-                "name": {
+                "message": {
+                    "type": "string"
+                },
+                "pipeline": {
                     "type": "string"
                 },
+                "project_id": {
+                    "type": "integer"
+                },
                 "status": {
-                    "$ref": "#/definitions/db.ProjectStatus"
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         },
-        "db.ProjectFile": {
+        "models.ProjectReportTemplateResponse": {
             "type": "object",
             "properties": {
-                "extension": {
+                "project_id": {
+                    "type": "integer"
+                },
+                "template": {
+                    "description": "Template шаблон, по которому формируются отчеты проекта; отсутствует, если действует шаблон сервиса",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ReportTemplateResponse"
+                        }
+                    ]
+                },
+                "template_id": {
+                    "description": "TemplateID шаблон, выбранный для проекта; null - используется шаблон по умолчанию",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ReportTemplateRequest": {
+            "type": "object",
+            "properties": {
+                "final_report_conclusion": {
                     "type": "string"
                 },
-                "file_path": {
+                "final_report_intro": {
                     "type": "string"
                 },
-                "file_size": {
+                "is_default": {
+                    "description": "IsDefault шаблон используется для проектов, которым шаблон не выбран",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Недра"
+                },
+                "organization": {
+                    "type": "string",
+                    "example": "ООО «Недра»"
+                },
+                "remarks_conclusion": {
+                    "type": "string"
+                },
+                "remarks_intro": {
+                    "type": "string",
+                    "example": "Отчёт по проекту {{.ProjectName}} от {{date .GeneratedAt}}: {{.Remarks}} замечаний в {{len .Sections}} разделах."
+                }
+            }
+        },
+        "models.ReportTemplateResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "final_report_conclusion": {
+                    "type": "string"
+                },
+                "final_report_intro": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "file_type": {
-                    "$ref": "#/definitions/db.FileType"
+                "is_default": {
+                    "type": "boolean"
                 },
-                "filename": {
+                "name": {
+                    "type": "string"
+                },
+                "organization": {
+                    "type": "string"
+                },
+                "remarks_conclusion": {
+                    "type": "string"
+                },
+                "remarks_intro": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ResetProjectRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScheduleResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "cron": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "original_name": {
+                "kind": {
+                    "type": "string"
+                },
+                "last_run_at": {
+                    "type": "string"
+                },
+                "next_run_at": {
                     "type": "string"
                 },
                 "project_id": {
                     "type": "integer"
+                }
+            }
+        },
+        "models.SetProjectReportTemplateRequest": {
+            "type": "object",
+            "properties": {
+                "template_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.StatusTransitionResponse": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string",
+                    "example": "user"
                 },
-                "uploaded_at": {
+                "created_at": {
                     "type": "string"
+                },
+                "from_status": {
+                    "type": "string",
+                    "example": "ready"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "pipeline": {
+                    "type": "string",
+                    "example": "checklist"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "checklist generation requested"
+                },
+                "to_status": {
+                    "type": "string",
+                    "example": "processing_checklist"
                 }
             }
         },
-        "db.ProjectStatus": {
-            "type": "string",
-            "enum": [
-                "ready",
-                "processing_remarks",
-                "processing_checklist",
-                "generating_final_report"
-            ],
-            "x-enum-varnames": [
-                "ProjectStatusReady",
-                "ProjectStatusProcessingRemarks",
-                "ProjectStatusProcessingChecklist",
-                "ProjectStatusGeneratingFinalReport"
-            ]
+        "tasks.KindStats": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "integer"
+                },
+                "dead": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "latency_p50_ms": {
+                    "type": "integer"
+                },
+                "latency_p90_ms": {
+                    "type": "integer"
+                },
+                "latency_p99_ms": {
+                    "type": "integer"
+                },
+                "retried": {
+                    "type": "integer"
+                },
+                "samples": {
+                    "type": "integer"
+                }
+            }
         },
-        "handler.Error": {
+        "tasks.QueueStats": {
             "type": "object",
             "properties": {
-                "error": {}
+                "dead": {
+                    "description": "исчерпали попытки повтора",
+                    "type": "integer"
+                },
+                "delayed": {
+                    "description": "ждут повтора или отложенного запуска",
+                    "type": "integer"
+                },
+                "oldest_wait_seconds": {
+                    "description": "ожидание самой старой готовой задачи",
+                    "type": "number"
+                },
+                "ready": {
+                    "description": "готовы к выполнению и ждут воркера",
+                    "type": "integer"
+                },
+                "running": {
+                    "description": "выполняются",
+                    "type": "integer"
+                }
             }
         },
-        "handler.Response": {
+        "tasks.RunningTask": {
             "type": "object",
             "properties": {
-                "body": {}
+                "attempt": {
+                    "type": "integer"
+                },
+                "elapsed_ms": {
+                    "type": "integer"
+                },
+                "job_id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "project_id": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "worker": {
+                    "type": "integer"
+                }
             }
         },
-        "models.CreateProjectRequest": {
+        "tasks.TaskStats": {
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
-                "name": {
-                    "type": "string",
-                    "maxLength": 255
+                "cancelled_tasks": {
+                    "type": "integer"
+                },
+                "completed_tasks": {
+                    "type": "integer"
+                },
+                "dead_tasks": {
+                    "type": "integer"
+                },
+                "failed_tasks": {
+                    "type": "integer"
+                },
+                "is_running": {
+                    "type": "boolean"
+                },
+                "kinds": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/tasks.KindStats"
+                    }
+                },
+                "pending_tasks": {
+                    "type": "integer"
+                },
+                "queue": {
+                    "$ref": "#/definitions/tasks.QueueStats"
+                },
+                "retried_tasks": {
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/tasks.RunningTask"
+                    }
+                },
+                "total_tasks": {
+                    "type": "integer"
+                },
+                "worker_count": {
+                    "type": "integer"
+                },
+                "worker_id": {
+                    "type": "string"
                 }
             }
         }
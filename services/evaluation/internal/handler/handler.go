@@ -9,12 +9,16 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	m "evaluation/internal/models"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
@@ -66,19 +70,33 @@ func returnErrorJSON(w http.ResponseWriter, err error) {
 
 // Handler объединяет все HTTP хендлеры
 type Handler struct {
-	projectService services.ProjectService
-	fileService    services.FileService
-	healthService  services.HealthService
-	taskManager    tasks.TaskManager
+	projectService  services.ProjectService
+	fileService     services.FileService
+	healthService   services.HealthService
+	jobService      services.JobService
+	recoveryService services.RecoveryService
+	eventService    services.EventService
+	scheduleService services.ScheduleService
+	// reportTemplateService шаблоны отчетов
+	reportTemplateService services.ReportTemplateService
+	taskManager           tasks.TaskManager
+	streamsDone           chan struct{}
+	closeStreams          sync.Once
 }
 
 // New создает новый экземпляр хендлера
-func New(projectService services.ProjectService, fileService services.FileService, healthService services.HealthService, taskManager tasks.TaskManager) *Handler {
+func New(projectService services.ProjectService, fileService services.FileService, healthService services.HealthService, jobService services.JobService, recoveryService services.RecoveryService, eventService services.EventService, scheduleService services.ScheduleService, reportTemplateService services.ReportTemplateService, taskManager tasks.TaskManager) *Handler {
 	return &Handler{
-		projectService: projectService,
-		fileService:    fileService,
-		healthService:  healthService,
-		taskManager:    taskManager,
+		projectService:        projectService,
+		fileService:           fileService,
+		healthService:         healthService,
+		jobService:            jobService,
+		recoveryService:       recoveryService,
+		eventService:          eventService,
+		scheduleService:       scheduleService,
+		reportTemplateService: reportTemplateService,
+		taskManager:           taskManager,
+		streamsDone:           make(chan struct{}),
 	}
 }
 
@@ -287,6 +305,26 @@ func (h *Handler) HandleProject(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// HandleProjectPipelines обрабатывает запросы к /api/projects/{id}/pipelines
+func (h *Handler) HandleProjectPipelines(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetProjectPipelines(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleProjectHistory обрабатывает запросы к /api/projects/{id}/history
+func (h *Handler) HandleProjectHistory(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetProjectHistory(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 // HandleDocumentation обрабатывает запросы к /api/projects/{id}/documentation
 func (h *Handler) HandleDocumentation(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -455,8 +493,9 @@ func (h *Handler) GenerateChecklist(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "Project ID"
 // @Param file formData file true "Remarks file to upload"
+// @Param format query string false "Remarks report format" Enums(pdf, docx) default(pdf)
 // @Success 202 {object} db.ProjectFile "Remarks file uploaded successfully"
-// @Failure 400 {object} Error "Bad request - invalid input data"
+// @Failure 400 {object} Error "Bad request - invalid input data or report format"
 // @Failure 404 {object} Error "Project not found"
 // @Failure 500 {object} Error "Internal server error"
 // @Router /projects/{id}/remarks [post]
@@ -499,7 +538,8 @@ func (h *Handler) UploadRemarks(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Received remarks file: %s, size: %d bytes", fileName, fileSize)
 
 	// Используем сервис для загрузки файла замечаний (только тип "remarks")
-	projectFile, err := h.fileService.UploadRemarks(r.Context(), int32(projectID), file, fileName, "remarks", fileSize)
+	format := r.URL.Query().Get("format")
+	projectFile, err := h.fileService.UploadRemarks(r.Context(), int32(projectID), file, fileName, "remarks", fileSize, format)
 	if err != nil {
 		log.Printf("Failed to upload remarks file: %v", err)
 		returnErrorJSON(w, err)
@@ -589,6 +629,74 @@ func (h *Handler) GetProject(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(project)
 }
 
+// GetProjectPipelines godoc
+// @Summary Get project pipelines
+// @Description Get the status of every processing pipeline of a project (remarks, checklist, final_report); pipelines run independently of each other
+// @ID getProjectPipelines
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {object} Response{body=[]models.PipelineResponse} "Pipeline states"
+// @Failure 400 {object} Error "Bad request - invalid project ID"
+// @Failure 404 {object} Error "Project not found"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /projects/{id}/pipelines [get]
+func (h *Handler) GetProjectPipelines(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		log.Printf("Invalid project ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	pipelines, err := h.projectService.GetProjectPipelines(r.Context(), int32(projectID))
+	if err != nil {
+		log.Printf("Failed to get pipelines of project %d: %v", projectID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: pipelines,
+	})
+}
+
+// GetProjectHistory godoc
+// @Summary Get project status history
+// @Description Get all pipeline status transitions of a project in chronological order with actor and reason
+// @ID getProjectHistory
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {object} Response{body=[]models.StatusTransitionResponse} "Status transitions"
+// @Failure 400 {object} Error "Bad request - invalid project ID"
+// @Failure 404 {object} Error "Project not found"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /projects/{id}/history [get]
+func (h *Handler) GetProjectHistory(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		log.Printf("Invalid project ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	history, err := h.projectService.GetProjectHistory(r.Context(), int32(projectID))
+	if err != nil {
+		log.Printf("Failed to get status history of project %d: %v", projectID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: history,
+	})
+}
+
 // CreateProject godoc
 // @Summary Create new project
 // @Description Create a new project with name
@@ -634,8 +742,9 @@ func (h *Handler) CreateProject(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Project ID"
+// @Param format query string false "Final report format" Enums(pdf, docx) default(pdf)
 // @Success 202 {object} Response "Final report generation started"
-// @Failure 400 {object} Error "Bad request - invalid project ID"
+// @Failure 400 {object} Error "Bad request - invalid project ID or report format"
 // @Failure 404 {object} Error "Project not found"
 // @Failure 409 {object} Error "Project is already being processed"
 // @Failure 500 {object} Error "Internal server error"
@@ -662,8 +771,8 @@ func (h *Handler) GenerateFinalReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Используем сервис для генерации финального отчета
-	err = h.fileService.GenerateFinalReport(r.Context(), int32(projectID))
+	// Используем сервис для генерации финального отчета в запрошенном формате
+	err = h.fileService.GenerateFinalReport(r.Context(), int32(projectID), r.URL.Query().Get("format"))
 	if err != nil {
 		log.Printf("Failed to generate final report for project %d: %v", projectID, err)
 		returnErrorJSON(w, err)
@@ -759,13 +868,15 @@ func (h *Handler) GetChecklist(w http.ResponseWriter, r *http.Request) {
 
 // GetRemarksClustered godoc
 // @Summary Get clustered remarks for project
-// @Description Get clustered remarks result for a specific project
+// @Description Get clustered remarks result for a specific project.
+// @Description With the format parameter the latest report in that format is downloaded as a file.
 // @ID getRemarksClustered
 // @Accept json
-// @Produce json
+// @Produce json,octet-stream
 // @Param id path int true "Project ID"
+// @Param format query string false "Download the latest report file in this format" Enums(xlsx, pdf, docx)
 // @Success 200 {object} Response "Clustered remarks result"
-// @Failure 400 {object} Error "Bad request - invalid project ID"
+// @Failure 400 {object} Error "Bad request - invalid project ID or format"
 // @Failure 404 {object} Error "Project not found"
 // @Failure 409 {object} Error "Remarks are still being processed"
 // @Failure 500 {object} Error "Internal server error"
@@ -787,6 +898,12 @@ func (h *Handler) GetRemarksClustered(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// С параметром format отдаем сам файл отчета
+	if format := r.URL.Query().Get("format"); format != "" {
+		h.downloadRemarksClustered(w, r, int32(projectID), format)
+		return
+	}
+
 	// Используем сервис для получения кластеризированных замечаний
 	result, err := h.fileService.GetRemarksClustered(r.Context(), int32(projectID))
 	if err != nil {
@@ -803,6 +920,28 @@ func (h *Handler) GetRemarksClustered(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// downloadRemarksClustered отдает последний отчет по кластеризованным замечаниям как вложение
+func (h *Handler) downloadRemarksClustered(w http.ResponseWriter, r *http.Request, projectID int32, format string) {
+	file, content, err := h.fileService.DownloadRemarksClustered(r.Context(), projectID, format)
+	if err != nil {
+		log.Printf("Failed to download clustered remarks for project %d: %v", projectID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", tasks.ReportContentType(strings.TrimPrefix(file.Extension, ".")))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.OriginalName}))
+	if file.FileSize > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(file.FileSize, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("Failed to send clustered remarks file %s: %v", file.FilePath, err)
+	}
+}
+
 // GetFinalReport godoc
 // @Summary Get final report for project
 // @Description Get final report result for a specific project
@@ -848,3 +987,880 @@ func (h *Handler) GetFinalReport(w http.ResponseWriter, r *http.Request) {
 		Body: result,
 	})
 }
+
+// ========== JOBS ==========
+
+// HandleProjectJobs обрабатывает запросы к /api/projects/{id}/jobs
+func (h *Handler) HandleProjectJobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.ListProjectJobs(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleJob обрабатывает запросы к /api/jobs/{job_id}
+func (h *Handler) HandleJob(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetJob(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleCancelJob обрабатывает запросы к /api/projects/{id}/jobs/{job_id}/cancel
+func (h *Handler) HandleCancelJob(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.CancelJob(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ListProjectJobs godoc
+// @Summary List project jobs
+// @Description Get background jobs of a specific project, newest first
+// @ID listProjectJobs
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {object} Response{body=[]models.JobResponse} "List of jobs"
+// @Failure 400 {object} Error "Bad request - invalid project ID"
+// @Failure 404 {object} Error "Project not found"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /projects/{id}/jobs [get]
+func (h *Handler) ListProjectJobs(w http.ResponseWriter, r *http.Request) {
+	// Извлекаем ID проекта из URL с помощью gorilla/mux
+	vars := mux.Vars(r)
+	projectIDStr, ok := vars["id"]
+	if !ok {
+		log.Println("Project ID not found in URL")
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	projectID, err := strconv.ParseInt(projectIDStr, 10, 32)
+	if err != nil {
+		log.Printf("Invalid project ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	jobs, err := h.jobService.ListProjectJobs(r.Context(), int32(projectID))
+	if err != nil {
+		log.Printf("Failed to list jobs for project %d: %v", projectID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: jobs,
+	})
+}
+
+// GetJob godoc
+// @Summary Get job by ID
+// @Description Get background job state together with its run history
+// @ID getJob
+// @Accept json
+// @Produce json
+// @Param job_id path string true "Job ID (UUID)"
+// @Success 200 {object} Response{body=models.JobResponse} "Job found"
+// @Failure 400 {object} Error "Bad request - invalid job ID"
+// @Failure 404 {object} Error "Job not found"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /jobs/{job_id} [get]
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(mux.Vars(r)["job_id"])
+	if err != nil {
+		log.Printf("Invalid job ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	job, err := h.jobService.GetJob(r.Context(), jobID)
+	if err != nil {
+		log.Printf("Failed to get job %s: %v", jobID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: job,
+	})
+}
+
+// CancelJob godoc
+// @Summary Cancel project job
+// @Description Cancel a queued or running job of a project. A running job is interrupted, the project returns to ready
+// @ID cancelJob
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param job_id path string true "Job ID (UUID)"
+// @Success 200 {object} Response{body=models.JobResponse} "Job cancelled"
+// @Failure 400 {object} Error "Bad request - invalid project or job ID"
+// @Failure 404 {object} Error "Job not found"
+// @Failure 409 {object} Error "Job is already finished"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /projects/{id}/jobs/{job_id}/cancel [post]
+func (h *Handler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	// Извлекаем ID проекта и задачи из URL с помощью gorilla/mux
+	vars := mux.Vars(r)
+	projectID, err := strconv.ParseInt(vars["id"], 10, 32)
+	if err != nil {
+		log.Printf("Invalid project ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	jobID, err := uuid.Parse(vars["job_id"])
+	if err != nil {
+		log.Printf("Invalid job ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	job, err := h.jobService.CancelJob(r.Context(), int32(projectID), jobID)
+	if err != nil {
+		log.Printf("Failed to cancel job %s of project %d: %v", jobID, projectID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: job,
+	})
+}
+
+// ========== SCHEDULES ==========
+
+// HandleProjectSchedules обрабатывает запросы к /api/projects/{id}/schedules
+func (h *Handler) HandleProjectSchedules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.ListSchedules(w, r)
+	case http.MethodPost:
+		h.CreateSchedule(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleSchedule обрабатывает запросы к /api/projects/{id}/schedules/{schedule_id}
+func (h *Handler) HandleSchedule(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodDelete:
+		h.DeleteSchedule(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// CreateSchedule godoc
+// @Summary Create project schedule
+// @Description Schedule a recurring job of a project by a cron expression (5 fields or @hourly/@daily/@weekly/@monthly, evaluated in UTC). Supported kinds: checklist, final_report. A run is skipped if the project is being processed at that time
+// @ID createSchedule
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param schedule body models.CreateScheduleRequest true "Schedule data"
+// @Success 201 {object} Response{body=models.ScheduleResponse} "Schedule created"
+// @Failure 400 {object} Error "Bad request - invalid project ID, job kind or cron expression"
+// @Failure 404 {object} Error "Project not found"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /projects/{id}/schedules [post]
+func (h *Handler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		log.Printf("Invalid project ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	var req m.CreateScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Failed to decode schedule request: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	schedule, err := h.scheduleService.CreateSchedule(r.Context(), int32(projectID), req.Kind, req.Cron)
+	if err != nil {
+		log.Printf("Failed to create schedule for project %d: %v", projectID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&Response{
+		Body: schedule,
+	})
+}
+
+// ListSchedules godoc
+// @Summary List project schedules
+// @Description Get recurring job schedules of a specific project
+// @ID listSchedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {object} Response{body=[]models.ScheduleResponse} "List of schedules"
+// @Failure 400 {object} Error "Bad request - invalid project ID"
+// @Failure 404 {object} Error "Project not found"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /projects/{id}/schedules [get]
+func (h *Handler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		log.Printf("Invalid project ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	schedules, err := h.scheduleService.ListSchedules(r.Context(), int32(projectID))
+	if err != nil {
+		log.Printf("Failed to list schedules for project %d: %v", projectID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: schedules,
+	})
+}
+
+// DeleteSchedule godoc
+// @Summary Delete project schedule
+// @Description Delete a recurring job schedule of a project. Jobs already started by the schedule are not affected
+// @ID deleteSchedule
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param schedule_id path int true "Schedule ID"
+// @Success 204 "Schedule deleted"
+// @Failure 400 {object} Error "Bad request - invalid project or schedule ID"
+// @Failure 404 {object} Error "Schedule not found"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /projects/{id}/schedules/{schedule_id} [delete]
+func (h *Handler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, err := strconv.ParseInt(vars["id"], 10, 32)
+	if err != nil {
+		log.Printf("Invalid project ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	scheduleID, err := strconv.ParseInt(vars["schedule_id"], 10, 32)
+	if err != nil {
+		log.Printf("Invalid schedule ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	if err := h.scheduleService.DeleteSchedule(r.Context(), int32(projectID), int32(scheduleID)); err != nil {
+		log.Printf("Failed to delete schedule %d of project %d: %v", scheduleID, projectID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ========== REPORT TEMPLATES ==========
+
+// HandleReportTemplates обрабатывает запросы к /api/admin/report_templates
+func (h *Handler) HandleReportTemplates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.ListReportTemplates(w, r)
+	case http.MethodPost:
+		h.CreateReportTemplate(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleReportTemplate обрабатывает запросы к /api/admin/report_templates/{template_id}
+func (h *Handler) HandleReportTemplate(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetReportTemplate(w, r)
+	case http.MethodPut:
+		h.UpdateReportTemplate(w, r)
+	case http.MethodDelete:
+		h.DeleteReportTemplate(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleProjectReportTemplate обрабатывает запросы к /api/projects/{id}/report_template
+func (h *Handler) HandleProjectReportTemplate(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetProjectReportTemplate(w, r)
+	case http.MethodPut:
+		h.SetProjectReportTemplate(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// CreateReportTemplate godoc
+// @Summary Create report template
+// @Description Upload a report template. Intro and conclusion texts are Go text/template sources with project placeholders: {{.ProjectName}}, {{date .GeneratedAt}}, {{date .ProjectCreatedAt}}, {{.Groups}}, {{.Remarks}}, {{.ChecklistCriteria}} and {{range .Sections}}{{.Title}}: {{.Groups}} / {{.Remarks}}{{end}}. Empty texts are taken from the service template. A default template is used for projects without a selected template
+// @ID createReportTemplate
+// @Accept json
+// @Produce json
+// @Param template body models.ReportTemplateRequest true "Report template"
+// @Success 201 {object} Response{body=models.ReportTemplateResponse} "Report template created"
+// @Failure 400 {object} Error "Bad request - empty name or invalid template text"
+// @Failure 409 {object} Error "Report template with this name already exists"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /admin/report_templates [post]
+func (h *Handler) CreateReportTemplate(w http.ResponseWriter, r *http.Request) {
+	var req m.ReportTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Failed to decode report template request: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	template, err := h.reportTemplateService.CreateTemplate(r.Context(), req)
+	if err != nil {
+		log.Printf("Failed to create report template: %v", err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&Response{
+		Body: template,
+	})
+}
+
+// ListReportTemplates godoc
+// @Summary List report templates
+// @Description Get all report templates ordered by name
+// @ID listReportTemplates
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response{body=[]models.ReportTemplateResponse} "List of report templates"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /admin/report_templates [get]
+func (h *Handler) ListReportTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.reportTemplateService.ListTemplates(r.Context())
+	if err != nil {
+		log.Printf("Failed to list report templates: %v", err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: templates,
+	})
+}
+
+// GetReportTemplate godoc
+// @Summary Get report template
+// @Description Get a report template by ID
+// @ID getReportTemplate
+// @Accept json
+// @Produce json
+// @Param template_id path int true "Report template ID"
+// @Success 200 {object} Response{body=models.ReportTemplateResponse} "Report template"
+// @Failure 400 {object} Error "Bad request - invalid template ID"
+// @Failure 404 {object} Error "Report template not found"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /admin/report_templates/{template_id} [get]
+func (h *Handler) GetReportTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := strconv.ParseInt(mux.Vars(r)["template_id"], 10, 32)
+	if err != nil {
+		log.Printf("Invalid report template ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	template, err := h.reportTemplateService.GetTemplate(r.Context(), int32(templateID))
+	if err != nil {
+		log.Printf("Failed to get report template %d: %v", templateID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: template,
+	})
+}
+
+// UpdateReportTemplate godoc
+// @Summary Replace report template
+// @Description Replace name, texts and default flag of a report template. Reports generated earlier are not regenerated
+// @ID updateReportTemplate
+// @Accept json
+// @Produce json
+// @Param template_id path int true "Report template ID"
+// @Param template body models.ReportTemplateRequest true "Report template"
+// @Success 200 {object} Response{body=models.ReportTemplateResponse} "Report template updated"
+// @Failure 400 {object} Error "Bad request - invalid template ID, empty name or invalid template text"
+// @Failure 404 {object} Error "Report template not found"
+// @Failure 409 {object} Error "Report template with this name already exists"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /admin/report_templates/{template_id} [put]
+func (h *Handler) UpdateReportTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := strconv.ParseInt(mux.Vars(r)["template_id"], 10, 32)
+	if err != nil {
+		log.Printf("Invalid report template ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	var req m.ReportTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Failed to decode report template request: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	template, err := h.reportTemplateService.UpdateTemplate(r.Context(), int32(templateID), req)
+	if err != nil {
+		log.Printf("Failed to update report template %d: %v", templateID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: template,
+	})
+}
+
+// DeleteReportTemplate godoc
+// @Summary Delete report template
+// @Description Delete a report template. Projects that selected it return to the default template
+// @ID deleteReportTemplate
+// @Accept json
+// @Produce json
+// @Param template_id path int true "Report template ID"
+// @Success 204 "Report template deleted"
+// @Failure 400 {object} Error "Bad request - invalid template ID"
+// @Failure 404 {object} Error "Report template not found"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /admin/report_templates/{template_id} [delete]
+func (h *Handler) DeleteReportTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := strconv.ParseInt(mux.Vars(r)["template_id"], 10, 32)
+	if err != nil {
+		log.Printf("Invalid report template ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	if err := h.reportTemplateService.DeleteTemplate(r.Context(), int32(templateID)); err != nil {
+		log.Printf("Failed to delete report template %d: %v", templateID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProjectReportTemplate godoc
+// @Summary Get project report template
+// @Description Get the report template selected for a project and the template its reports are generated with. Without a selected template the default template applies, without a default template - the service template
+// @ID getProjectReportTemplate
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {object} Response{body=models.ProjectReportTemplateResponse} "Project report template"
+// @Failure 400 {object} Error "Bad request - invalid project ID"
+// @Failure 404 {object} Error "Project not found"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /projects/{id}/report_template [get]
+func (h *Handler) GetProjectReportTemplate(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		log.Printf("Invalid project ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	template, err := h.reportTemplateService.GetProjectTemplate(r.Context(), int32(projectID))
+	if err != nil {
+		log.Printf("Failed to get report template of project %d: %v", projectID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: template,
+	})
+}
+
+// SetProjectReportTemplate godoc
+// @Summary Select project report template
+// @Description Select the report template of a project, null template_id returns the project to the default template. Applies to reports generated afterwards
+// @ID setProjectReportTemplate
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param request body models.SetProjectReportTemplateRequest true "Report template selection"
+// @Success 200 {object} Response{body=models.ProjectReportTemplateResponse} "Project report template"
+// @Failure 400 {object} Error "Bad request - invalid project ID or body"
+// @Failure 404 {object} Error "Project or report template not found"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /projects/{id}/report_template [put]
+func (h *Handler) SetProjectReportTemplate(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		log.Printf("Invalid project ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	var req m.SetProjectReportTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Failed to decode report template selection: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	template, err := h.reportTemplateService.SetProjectTemplate(r.Context(), int32(projectID), req.TemplateID)
+	if err != nil {
+		log.Printf("Failed to select report template of project %d: %v", projectID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: template,
+	})
+}
+
+// ========== EVENTS ==========
+
+// sseHeartbeatInterval интервал отправки комментария, удерживающего SSE-соединение открытым
+const sseHeartbeatInterval = 15 * time.Second
+
+// HandleProjectEvents обрабатывает запросы к /api/projects/{id}/events
+func (h *Handler) HandleProjectEvents(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.StreamProjectEvents(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// StreamProjectEvents godoc
+// @Summary Stream project events
+// @Description Server-Sent Events stream of pipeline status transitions (event "status") and job progress messages (event "progress"). The current status of every project pipeline is sent first
+// @ID streamProjectEvents
+// @Produce text/event-stream
+// @Param id path int true "Project ID"
+// @Success 200 {object} models.ProjectEvent "Stream of project events"
+// @Failure 400 {object} Error "Bad request - invalid project ID"
+// @Failure 404 {object} Error "Project not found"
+// @Failure 500 {object} Error "Internal server error - streaming is not supported"
+// @Router /projects/{id}/events [get]
+func (h *Handler) StreamProjectEvents(w http.ResponseWriter, r *http.Request) {
+	// Извлекаем ID проекта из URL с помощью gorilla/mux
+	vars := mux.Vars(r)
+	projectIDStr, ok := vars["id"]
+	if !ok {
+		log.Println("Project ID not found in URL")
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	projectID, err := strconv.ParseInt(projectIDStr, 10, 32)
+	if err != nil {
+		log.Printf("Invalid project ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	// Подписываемся до чтения статуса, чтобы не пропустить смену статуса между ними
+	events, unsubscribe := h.eventService.Subscribe(int32(projectID))
+	defer unsubscribe()
+
+	project, err := h.projectService.GetProject(r.Context(), int32(projectID))
+	if err != nil {
+		log.Printf("Failed to get project %d: %v", projectID, err)
+		returnErrorJSON(w, m.ErrNotFound404)
+		return
+	}
+
+	pipelines, err := h.projectService.GetProjectPipelines(r.Context(), project.ID)
+	if err != nil {
+		log.Printf("Failed to get pipelines of project %d: %v", projectID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	// Поток живет дольше WriteTimeout сервера - снимаем дедлайн записи для этого соединения
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Failed to disable write deadline for project %d events: %v", projectID, err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Первыми отправляем текущие статусы всех конвейеров проекта
+	for _, pipeline := range pipelines {
+		current := m.ProjectEvent{
+			ProjectID: project.ID,
+			Type:      m.ProjectEventStatus,
+			Pipeline:  pipeline.Pipeline,
+			Status:    pipeline.Status,
+			CreatedAt: time.Now(),
+		}
+		if err := writeSSE(w, rc, current); err != nil {
+			log.Printf("Failed to stream events of project %d: %v", projectID, err)
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.streamsDone:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeSSE(w, rc, event); err != nil {
+				log.Printf("Failed to stream events of project %d: %v", projectID, err)
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// CloseStreams завершает открытые потоки событий, чтобы они не задерживали остановку сервера
+func (h *Handler) CloseStreams() {
+	h.closeStreams.Do(func() {
+		close(h.streamsDone)
+	})
+}
+
+// writeSSE отправляет событие проекта в формате Server-Sent Events
+func writeSSE(w http.ResponseWriter, rc *http.ResponseController, event m.ProjectEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+// ========== ADMIN ==========
+
+// HandleAdminResetProject обрабатывает запросы к /api/admin/projects/{id}/reset
+func (h *Handler) HandleAdminResetProject(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.ResetProject(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleDeadJobs обрабатывает запросы к /api/admin/jobs/dead
+func (h *Handler) HandleDeadJobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.ListDeadJobs(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleRequeueJob обрабатывает запросы к /api/admin/jobs/{job_id}/requeue
+func (h *Handler) HandleRequeueJob(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.RequeueJob(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleAdminTasks обрабатывает запросы к /api/admin/tasks
+func (h *Handler) HandleAdminTasks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetTaskStats(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetTaskStats godoc
+// @Summary Task manager stats
+// @Description Get depth of the shared job queue and stats of this service instance: running jobs with elapsed time, per-kind outcome counts and latency percentiles over recent runs
+// @ID getTaskStats
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response{body=tasks.TaskStats} "Task manager stats"
+// @Router /admin/tasks [get]
+func (h *Handler) GetTaskStats(w http.ResponseWriter, r *http.Request) {
+	stats := h.taskManager.GetStats()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: stats,
+	})
+}
+
+// ListDeadJobs godoc
+// @Summary List dead-letter jobs
+// @Description Get jobs that exhausted their retry attempts, newest first
+// @ID listDeadJobs
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response{body=[]models.JobResponse} "List of dead jobs"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /admin/jobs/dead [get]
+func (h *Handler) ListDeadJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.jobService.ListDeadJobs(r.Context())
+	if err != nil {
+		log.Printf("Failed to list dead jobs: %v", err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: jobs,
+	})
+}
+
+// RequeueJob godoc
+// @Summary Requeue dead-letter job
+// @Description Return a job from dead-letter to the queue with a reset attempt counter. The project is switched back to the processing status of the job
+// @ID requeueJob
+// @Accept json
+// @Produce json
+// @Param job_id path string true "Job ID (UUID)"
+// @Success 200 {object} Response{body=models.JobResponse} "Job requeued"
+// @Failure 400 {object} Error "Bad request - invalid job ID"
+// @Failure 404 {object} Error "Job not found"
+// @Failure 409 {object} Error "Job is not in dead-letter or project is already being processed"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /admin/jobs/{job_id}/requeue [post]
+func (h *Handler) RequeueJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(mux.Vars(r)["job_id"])
+	if err != nil {
+		log.Printf("Invalid job ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	job, err := h.jobService.RequeueJob(r.Context(), jobID)
+	if err != nil {
+		log.Printf("Failed to requeue job %s: %v", jobID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&Response{
+		Body: job,
+	})
+}
+
+// ResetProject godoc
+// @Summary Force-reset project status
+// @Description Return a project stuck in a processing status to ready. Active jobs of the project are marked as failed, the reason is recorded
+// @ID resetProject
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param request body models.ResetProjectRequest false "Reset reason"
+// @Success 200 {object} db.Project "Project reset to ready"
+// @Failure 400 {object} Error "Bad request - invalid project ID or body"
+// @Failure 404 {object} Error "Project not found"
+// @Failure 409 {object} Error "Project status changed during reset"
+// @Failure 500 {object} Error "Internal server error"
+// @Router /admin/projects/{id}/reset [post]
+func (h *Handler) ResetProject(w http.ResponseWriter, r *http.Request) {
+	// Извлекаем ID проекта из URL с помощью gorilla/mux
+	vars := mux.Vars(r)
+	projectIDStr, ok := vars["id"]
+	if !ok {
+		log.Println("Project ID not found in URL")
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	projectID, err := strconv.ParseInt(projectIDStr, 10, 32)
+	if err != nil {
+		log.Printf("Invalid project ID format: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	// Тело запроса необязательно
+	var req m.ResetProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		log.Printf("Failed to decode reset request: %v", err)
+		returnErrorJSON(w, m.ErrBadRequest400)
+		return
+	}
+
+	project, err := h.recoveryService.ResetProject(r.Context(), int32(projectID), req.Reason)
+	if err != nil {
+		log.Printf("Failed to reset project %d: %v", projectID, err)
+		returnErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(project)
+}
@@ -16,6 +16,12 @@ var ErrProjectAlreadyProcessing = errors.New("project is already being processed
 var ErrChecklistStillGenerating = errors.New("checklist is still being generated - please wait")
 var ErrRemarksStillProcessing = errors.New("remarks are still being processed - please wait")
 var ErrFinalReportStillGenerating = errors.New("final report is still being generated - please wait")
+var ErrJobNotCancellable = errors.New("job is already finished - cannot cancel")
+var ErrJobNotDead = errors.New("job is not in dead-letter - cannot requeue")
+var ErrInvalidSchedule = errors.New("invalid schedule - unsupported job kind or cron expression")
+var ErrInvalidReportFormat = errors.New("invalid report format - unsupported format requested")
+var ErrInvalidReportTemplate = errors.New("invalid report template - name is required and texts must be valid Go templates")
+var ErrReportTemplateExists = errors.New("report template with this name already exists")
 var ErrServerError500 = errors.New("internal server error - Request is valid but operation failed at server side")
 var ErrServerError503 = errors.New("service unavailable")
 
@@ -68,10 +74,34 @@ func CheckError(err error) (int, string) {
 		return 409, ErrFinalReportStillGenerating.Error()
 	}
 
+	if errors.Is(err, ErrJobNotCancellable) {
+		return 409, ErrJobNotCancellable.Error()
+	}
+
+	if errors.Is(err, ErrJobNotDead) {
+		return 409, ErrJobNotDead.Error()
+	}
+
 	if errors.Is(err, ErrBadRequest400) {
 		return 400, ErrBadRequest400.Error()
 	}
 
+	if errors.Is(err, ErrInvalidSchedule) {
+		return 400, ErrInvalidSchedule.Error()
+	}
+
+	if errors.Is(err, ErrInvalidReportFormat) {
+		return 400, ErrInvalidReportFormat.Error()
+	}
+
+	if errors.Is(err, ErrInvalidReportTemplate) {
+		return 400, ErrInvalidReportTemplate.Error()
+	}
+
+	if errors.Is(err, ErrReportTemplateExists) {
+		return 409, ErrReportTemplateExists.Error()
+	}
+
 	if errors.Is(err, ErrServerError503) {
 		return 503, ErrServerError503.Error()
 	}
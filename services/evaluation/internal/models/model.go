@@ -1,6 +1,9 @@
 package models
 
-import "io"
+import (
+	"io"
+	"time"
+)
 
 type File interface {
 	io.Reader
@@ -31,3 +34,135 @@ type Response struct {
 type CreateProjectRequest struct {
 	Name string `json:"name" validate:"required,max=255"`
 }
+
+// ResetProjectRequest структура запроса для принудительного сброса статуса проекта
+type ResetProjectRequest struct {
+	Reason string `json:"reason"`
+}
+
+// JobResponse структура ответа с состоянием фоновой задачи
+type JobResponse struct {
+	ID          string           `json:"id"`
+	ProjectID   int32            `json:"project_id"`
+	Kind        string           `json:"kind"`
+	State       string           `json:"state"`
+	Attempts    int32            `json:"attempts"`
+	Priority    int32            `json:"priority"`
+	RunAfter    time.Time        `json:"run_after"`
+	LockedBy    *string          `json:"locked_by,omitempty"`
+	LockedUntil *time.Time       `json:"locked_until,omitempty"`
+	LastError   *string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	FinishedAt  *time.Time       `json:"finished_at,omitempty"`
+	Runs        []JobRunResponse `json:"runs,omitempty"`
+}
+
+// JobRunResponse структура ответа с результатом одного запуска задачи
+type JobRunResponse struct {
+	Attempt    int32     `json:"attempt"`
+	WorkerID   string    `json:"worker_id"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Success    bool      `json:"success"`
+	Error      *string   `json:"error,omitempty"`
+}
+
+// CreateScheduleRequest структура запроса для создания расписания задачи проекта
+type CreateScheduleRequest struct {
+	Kind string `json:"kind" example:"checklist"`
+	Cron string `json:"cron" example:"0 3 * * *"`
+}
+
+// ScheduleResponse структура ответа с расписанием задачи проекта
+// Время запуска вычисляется по cron-выражению в UTC
+type ScheduleResponse struct {
+	ID        int32      `json:"id"`
+	ProjectID int32      `json:"project_id"`
+	Kind      string     `json:"kind"`
+	Cron      string     `json:"cron"`
+	NextRunAt time.Time  `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// ReportTemplateRequest структура запроса для создания или замены шаблона отчетов
+// Тексты - шаблоны Go text/template с данными проекта: {{.ProjectName}}, {{date .GeneratedAt}},
+// {{.Groups}}, {{.Remarks}}, {{.ChecklistCriteria}} и {{range .Sections}}{{.Title}}: {{.Remarks}}{{end}}.
+// Пустой текст берется из шаблона сервиса
+type ReportTemplateRequest struct {
+	Name                  string `json:"name" example:"Недра"`
+	Organization          string `json:"organization" example:"ООО «Недра»"`
+	RemarksIntro          string `json:"remarks_intro" example:"Отчёт по проекту {{.ProjectName}} от {{date .GeneratedAt}}: {{.Remarks}} замечаний в {{len .Sections}} разделах."`
+	RemarksConclusion     string `json:"remarks_conclusion"`
+	FinalReportIntro      string `json:"final_report_intro"`
+	FinalReportConclusion string `json:"final_report_conclusion"`
+	// IsDefault шаблон используется для проектов, которым шаблон не выбран
+	IsDefault bool `json:"is_default"`
+}
+
+// ReportTemplateResponse структура ответа с шаблоном отчетов
+type ReportTemplateResponse struct {
+	ID                    int32     `json:"id"`
+	Name                  string    `json:"name"`
+	Organization          string    `json:"organization"`
+	RemarksIntro          string    `json:"remarks_intro"`
+	RemarksConclusion     string    `json:"remarks_conclusion"`
+	FinalReportIntro      string    `json:"final_report_intro"`
+	FinalReportConclusion string    `json:"final_report_conclusion"`
+	IsDefault             bool      `json:"is_default"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// SetProjectReportTemplateRequest структура запроса для выбора шаблона отчетов проекта
+// null возвращает проект к шаблону по умолчанию
+type SetProjectReportTemplateRequest struct {
+	TemplateID *int32 `json:"template_id" example:"1"`
+}
+
+// ProjectReportTemplateResponse структура ответа с шаблоном отчетов проекта
+type ProjectReportTemplateResponse struct {
+	ProjectID int32 `json:"project_id"`
+	// TemplateID шаблон, выбранный для проекта; null - используется шаблон по умолчанию
+	TemplateID *int32 `json:"template_id"`
+	// Template шаблон, по которому формируются отчеты проекта; отсутствует, если действует шаблон сервиса
+	Template *ReportTemplateResponse `json:"template,omitempty"`
+}
+
+// PipelineResponse структура ответа с состоянием конвейера обработки проекта
+type PipelineResponse struct {
+	Pipeline  string    `json:"pipeline" example:"checklist"`
+	Status    string    `json:"status" example:"processing_checklist"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StatusTransitionResponse структура ответа с переходом статуса конвейера проекта
+type StatusTransitionResponse struct {
+	ID         int32     `json:"id"`
+	Pipeline   string    `json:"pipeline" example:"checklist"`
+	FromStatus string    `json:"from_status" example:"ready"`
+	ToStatus   string    `json:"to_status" example:"processing_checklist"`
+	Actor      string    `json:"actor" example:"user"`
+	Reason     string    `json:"reason" example:"checklist generation requested"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Типы событий проекта
+const (
+	ProjectEventStatus   = "status"   // смена статуса проекта
+	ProjectEventProgress = "progress" // прогресс выполнения задачи проекта
+)
+
+// ProjectEvent событие проекта, передаваемое клиенту через Server-Sent Events
+type ProjectEvent struct {
+	ProjectID int32     `json:"project_id"`
+	Type      string    `json:"type"`
+	Pipeline  string    `json:"pipeline,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Current   int       `json:"current,omitempty"`
+	Total     int       `json:"total,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
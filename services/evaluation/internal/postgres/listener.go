@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"evaluation/internal/config"
+
+	"github.com/lib/pq"
+)
+
+// listenerPingInterval интервал проверки соединения при отсутствии уведомлений
+const listenerPingInterval = 90 * time.Second
+
+// Listener подписка на уведомления канала PostgreSQL (LISTEN/NOTIFY)
+// Держит отдельное соединение и переподключается при его обрыве
+type Listener struct {
+	listener *pq.Listener
+	payloads chan string
+	done     chan struct{}
+}
+
+// NewListener подписывается на уведомления канала
+func NewListener(cfg *config.PostgresConfig, channel string) (*Listener, error) {
+	pqListener := pq.NewListener(connString(cfg), 10*time.Second, time.Minute,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Printf("Postgres listener connection event %d: %v", event, err)
+			}
+		})
+
+	if err := pqListener.Listen(channel); err != nil {
+		pqListener.Close()
+		return nil, fmt.Errorf("failed to listen channel %s: %w", channel, err)
+	}
+
+	l := &Listener{
+		listener: pqListener,
+		payloads: make(chan string, 100),
+		done:     make(chan struct{}),
+	}
+	go l.run()
+
+	return l, nil
+}
+
+// Notifications возвращает канал с содержимым уведомлений
+// Канал закрывается после Close
+func (l *Listener) Notifications() <-chan string {
+	return l.payloads
+}
+
+// Close закрывает соединение подписки
+func (l *Listener) Close() error {
+	close(l.done)
+	return l.listener.Close()
+}
+
+// run пересылает уведомления в канал payloads и периодически проверяет соединение
+func (l *Listener) run() {
+	defer close(l.payloads)
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case notification, ok := <-l.listener.Notify:
+			if !ok {
+				return
+			}
+			// nil приходит после переподключения - уведомления за время обрыва потеряны
+			if notification == nil {
+				continue
+			}
+			select {
+			case l.payloads <- notification.Extra:
+			case <-l.done:
+				return
+			}
+		case <-ticker.C:
+			if err := l.listener.Ping(); err != nil {
+				log.Printf("Postgres listener ping failed: %v", err)
+			}
+		}
+	}
+}
@@ -15,8 +15,7 @@ type Client struct {
 }
 
 func New(cfg *config.PostgresConfig) (*Client, error) {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode, int(cfg.ConnectTimeout.Seconds()))
+	dsn := connString(cfg)
 
 	fmt.Println("dsn", dsn)
 	
@@ -41,6 +40,12 @@ func New(cfg *config.PostgresConfig) (*Client, error) {
 	return &Client{DB: db}, nil
 }
 
+// connString формирует строку подключения к PostgreSQL
+func connString(cfg *config.PostgresConfig) string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode, int(cfg.ConnectTimeout.Seconds()))
+}
+
 func (c *Client) Close() error {
 	return c.DB.Close()
 }
@@ -5,9 +5,13 @@
 package db
 
 import (
+	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type FileType string
@@ -55,6 +59,52 @@ func (ns NullFileType) Value() (driver.Value, error) {
 	return string(ns.FileType), nil
 }
 
+type JobState string
+
+const (
+	JobStateQueued    JobState = "queued"
+	JobStateRunning   JobState = "running"
+	JobStateCompleted JobState = "completed"
+	JobStateFailed    JobState = "failed"
+	JobStateCancelled JobState = "cancelled"
+	JobStateDead      JobState = "dead"
+)
+
+func (e *JobState) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = JobState(s)
+	case string:
+		*e = JobState(s)
+	default:
+		return fmt.Errorf("unsupported scan type for JobState: %T", src)
+	}
+	return nil
+}
+
+type NullJobState struct {
+	JobState JobState `json:"job_state"`
+	Valid    bool     `json:"valid"` // Valid is true if JobState is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullJobState) Scan(value interface{}) error {
+	if value == nil {
+		ns.JobState, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.JobState.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullJobState) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.JobState), nil
+}
+
 type ProjectStatus string
 
 const (
@@ -99,6 +149,59 @@ func (ns NullProjectStatus) Value() (driver.Value, error) {
 	return string(ns.ProjectStatus), nil
 }
 
+type DocumentIndex struct {
+	ProjectFileID  int32           `json:"project_file_id"`
+	ProjectID      int32           `json:"project_id"`
+	ContentHash    string          `json:"content_hash"`
+	ChunkSize      int32           `json:"chunk_size"`
+	ChunkOverlap   int32           `json:"chunk_overlap"`
+	EmbeddingModel string          `json:"embedding_model"`
+	Chunks         json.RawMessage `json:"chunks"`
+	IndexedAt      time.Time       `json:"indexed_at"`
+}
+
+type Job struct {
+	ID          uuid.UUID       `json:"id"`
+	ProjectID   int32           `json:"project_id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	State       JobState        `json:"state"`
+	Attempts    int32           `json:"attempts"`
+	RunAfter    time.Time       `json:"run_after"`
+	LockedBy    sql.NullString  `json:"locked_by"`
+	LockedUntil sql.NullTime    `json:"locked_until"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	LastError   sql.NullString  `json:"last_error"`
+	FinishedAt  sql.NullTime    `json:"finished_at"`
+	Priority    int32           `json:"priority"`
+}
+
+type JobRun struct {
+	ID         int32          `json:"id"`
+	JobID      uuid.UUID      `json:"job_id"`
+	ProjectID  int32          `json:"project_id"`
+	Kind       string         `json:"kind"`
+	Attempt    int32          `json:"attempt"`
+	WorkerID   string         `json:"worker_id"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	DurationMs int64          `json:"duration_ms"`
+	Success    bool           `json:"success"`
+	Error      sql.NullString `json:"error"`
+}
+
+type JobSchedule struct {
+	ID        int32        `json:"id"`
+	ProjectID int32        `json:"project_id"`
+	Kind      string       `json:"kind"`
+	CronExpr  string       `json:"cron_expr"`
+	NextRunAt time.Time    `json:"next_run_at"`
+	LastRunAt sql.NullTime `json:"last_run_at"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
 type Project struct {
 	ID        int32         `json:"id"`
 	Name      string        `json:"name"`
@@ -118,6 +221,43 @@ type ProjectFile struct {
 	UploadedAt   time.Time `json:"uploaded_at"`
 }
 
+type ProjectPipeline struct {
+	ProjectID int32         `json:"project_id"`
+	Pipeline  string        `json:"pipeline"`
+	Status    ProjectStatus `json:"status"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+type ProjectReportTemplate struct {
+	ProjectID  int32     `json:"project_id"`
+	TemplateID int32     `json:"template_id"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type ProjectStatusHistory struct {
+	ID         int32         `json:"id"`
+	ProjectID  int32         `json:"project_id"`
+	FromStatus ProjectStatus `json:"from_status"`
+	ToStatus   ProjectStatus `json:"to_status"`
+	Actor      string        `json:"actor"`
+	Reason     string        `json:"reason"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Pipeline   string        `json:"pipeline"`
+}
+
+type ReportTemplate struct {
+	ID                    int32     `json:"id"`
+	Name                  string    `json:"name"`
+	Organization          string    `json:"organization"`
+	RemarksIntro          string    `json:"remarks_intro"`
+	RemarksConclusion     string    `json:"remarks_conclusion"`
+	FinalReportIntro      string    `json:"final_report_intro"`
+	FinalReportConclusion string    `json:"final_report_conclusion"`
+	IsDefault             bool      `json:"is_default"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
 type Remark struct {
 	ID         int32     `json:"id"`
 	ProjectID  int32     `json:"project_id"`
@@ -126,4 +266,5 @@ type Remark struct {
 	Subsection string    `json:"subsection"`
 	Content    string    `json:"content"`
 	CreatedAt  time.Time `json:"created_at"`
+	Duplicates int32     `json:"duplicates"`
 }
@@ -7,47 +7,33 @@ package db
 
 import (
 	"context"
-)
-
-const checkAndUpdateProjectStatus = `-- name: CheckAndUpdateProjectStatus :one
-UPDATE projects 
-SET status = $2
-WHERE id = $1 AND status = 'ready'
-RETURNING id, name, created_at, status
-`
-
-type CheckAndUpdateProjectStatusParams struct {
-	ID     int32         `json:"id"`
-	Status ProjectStatus `json:"status"`
-}
 
-// Атомарно проверяет статус проекта и обновляет его, если он "ready"
-// Возвращает ошибку, если статус не "ready"
-func (q *Queries) CheckAndUpdateProjectStatus(ctx context.Context, arg CheckAndUpdateProjectStatusParams) (Project, error) {
-	row := q.db.QueryRowContext(ctx, checkAndUpdateProjectStatus, arg.ID, arg.Status)
-	var i Project
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.CreatedAt,
-		&i.Status,
-	)
-	return i, err
-}
+	"github.com/lib/pq"
+)
 
 const createProject = `-- name: CreateProject :one
-INSERT INTO projects (name, status)
-VALUES ($1, $2)
-RETURNING id, name, created_at, status
+WITH project AS (
+    INSERT INTO projects (name, status)
+    VALUES ($1, $2)
+    RETURNING id, name, created_at, status
+), pipelines AS (
+    INSERT INTO project_pipelines (project_id, pipeline)
+    SELECT project.id, pipeline
+    FROM project, unnest($3::text[]) AS pipeline
+)
+SELECT id, name, created_at, status
+FROM project
 `
 
 type CreateProjectParams struct {
-	Name   string        `json:"name"`
-	Status ProjectStatus `json:"status"`
+	Name      string        `json:"name"`
+	Status    ProjectStatus `json:"status"`
+	Pipelines []string      `json:"pipelines"`
 }
 
+// Создает проект вместе с его конвейерами обработки в статусе "ready"
 func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (Project, error) {
-	row := q.db.QueryRowContext(ctx, createProject, arg.Name, arg.Status)
+	row := q.db.QueryRowContext(ctx, createProject, arg.Name, arg.Status, pq.Array(arg.Pipelines))
 	var i Project
 	err := row.Scan(
 		&i.ID,
@@ -59,9 +45,9 @@ func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (P
 }
 
 const createRemark = `-- name: CreateRemark :one
-INSERT INTO remarks (project_id, direction, section, subsection, content)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, project_id, direction, section, subsection, content, created_at
+INSERT INTO remarks (project_id, direction, section, subsection, content, duplicates)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, project_id, direction, section, subsection, content, created_at, duplicates
 `
 
 type CreateRemarkParams struct {
@@ -70,6 +56,7 @@ type CreateRemarkParams struct {
 	Section    string `json:"section"`
 	Subsection string `json:"subsection"`
 	Content    string `json:"content"`
+	Duplicates int32  `json:"duplicates"`
 }
 
 func (q *Queries) CreateRemark(ctx context.Context, arg CreateRemarkParams) (Remark, error) {
@@ -79,6 +66,7 @@ func (q *Queries) CreateRemark(ctx context.Context, arg CreateRemarkParams) (Rem
 		arg.Section,
 		arg.Subsection,
 		arg.Content,
+		arg.Duplicates,
 	)
 	var i Remark
 	err := row.Scan(
@@ -89,10 +77,21 @@ func (q *Queries) CreateRemark(ctx context.Context, arg CreateRemarkParams) (Rem
 		&i.Subsection,
 		&i.Content,
 		&i.CreatedAt,
+		&i.Duplicates,
 	)
 	return i, err
 }
 
+const deleteRemarksByProject = `-- name: DeleteRemarksByProject :exec
+DELETE FROM remarks
+WHERE project_id = $1
+`
+
+func (q *Queries) DeleteRemarksByProject(ctx context.Context, projectID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteRemarksByProject, projectID)
+	return err
+}
+
 const getProject = `-- name: GetProject :one
 SELECT id, name, created_at, status
 FROM projects
@@ -112,10 +111,10 @@ func (q *Queries) GetProject(ctx context.Context, id int32) (Project, error) {
 }
 
 const getRemarksByProject = `-- name: GetRemarksByProject :many
-SELECT id, project_id, direction, section, subsection, content, created_at
+SELECT id, project_id, direction, section, subsection, content, created_at, duplicates
 FROM remarks
 WHERE project_id = $1
-ORDER BY created_at DESC
+ORDER BY created_at DESC, id DESC
 `
 
 func (q *Queries) GetRemarksByProject(ctx context.Context, projectID int32) ([]Remark, error) {
@@ -135,6 +134,46 @@ func (q *Queries) GetRemarksByProject(ctx context.Context, projectID int32) ([]R
 			&i.Subsection,
 			&i.Content,
 			&i.CreatedAt,
+			&i.Duplicates,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProjectStatusHistory = `-- name: ListProjectStatusHistory :many
+SELECT id, project_id, from_status, to_status, actor, reason, created_at, pipeline
+FROM project_status_history
+WHERE project_id = $1
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListProjectStatusHistory(ctx context.Context, projectID int32) ([]ProjectStatusHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listProjectStatusHistory, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ProjectStatusHistory{}
+	for rows.Next() {
+		var i ProjectStatusHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.Actor,
+			&i.Reason,
+			&i.CreatedAt,
+			&i.Pipeline,
 		); err != nil {
 			return nil, err
 		}
@@ -183,26 +222,12 @@ func (q *Queries) ListProjects(ctx context.Context) ([]Project, error) {
 	return items, nil
 }
 
-const updateProjectStatus = `-- name: UpdateProjectStatus :one
-UPDATE projects 
-SET status = $2
-WHERE id = $1
-RETURNING id, name, created_at, status
+const publishProjectEvent = `-- name: PublishProjectEvent :exec
+SELECT pg_notify('project_events', $1::text)
 `
 
-type UpdateProjectStatusParams struct {
-	ID     int32         `json:"id"`
-	Status ProjectStatus `json:"status"`
-}
-
-func (q *Queries) UpdateProjectStatus(ctx context.Context, arg UpdateProjectStatusParams) (Project, error) {
-	row := q.db.QueryRowContext(ctx, updateProjectStatus, arg.ID, arg.Status)
-	var i Project
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.CreatedAt,
-		&i.Status,
-	)
-	return i, err
+// Публикует событие проекта (прогресс задачи) в канал project_events
+func (q *Queries) PublishProjectEvent(ctx context.Context, payload string) error {
+	_, err := q.db.ExecContext(ctx, publishProjectEvent, payload)
+	return err
 }
@@ -6,21 +6,88 @@ package db
 
 import (
 	"context"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
-	// Атомарно проверяет статус проекта и обновляет его, если он "ready"
-	// Возвращает ошибку, если статус не "ready"
-	CheckAndUpdateProjectStatus(ctx context.Context, arg CheckAndUpdateProjectStatusParams) (Project, error)
+	// Переносит расписание на следующий запуск, если его еще не перенес другой экземпляр сервиса
+	// Запуск выполняет только тот экземпляр, чье обновление затронуло строку
+	AdvanceJobSchedule(ctx context.Context, arg AdvanceJobScheduleParams) (int64, error)
+	// Отменяет задачу, которая еще ожидает выполнения или выполняется
+	// Воркер, выполняющий задачу, узнает об отмене при продлении блокировки
+	CancelJob(ctx context.Context, arg CancelJobParams) (Job, error)
+	// Захватывает одну готовую к выполнению задачу, а также задачи,
+	// блокировка которых истекла (воркер упал во время выполнения)
+	// Задачи выбираются по приоритету, который повышается на 1 за каждые aging_seconds ожидания,
+	// при равном приоритете - в порядке постановки в очередь
+	// Пропускаются задачи типов excluded_kinds и задачи, конвейер которых уже выполняется у проекта
+	ClaimJob(ctx context.Context, arg ClaimJobParams) (Job, error)
+	ClearProjectReportTemplate(ctx context.Context, projectID int32) error
+	CompleteJob(ctx context.Context, arg CompleteJobParams) (int64, error)
+	CountJobsByState(ctx context.Context, state JobState) (int64, error)
+	CreateJobRun(ctx context.Context, arg CreateJobRunParams) (JobRun, error)
+	CreateJobSchedule(ctx context.Context, arg CreateJobScheduleParams) (JobSchedule, error)
+	// Создает проект вместе с его конвейерами обработки в статусе "ready"
 	CreateProject(ctx context.Context, arg CreateProjectParams) (Project, error)
 	CreateProjectFile(ctx context.Context, arg CreateProjectFileParams) (ProjectFile, error)
 	CreateRemark(ctx context.Context, arg CreateRemarkParams) (Remark, error)
+	CreateReportTemplate(ctx context.Context, arg CreateReportTemplateParams) (ReportTemplate, error)
+	DeleteJobSchedule(ctx context.Context, arg DeleteJobScheduleParams) (int64, error)
+	DeleteRemarksByProject(ctx context.Context, projectID int32) error
+	DeleteReportTemplate(ctx context.Context, id int32) (int64, error)
+	// Добавляет задачу в очередь; задача становится доступной воркерам через delay_ms миллисекунд
+	EnqueueJob(ctx context.Context, arg EnqueueJobParams) (Job, error)
+	// Продлевает блокировку выполняющейся задачи (heartbeat воркера)
+	ExtendJobLock(ctx context.Context, arg ExtendJobLockParams) (int64, error)
+	FailJob(ctx context.Context, arg FailJobParams) (int64, error)
+	// Помечает все активные задачи проекта как проваленные (принудительный сброс проекта)
+	FailProjectJobs(ctx context.Context, arg FailProjectJobsParams) (int64, error)
+	// Находит индекс файла с тем же содержимым и параметрами разбиения, предпочитая индекс с эмбеддингами нужной модели
+	FindDocumentIndexByHash(ctx context.Context, arg FindDocumentIndexByHashParams) (DocumentIndex, error)
+	// Получает шаблон, выбранный для проекта, а если он не выбран - шаблон по умолчанию
+	GetEffectiveReportTemplate(ctx context.Context, projectID int32) (ReportTemplate, error)
+	GetJob(ctx context.Context, id uuid.UUID) (Job, error)
 	GetProject(ctx context.Context, id int32) (Project, error)
 	GetProjectFiles(ctx context.Context, projectID int32) ([]ProjectFile, error)
 	GetProjectFilesByType(ctx context.Context, arg GetProjectFilesByTypeParams) ([]ProjectFile, error)
+	GetProjectPipeline(ctx context.Context, arg GetProjectPipelineParams) (ProjectPipeline, error)
+	GetProjectReportTemplateID(ctx context.Context, projectID int32) (int32, error)
+	// Возвращает глубину общей очереди: готовые и отложенные задачи, выполняющиеся и dead-letter,
+	// а также сколько секунд ждет самая старая готовая к выполнению задача
+	GetQueueDepth(ctx context.Context) (GetQueueDepthRow, error)
 	GetRemarksByProject(ctx context.Context, projectID int32) ([]Remark, error)
+	GetReportTemplate(ctx context.Context, id int32) (ReportTemplate, error)
+	ListDeadJobs(ctx context.Context) ([]Job, error)
+	ListDocumentIndex(ctx context.Context, projectID int32) ([]DocumentIndex, error)
+	// Получает расписания, время запуска которых наступило
+	ListDueJobSchedules(ctx context.Context) ([]JobSchedule, error)
+	ListJobRuns(ctx context.Context, jobID uuid.UUID) ([]JobRun, error)
+	ListJobSchedulesByProject(ctx context.Context, projectID int32) ([]JobSchedule, error)
+	ListJobsByProject(ctx context.Context, projectID int32) ([]Job, error)
+	ListProjectPipelines(ctx context.Context, projectID int32) ([]ProjectPipeline, error)
+	ListProjectStatusHistory(ctx context.Context, projectID int32) ([]ProjectStatusHistory, error)
 	ListProjects(ctx context.Context) ([]Project, error)
-	UpdateProjectStatus(ctx context.Context, arg UpdateProjectStatusParams) (Project, error)
+	ListReportTemplates(ctx context.Context) ([]ReportTemplate, error)
+	// Возвращает конвейеры в статусе обработки, для которых в очереди нет активной задачи
+	ListStuckPipelines(ctx context.Context, graceSeconds int32) ([]ProjectPipeline, error)
+	// Переводит задачу, исчерпавшую попытки повтора, в dead-letter
+	MarkJobDead(ctx context.Context, arg MarkJobDeadParams) (int64, error)
+	// Публикует событие проекта (прогресс задачи) в канал project_events
+	PublishProjectEvent(ctx context.Context, payload string) error
+	// Возвращает задачу из dead-letter в очередь со сброшенным счетчиком попыток
+	RequeueDeadJob(ctx context.Context, id uuid.UUID) (Job, error)
+	// Возвращает проваленную задачу в очередь с отложенным запуском (повтор после backoff)
+	RetryJob(ctx context.Context, arg RetryJobParams) (int64, error)
+	// Делает шаблон шаблоном по умолчанию, снимая признак с предыдущего одним запросом
+	SetDefaultReportTemplate(ctx context.Context, id int32) (int64, error)
+	SetProjectReportTemplate(ctx context.Context, arg SetProjectReportTemplateParams) error
+	// Атомарно переводит конвейер проекта из статуса from_status в to_status и записывает переход в историю
+	// Возвращает ошибку, если статус конвейера уже отличается от ожидаемого
+	TransitionPipelineStatus(ctx context.Context, arg TransitionPipelineStatusParams) (ProjectPipeline, error)
+	UnsetDefaultReportTemplate(ctx context.Context, id int32) error
+	UpdateReportTemplate(ctx context.Context, arg UpdateReportTemplateParams) (ReportTemplate, error)
+	UpsertDocumentIndex(ctx context.Context, arg UpsertDocumentIndexParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,129 @@
+// Package projectstate машина состояний конвейеров обработки проекта
+package projectstate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	db "evaluation/internal/postgres/sqlc"
+)
+
+// Инициаторы переходов статуса, записываемые в историю
+const (
+	ActorUser      = "user"      // запрос пользователя через API
+	ActorWorker    = "worker"    // фоновая задача обработки проекта
+	ActorScheduler = "scheduler" // запуск по расписанию
+	ActorRecovery  = "recovery"  // восстановление зависших проектов при старте сервиса
+	ActorAdmin     = "admin"     // принудительный сброс администратором
+)
+
+// Конвейеры обработки проекта, каждый выполняется независимо от остальных
+// Имя конвейера совпадает с типом задачи, которая его выполняет
+const (
+	PipelineRemarks     = "remarks"      // кластеризация замечаний
+	PipelineChecklist   = "checklist"    // генерация чек-листа
+	PipelineFinalReport = "final_report" // генерация итогового отчета
+)
+
+// Pipelines все конвейеры, создаваемые у проекта
+var Pipelines = []string{PipelineRemarks, PipelineChecklist, PipelineFinalReport}
+
+// ErrInvalidTransition переход между статусами не допускается машиной состояний
+var ErrInvalidTransition = errors.New("invalid project status transition")
+
+// ProcessingStatus возвращает статус конвейера во время обработки
+func ProcessingStatus(pipeline string) (db.ProjectStatus, bool) {
+	switch pipeline {
+	case PipelineRemarks:
+		return db.ProjectStatusProcessingRemarks, true
+	case PipelineChecklist:
+		return db.ProjectStatusProcessingChecklist, true
+	case PipelineFinalReport:
+		return db.ProjectStatusGeneratingFinalReport, true
+	default:
+		return "", false
+	}
+}
+
+// Store хранилище статусов конвейеров и истории их переходов
+type Store interface {
+	// TransitionPipelineStatus атомарно меняет статус конвейера с from на to и записывает переход в историю
+	// Возвращает sql.ErrNoRows, если текущий статус конвейера отличается от from
+	TransitionPipelineStatus(ctx context.Context, projectID int32, pipeline string, from, to db.ProjectStatus, actor, reason string) (*db.ProjectPipeline, error)
+}
+
+// Machine единая точка смены статуса конвейеров проекта
+type Machine struct {
+	store Store
+}
+
+// New создает машину состояний поверх хранилища
+func New(store Store) *Machine {
+	return &Machine{store: store}
+}
+
+// CanTransition проверяет, допускается ли переход конвейера из from в to:
+// обработка запускается только из ready и всегда завершается возвратом в ready
+func CanTransition(pipeline string, from, to db.ProjectStatus) bool {
+	processing, ok := ProcessingStatus(pipeline)
+	if !ok {
+		return false
+	}
+	return (from == db.ProjectStatusReady && to == processing) ||
+		(from == processing && to == db.ProjectStatusReady)
+}
+
+// Transition переводит конвейер проекта из статуса from в to от имени actor
+// Возвращает ErrInvalidTransition для недопустимого перехода и sql.ErrNoRows,
+// если статус конвейера уже изменился
+func (m *Machine) Transition(ctx context.Context, projectID int32, pipeline string, from, to db.ProjectStatus, actor, reason string) (*db.ProjectPipeline, error) {
+	if !CanTransition(pipeline, from, to) {
+		return nil, fmt.Errorf("%w: %s %s -> %s", ErrInvalidTransition, pipeline, from, to)
+	}
+
+	state, err := m.store.TransitionPipelineStatus(ctx, projectID, pipeline, from, to, actor, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Project %d %s pipeline changed %s -> %s by %s: %s", projectID, pipeline, from, to, actor, reason)
+	return state, nil
+}
+
+// Start запускает обработку конвейера, переводя его из ready в статус обработки
+// Возвращает sql.ErrNoRows, если конвейер уже обрабатывается
+func (m *Machine) Start(ctx context.Context, projectID int32, pipeline, actor, reason string) (*db.ProjectPipeline, error) {
+	processing, ok := ProcessingStatus(pipeline)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown pipeline %q", ErrInvalidTransition, pipeline)
+	}
+	return m.Transition(ctx, projectID, pipeline, db.ProjectStatusReady, processing, actor, reason)
+}
+
+// Finish возвращает конвейер из статуса обработки в ready
+// Возвращает sql.ErrNoRows, если конвейер не обрабатывается
+func (m *Machine) Finish(ctx context.Context, projectID int32, pipeline, actor, reason string) (*db.ProjectPipeline, error) {
+	processing, ok := ProcessingStatus(pipeline)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown pipeline %q", ErrInvalidTransition, pipeline)
+	}
+	return m.Transition(ctx, projectID, pipeline, processing, db.ProjectStatusReady, actor, reason)
+}
+
+// actorKey ключ контекста с инициатором перехода
+type actorKey struct{}
+
+// WithActor сохраняет в контексте инициатора переходов, выполняемых в рамках запроса
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom возвращает инициатора из контекста или defaultActor, если он не задан
+func ActorFrom(ctx context.Context, defaultActor string) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return defaultActor
+}
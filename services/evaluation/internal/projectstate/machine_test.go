@@ -0,0 +1,126 @@
+package projectstate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	db "evaluation/internal/postgres/sqlc"
+)
+
+// fakeStore - хранилище статусов конвейеров одного проекта в памяти
+type fakeStore struct {
+	statuses map[string]db.ProjectStatus
+	calls    int
+	actor    string
+	reason   string
+}
+
+func newFakeStore() *fakeStore {
+	statuses := make(map[string]db.ProjectStatus)
+	for _, pipeline := range Pipelines {
+		statuses[pipeline] = db.ProjectStatusReady
+	}
+	return &fakeStore{statuses: statuses}
+}
+
+func (s *fakeStore) TransitionPipelineStatus(ctx context.Context, projectID int32, pipeline string, from, to db.ProjectStatus, actor, reason string) (*db.ProjectPipeline, error) {
+	s.calls++
+	if s.statuses[pipeline] != from {
+		return nil, sql.ErrNoRows
+	}
+	s.statuses[pipeline] = to
+	s.actor = actor
+	s.reason = reason
+	return &db.ProjectPipeline{ProjectID: projectID, Pipeline: pipeline, Status: to}, nil
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		pipeline string
+		from, to db.ProjectStatus
+		want     bool
+	}{
+		{PipelineRemarks, db.ProjectStatusReady, db.ProjectStatusProcessingRemarks, true},
+		{PipelineChecklist, db.ProjectStatusReady, db.ProjectStatusProcessingChecklist, true},
+		{PipelineFinalReport, db.ProjectStatusReady, db.ProjectStatusGeneratingFinalReport, true},
+		{PipelineRemarks, db.ProjectStatusProcessingRemarks, db.ProjectStatusReady, true},
+		{PipelineChecklist, db.ProjectStatusProcessingChecklist, db.ProjectStatusReady, true},
+		{PipelineFinalReport, db.ProjectStatusGeneratingFinalReport, db.ProjectStatusReady, true},
+		{PipelineRemarks, db.ProjectStatusReady, db.ProjectStatusReady, false},
+		{PipelineRemarks, db.ProjectStatusReady, db.ProjectStatusProcessingChecklist, false},
+		{PipelineChecklist, db.ProjectStatusProcessingRemarks, db.ProjectStatusReady, false},
+		{PipelineFinalReport, db.ProjectStatusGeneratingFinalReport, db.ProjectStatusGeneratingFinalReport, false},
+		{"unknown", db.ProjectStatusReady, db.ProjectStatusProcessingRemarks, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.pipeline, tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s, %s) = %v, want %v", tt.pipeline, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestMachine_Transition(t *testing.T) {
+	store := newFakeStore()
+	machine := New(store)
+	ctx := context.Background()
+
+	state, err := machine.Start(ctx, 1, PipelineChecklist, ActorUser, "checklist generation requested")
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if state.Status != db.ProjectStatusProcessingChecklist {
+		t.Errorf("pipeline status = %s, want processing_checklist", state.Status)
+	}
+	if store.actor != ActorUser || store.reason != "checklist generation requested" {
+		t.Errorf("recorded actor = %q, reason = %q", store.actor, store.reason)
+	}
+
+	// Другой конвейер запускается независимо
+	if _, err := machine.Start(ctx, 1, PipelineRemarks, ActorUser, "remarks upload"); err != nil {
+		t.Fatalf("Start() of another pipeline error = %v", err)
+	}
+
+	// Повторный запуск того же конвейера отклоняется хранилищем
+	if _, err := machine.Start(ctx, 1, PipelineChecklist, ActorUser, ""); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Start() error = %v, want sql.ErrNoRows", err)
+	}
+
+	// Недопустимый переход не доходит до хранилища
+	calls := store.calls
+	_, err = machine.Transition(ctx, 1, PipelineChecklist, db.ProjectStatusProcessingChecklist, db.ProjectStatusGeneratingFinalReport, ActorUser, "")
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Transition() error = %v, want ErrInvalidTransition", err)
+	}
+	if _, err := machine.Finish(ctx, 1, "unknown", ActorUser, ""); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Finish() error = %v, want ErrInvalidTransition", err)
+	}
+	if store.calls != calls {
+		t.Errorf("store called %d times after invalid transitions, want %d", store.calls, calls)
+	}
+
+	state, err = machine.Finish(ctx, 1, PipelineChecklist, ActorWorker, "checklist generated")
+	if err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+	if state.Status != db.ProjectStatusReady {
+		t.Errorf("pipeline status = %s, want ready", state.Status)
+	}
+	if store.statuses[PipelineRemarks] != db.ProjectStatusProcessingRemarks {
+		t.Errorf("remarks pipeline status = %s, want processing_remarks", store.statuses[PipelineRemarks])
+	}
+}
+
+func TestActorFrom(t *testing.T) {
+	ctx := context.Background()
+	if got := ActorFrom(ctx, ActorUser); got != ActorUser {
+		t.Errorf("ActorFrom() = %q, want default %q", got, ActorUser)
+	}
+
+	ctx = WithActor(ctx, ActorScheduler)
+	if got := ActorFrom(ctx, ActorUser); got != ActorScheduler {
+		t.Errorf("ActorFrom() = %q, want %q", got, ActorScheduler)
+	}
+}
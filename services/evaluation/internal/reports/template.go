@@ -0,0 +1,244 @@
+// Package reports ресурсы для генерации отчетов: встроенные шрифты и шаблоны оформления
+package reports
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// FontFamily семейство шрифтов отчетов с поддержкой кириллицы
+const FontFamily = "DejaVu"
+
+//go:embed fonts/DejaVuSans.ttf fonts/DejaVuSans-Bold.ttf
+var fonts embed.FS
+
+// AddFonts регистрирует встроенные в бинарник шрифты FontFamily в PDF документе
+// Обычное начертание регистрируется со стилем "", полужирное - со стилем "B"
+func AddFonts(pdf *gofpdf.Fpdf) {
+	regular, _ := fonts.ReadFile("fonts/DejaVuSans.ttf")
+	bold, _ := fonts.ReadFile("fonts/DejaVuSans-Bold.ttf")
+
+	pdf.AddUTF8FontFromBytes(FontFamily, "", regular)
+	pdf.AddUTF8FontFromBytes(FontFamily, "B", bold)
+}
+
+// Template оформление отчетов, настраиваемое для каждой установки сервиса
+// Тексты введения и заключения - шаблоны text/template, см. ReportData
+type Template struct {
+	// Organization название организации на титульной странице
+	Organization string `json:"organization"`
+	// RemarksIntro текст введения отчета по замечаниям
+	RemarksIntro string `json:"remarks_intro"`
+	// RemarksConclusion текст заключения отчета по замечаниям
+	RemarksConclusion string `json:"remarks_conclusion"`
+	// FinalReportIntro текст введения итогового отчета
+	FinalReportIntro string `json:"final_report_intro"`
+	// FinalReportConclusion текст заключения итогового отчета, пустой текст - без заключения
+	FinalReportConclusion string `json:"final_report_conclusion"`
+	// LogoFile логотип для титульной страницы PDF отчетов (PNG или JPEG)
+	// Относительный путь отсчитывается от каталога файла шаблона
+	LogoFile string `json:"logo_file"`
+
+	logo     []byte
+	logoType string
+}
+
+// DefaultTemplate возвращает шаблон, используемый без файла шаблона
+func DefaultTemplate() *Template {
+	return &Template{
+		Organization: "ПАО «Газпром»",
+		RemarksIntro: "Настоящий отчёт подготовлен на основании предоставленных данных. " +
+			"Категории данных сформированы как главы, группы — как подразделы. " +
+			"Для каждого подраздела приведены синтезированное описание и исходные замечания.",
+		RemarksConclusion: "Предложенные мероприятия направлены на снижение неопределённостей и повышение качества " +
+			"прогнозов. Рекомендовано согласовать план доизучения и актуализировать модели по итогам " +
+			"получения новых данных.",
+		FinalReportIntro: "Итоговый отчёт по проекту «{{.ProjectName}}» сформирован {{date .GeneratedAt}}. " +
+			"Отчёт содержит замечания экспертизы, сгруппированные по разделам, и результаты проверки " +
+			"документации по чек-листу.",
+	}
+}
+
+// LoadTemplate загружает шаблон из JSON файла поверх шаблона по умолчанию
+// Пустой путь означает шаблон по умолчанию, незаданные в файле поля сохраняют значения по умолчанию
+func LoadTemplate(path string) (*Template, error) {
+	result := DefaultTemplate()
+	if path == "" {
+		return result, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report template: %w", err)
+	}
+
+	var overrides Template
+	if err := json.Unmarshal(content, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse report template %s: %w", path, err)
+	}
+
+	result = result.With(overrides)
+	if err := result.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid report template %s: %w", path, err)
+	}
+
+	if overrides.LogoFile != "" {
+		logoPath := overrides.LogoFile
+		if !filepath.IsAbs(logoPath) {
+			logoPath = filepath.Join(filepath.Dir(path), logoPath)
+		}
+		if err := result.loadLogo(logoPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// With возвращает копию шаблона, в которой непустые тексты заменены текстами overrides
+// Логотип сохраняется из исходного шаблона
+func (t *Template) With(overrides Template) *Template {
+	result := *t
+	for _, field := range []struct {
+		target   *string
+		override string
+	}{
+		{&result.Organization, overrides.Organization},
+		{&result.RemarksIntro, overrides.RemarksIntro},
+		{&result.RemarksConclusion, overrides.RemarksConclusion},
+		{&result.FinalReportIntro, overrides.FinalReportIntro},
+		{&result.FinalReportConclusion, overrides.FinalReportConclusion},
+	} {
+		if field.override != "" {
+			*field.target = field.override
+		}
+	}
+	return &result
+}
+
+// loadLogo читает логотип и определяет его формат по расширению
+func (t *Template) loadLogo(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		t.logoType = "PNG"
+	case ".jpg", ".jpeg":
+		t.logoType = "JPG"
+	default:
+		return fmt.Errorf("unsupported logo format %s: only PNG and JPEG are supported", path)
+	}
+
+	logo, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read logo: %w", err)
+	}
+
+	t.LogoFile = path
+	t.logo = logo
+	return nil
+}
+
+// Logo возвращает логотип и его формат для gofpdf, nil если логотип не задан
+func (t *Template) Logo() ([]byte, string) {
+	return t.logo, t.logoType
+}
+
+// ReportData данные проекта, доступные в текстах шаблона
+// Например: "Проект {{.ProjectName}}: {{.Remarks}} замечаний в {{len .Sections}} разделах на {{date .GeneratedAt}}"
+// Данные также используются в сводке по замечаниям на первой странице отчета
+type ReportData struct {
+	ProjectName      string
+	ProjectCreatedAt time.Time
+	GeneratedAt      time.Time
+	// Sections разделы отчета в порядке их следования
+	Sections []SectionSummary
+	// Groups и Remarks общее количество групп и исходных замечаний
+	Groups  int
+	Remarks int
+	// Merged количество замечаний, объединенных в группы как дубликаты
+	Merged int
+	// ChecklistCriteria количество проверенных критериев чек-листа, только в итоговом отчете
+	ChecklistCriteria int
+}
+
+// SectionSummary количество групп и замечаний раздела отчета
+type SectionSummary struct {
+	Title   string
+	Groups  int
+	Remarks int
+	// Merged замечания группы сверх первого считаются объединенными дубликатами
+	Merged int
+}
+
+// AddSection добавляет раздел в конец отчета и учитывает его в общих количествах
+func (d *ReportData) AddSection(summary SectionSummary) {
+	d.Sections = append(d.Sections, summary)
+	d.Groups += summary.Groups
+	d.Remarks += summary.Remarks
+	d.Merged += summary.Merged
+}
+
+// templateFuncs функции, доступные в текстах шаблона
+var templateFuncs = template.FuncMap{
+	"date": func(t time.Time) string {
+		return t.Format("02.01.2006")
+	},
+	"datetime": func(t time.Time) string {
+		return t.Format("02.01.2006 15:04")
+	},
+}
+
+// Execute возвращает копию шаблона с текстами, заполненными данными проекта
+func (t *Template) Execute(data ReportData) (*Template, error) {
+	result := *t
+	for _, field := range []struct {
+		name   string
+		target *string
+	}{
+		{"remarks_intro", &result.RemarksIntro},
+		{"remarks_conclusion", &result.RemarksConclusion},
+		{"final_report_intro", &result.FinalReportIntro},
+		{"final_report_conclusion", &result.FinalReportConclusion},
+	} {
+		text, err := executeText(field.name, *field.target, data)
+		if err != nil {
+			return nil, err
+		}
+		*field.target = text
+	}
+	return &result, nil
+}
+
+// Validate проверяет, что тексты шаблона разбираются и заполняются на примере данных проекта
+func (t *Template) Validate() error {
+	_, err := t.Execute(ReportData{
+		ProjectName:      "Проект",
+		ProjectCreatedAt: time.Now(),
+		GeneratedAt:      time.Now(),
+		Sections:         []SectionSummary{{Title: "Раздел", Groups: 1, Remarks: 1}},
+		Groups:           1,
+		Remarks:          1,
+	})
+	return err
+}
+
+// executeText заполняет текст шаблона данными проекта
+func executeText(name, text string, data ReportData) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to execute %s: %w", name, err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,120 @@
+package reports
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTemplate_Default(t *testing.T) {
+	template, err := LoadTemplate("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTemplate(), template)
+
+	logo, _ := template.Logo()
+	assert.Nil(t, logo)
+}
+
+func TestLoadTemplate_Overrides(t *testing.T) {
+	dir := t.TempDir()
+	writeLogo(t, filepath.Join(dir, "logo.png"))
+
+	path := filepath.Join(dir, "template.json")
+	content := `{"organization": "ООО «Недра»", "logo_file": "logo.png"}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	template, err := LoadTemplate(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ООО «Недра»", template.Organization)
+	// Незаданные поля берутся из шаблона по умолчанию
+	assert.Equal(t, DefaultTemplate().RemarksIntro, template.RemarksIntro)
+
+	// Логотип ищется относительно файла шаблона
+	logo, logoType := template.Logo()
+	assert.NotEmpty(t, logo)
+	assert.Equal(t, "PNG", logoType)
+}
+
+func TestLoadTemplate_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := LoadTemplate(filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "failed to read report template")
+
+	broken := filepath.Join(dir, "broken.json")
+	require.NoError(t, os.WriteFile(broken, []byte("{"), 0o644))
+	_, err = LoadTemplate(broken)
+	assert.ErrorContains(t, err, "failed to parse report template")
+
+	gif := filepath.Join(dir, "gif.json")
+	require.NoError(t, os.WriteFile(gif, []byte(`{"logo_file": "logo.gif"}`), 0o644))
+	_, err = LoadTemplate(gif)
+	assert.ErrorContains(t, err, "unsupported logo format")
+}
+
+func TestTemplate_With(t *testing.T) {
+	base := DefaultTemplate()
+	merged := base.With(Template{Organization: "ООО «Недра»", FinalReportConclusion: "Итог"})
+
+	assert.Equal(t, "ООО «Недра»", merged.Organization)
+	assert.Equal(t, "Итог", merged.FinalReportConclusion)
+	assert.Equal(t, base.RemarksIntro, merged.RemarksIntro)
+	// Исходный шаблон не меняется
+	assert.Equal(t, "ПАО «Газпром»", base.Organization)
+}
+
+func TestTemplate_Execute(t *testing.T) {
+	template := DefaultTemplate().With(Template{
+		RemarksIntro:      "Проект {{.ProjectName}} на {{date .GeneratedAt}}: {{.Remarks}} замечаний.",
+		RemarksConclusion: "{{range .Sections}}{{.Title}} - {{.Groups}}/{{.Remarks}}; {{end}}",
+	})
+	data := ReportData{
+		ProjectName: "Месторождение",
+		GeneratedAt: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+		Sections:    []SectionSummary{{Title: "Геология", Groups: 2, Remarks: 5}},
+		Remarks:     5,
+	}
+
+	rendered, err := template.Execute(data)
+	require.NoError(t, err)
+	assert.Equal(t, "Проект Месторождение на 16.10.2026: 5 замечаний.", rendered.RemarksIntro)
+	assert.Equal(t, "Геология - 2/5; ", rendered.RemarksConclusion)
+	assert.Contains(t, rendered.FinalReportIntro, "Месторождение")
+
+	// Неизвестные поля и синтаксические ошибки обнаруживаются при проверке шаблона
+	assert.Error(t, DefaultTemplate().With(Template{RemarksIntro: "{{.Unknown}}"}).Validate())
+	assert.Error(t, DefaultTemplate().With(Template{RemarksIntro: "{{.ProjectName"}).Validate())
+	assert.NoError(t, DefaultTemplate().Validate())
+}
+
+func TestAddFonts(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	AddFonts(pdf)
+	pdf.AddPage()
+	pdf.SetFont(FontFamily, "B", 14)
+	pdf.Cell(0, 10, "Отчёт по результатам анализа замечаний")
+	pdf.SetFont(FontFamily, "", 12)
+	pdf.Cell(0, 10, "Геологическая модель")
+
+	var buffer bytes.Buffer
+	require.NoError(t, pdf.Output(&buffer))
+	assert.True(t, bytes.HasPrefix(buffer.Bytes(), []byte("%PDF")))
+}
+
+// writeLogo записывает PNG изображение для тестов логотипа
+func writeLogo(t *testing.T, path string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, png.Encode(f, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+}
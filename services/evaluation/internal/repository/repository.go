@@ -2,29 +2,53 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"evaluation/internal/models"
 	"evaluation/internal/postgres"
 	db "evaluation/internal/postgres/sqlc"
+	"evaluation/internal/projectstate"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// uniqueViolation код ошибки PostgreSQL при нарушении уникального индекса
+const uniqueViolation = "23505"
+
 // Repository объединяет все операции с базой данных
 type Repository struct {
 	querier db.Querier
+	// transact выполняет fn в одной транзакции: при ошибке fn все ее запросы откатываются
+	transact func(ctx context.Context, fn func(q db.Querier) error) error
 }
 
 // New создает новый экземпляр репозитория
 func New(pgClient *postgres.Client) *Repository {
-	querier := db.New(pgClient.DB)
-	return &Repository{querier: querier}
+	queries := db.New(pgClient.DB)
+	return &Repository{
+		querier: queries,
+		transact: func(ctx context.Context, fn func(q db.Querier) error) error {
+			tx, err := pgClient.BeginTx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			if err := fn(queries.WithTx(tx)); err != nil {
+				tx.Rollback()
+				return err
+			}
+			return tx.Commit()
+		},
+	}
 }
 
-// CreateProject создает новый проект
+// CreateProject создает новый проект вместе с его конвейерами обработки
 func (r *Repository) CreateProject(ctx context.Context, name string) (*db.Project, error) {
 	arg := db.CreateProjectParams{
-		Name:   name,
-		Status: db.ProjectStatusReady, // По умолчанию статус "готов"
+		Name:      name,
+		Status:    db.ProjectStatusReady, // По умолчанию статус "готов"
+		Pipelines: projectstate.Pipelines,
 	}
 
 	project, err := r.querier.CreateProject(ctx, arg)
@@ -48,33 +72,59 @@ func (r *Repository) GetProject(ctx context.Context, id int32) (*db.Project, err
 	return &project, nil
 }
 
-// CheckAndUpdateProjectStatus атомарно проверяет и обновляет статус проекта
-// Возвращает ошибку, если проект не найден или статус не "ready"
-func (r *Repository) CheckAndUpdateProjectStatus(ctx context.Context, projectID int32, newStatus db.ProjectStatus) (*db.Project, error) {
-	arg := db.CheckAndUpdateProjectStatusParams{
-		ID:     projectID,
-		Status: newStatus,
+// ListStuckPipelines получает конвейеры в статусе обработки без активной задачи в очереди,
+// статус которых не менялся дольше grace
+func (r *Repository) ListStuckPipelines(ctx context.Context, grace time.Duration) ([]db.ProjectPipeline, error) {
+	return r.querier.ListStuckPipelines(ctx, int32(grace.Seconds()))
+}
+
+// GetProjectPipeline получает состояние конвейера проекта
+func (r *Repository) GetProjectPipeline(ctx context.Context, projectID int32, pipeline string) (*db.ProjectPipeline, error) {
+	arg := db.GetProjectPipelineParams{
+		ProjectID: projectID,
+		Pipeline:  pipeline,
 	}
 
-	project, err := r.querier.CheckAndUpdateProjectStatus(ctx, arg)
+	state, err := r.querier.GetProjectPipeline(ctx, arg)
 	if err != nil {
 		return nil, err
 	}
-	return &project, nil
+	return &state, nil
+}
+
+// ListProjectPipelines получает состояние всех конвейеров проекта
+func (r *Repository) ListProjectPipelines(ctx context.Context, projectID int32) ([]db.ProjectPipeline, error) {
+	return r.querier.ListProjectPipelines(ctx, projectID)
 }
 
-// UpdateProjectStatus обновляет статус проекта
-func (r *Repository) UpdateProjectStatus(ctx context.Context, projectID int32, newStatus db.ProjectStatus) (*db.Project, error) {
-	arg := db.UpdateProjectStatusParams{
-		ID:     projectID,
-		Status: newStatus,
+// TransitionPipelineStatus атомарно переводит конвейер проекта из статуса from в to и записывает переход в историю
+// Возвращает sql.ErrNoRows, если статус конвейера отличается от from
+func (r *Repository) TransitionPipelineStatus(ctx context.Context, projectID int32, pipeline string, from, to db.ProjectStatus, actor, reason string) (*db.ProjectPipeline, error) {
+	arg := db.TransitionPipelineStatusParams{
+		ProjectID:  projectID,
+		Pipeline:   pipeline,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      actor,
+		Reason:     reason,
 	}
 
-	project, err := r.querier.UpdateProjectStatus(ctx, arg)
+	state, err := r.querier.TransitionPipelineStatus(ctx, arg)
 	if err != nil {
 		return nil, err
 	}
-	return &project, nil
+	return &state, nil
+}
+
+// ListProjectStatusHistory получает историю переходов статуса проекта в хронологическом порядке
+func (r *Repository) ListProjectStatusHistory(ctx context.Context, projectID int32) ([]db.ProjectStatusHistory, error) {
+	return r.querier.ListProjectStatusHistory(ctx, projectID)
+}
+
+// PublishProjectEvent публикует событие проекта в канал project_events
+// Получают его все экземпляры сервиса, подписанные на канал
+func (r *Repository) PublishProjectEvent(ctx context.Context, payload []byte) error {
+	return r.querier.PublishProjectEvent(ctx, string(payload))
 }
 
 // GetProjectFilesByType получает файлы проекта по типу
@@ -110,9 +160,381 @@ func (r *Repository) CreateProjectFile(ctx context.Context, projectID int32, fil
 	return &file, nil
 }
 
-// CreateRemark создает новое замечание
-func (r *Repository) CreateRemark(ctx context.Context, arg db.CreateRemarkParams) (db.Remark, error) {
-	return r.querier.CreateRemark(ctx, arg)
+// ReplaceRemarks заменяет замечания проекта результатом новой обработки одной транзакцией,
+// чтобы в итоговый отчет не попадали замечания прошлых обработок
+func (r *Repository) ReplaceRemarks(ctx context.Context, projectID int32, remarks []db.CreateRemarkParams) error {
+	return r.transact(ctx, func(q db.Querier) error {
+		if err := q.DeleteRemarksByProject(ctx, projectID); err != nil {
+			return err
+		}
+		for _, remark := range remarks {
+			remark.ProjectID = projectID
+			if _, err := q.CreateRemark(ctx, remark); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetRemarksByProject получает замечания проекта, начиная с самых новых
+func (r *Repository) GetRemarksByProject(ctx context.Context, projectID int32) ([]db.Remark, error) {
+	return r.querier.GetRemarksByProject(ctx, projectID)
+}
+
+// EnqueueJob добавляет задачу в персистентную очередь
+func (r *Repository) EnqueueJob(ctx context.Context, arg db.EnqueueJobParams) (*db.Job, error) {
+	job, err := r.querier.EnqueueJob(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ClaimJob захватывает следующую готовую к выполнению задачу с наивысшим приоритетом
+// Приоритет ожидающей задачи повышается на 1 за каждый интервал agingInterval
+// Задачи типов excludedKinds и задачи проектов, у которых уже выполняется задача, пропускаются
+// Возвращает sql.ErrNoRows, если подходящих задач нет
+func (r *Repository) ClaimJob(ctx context.Context, workerID string, lockTimeout, agingInterval time.Duration, excludedKinds []string) (*db.Job, error) {
+	// NULL вместо пустого массива исключил бы все задачи
+	if excludedKinds == nil {
+		excludedKinds = []string{}
+	}
+
+	arg := db.ClaimJobParams{
+		WorkerID:      workerID,
+		LockSeconds:   int32(lockTimeout.Seconds()),
+		ExcludedKinds: excludedKinds,
+		AgingSeconds:  int32(max(agingInterval.Seconds(), 1)),
+	}
+
+	job, err := r.querier.ClaimJob(ctx, arg)
+	if err != nil {
+		// Другой воркер одновременно захватил задачу того же проекта - задачу возьмем при следующем опросе
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ExtendJobLock продлевает блокировку задачи, захваченной воркером
+// Возвращает false, если задача больше не принадлежит воркеру
+func (r *Repository) ExtendJobLock(ctx context.Context, jobID uuid.UUID, workerID string, lockTimeout time.Duration) (bool, error) {
+	arg := db.ExtendJobLockParams{
+		LockSeconds: int32(lockTimeout.Seconds()),
+		ID:          jobID,
+		WorkerID:    workerID,
+	}
+
+	rows, err := r.querier.ExtendJobLock(ctx, arg)
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// CompleteJob помечает задачу как успешно выполненную
+func (r *Repository) CompleteJob(ctx context.Context, jobID uuid.UUID, workerID string) error {
+	_, err := r.querier.CompleteJob(ctx, db.CompleteJobParams{ID: jobID, WorkerID: workerID})
+	return err
+}
+
+// FailJob помечает задачу как завершившуюся с ошибкой
+func (r *Repository) FailJob(ctx context.Context, jobID uuid.UUID, workerID string, errText string) error {
+	arg := db.FailJobParams{
+		LastError: errText,
+		ID:        jobID,
+		WorkerID:  workerID,
+	}
+
+	_, err := r.querier.FailJob(ctx, arg)
+	return err
+}
+
+// RetryJob возвращает задачу в очередь для повторного запуска через delay
+func (r *Repository) RetryJob(ctx context.Context, jobID uuid.UUID, workerID string, errText string, delay time.Duration) error {
+	arg := db.RetryJobParams{
+		LastError: errText,
+		DelayMs:   delay.Milliseconds(),
+		ID:        jobID,
+		WorkerID:  workerID,
+	}
+
+	_, err := r.querier.RetryJob(ctx, arg)
+	return err
+}
+
+// MarkJobDead переводит задачу, исчерпавшую попытки повтора, в dead-letter
+func (r *Repository) MarkJobDead(ctx context.Context, jobID uuid.UUID, workerID string, errText string) error {
+	arg := db.MarkJobDeadParams{
+		LastError: errText,
+		ID:        jobID,
+		WorkerID:  workerID,
+	}
+
+	_, err := r.querier.MarkJobDead(ctx, arg)
+	return err
+}
+
+// ListDeadJobs получает задачи в dead-letter, начиная с последних
+func (r *Repository) ListDeadJobs(ctx context.Context) ([]db.Job, error) {
+	return r.querier.ListDeadJobs(ctx)
+}
+
+// RequeueDeadJob возвращает задачу из dead-letter в очередь
+// Возвращает sql.ErrNoRows, если задача не найдена или не находится в dead-letter
+func (r *Repository) RequeueDeadJob(ctx context.Context, jobID uuid.UUID) (*db.Job, error) {
+	job, err := r.querier.RequeueDeadJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CancelJob отменяет ожидающую или выполняющуюся задачу
+// Возвращает sql.ErrNoRows, если задача не найдена или уже завершена
+func (r *Repository) CancelJob(ctx context.Context, jobID uuid.UUID, reason string) (*db.Job, error) {
+	job, err := r.querier.CancelJob(ctx, db.CancelJobParams{
+		Reason: reason,
+		ID:     jobID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CountJobsByState возвращает количество задач в указанном состоянии
+func (r *Repository) CountJobsByState(ctx context.Context, state db.JobState) (int64, error) {
+	return r.querier.CountJobsByState(ctx, state)
+}
+
+// GetQueueDepth возвращает глубину общей очереди задач
+func (r *Repository) GetQueueDepth(ctx context.Context) (*db.GetQueueDepthRow, error) {
+	depth, err := r.querier.GetQueueDepth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &depth, nil
+}
+
+// GetJob получает задачу по ID
+func (r *Repository) GetJob(ctx context.Context, jobID uuid.UUID) (*db.Job, error) {
+	job, err := r.querier.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListJobsByProject получает задачи проекта, начиная с последних
+func (r *Repository) ListJobsByProject(ctx context.Context, projectID int32) ([]db.Job, error) {
+	return r.querier.ListJobsByProject(ctx, projectID)
+}
+
+// RecordJobRun сохраняет результат выполнения задачи в историю
+func (r *Repository) RecordJobRun(ctx context.Context, arg db.CreateJobRunParams) error {
+	_, err := r.querier.CreateJobRun(ctx, arg)
+	return err
+}
+
+// ListJobRuns получает историю выполнения задачи
+func (r *Repository) ListJobRuns(ctx context.Context, jobID uuid.UUID) ([]db.JobRun, error) {
+	return r.querier.ListJobRuns(ctx, jobID)
+}
+
+// FailProjectJobs помечает активные задачи проекта как проваленные и возвращает их количество
+func (r *Repository) FailProjectJobs(ctx context.Context, projectID int32, errText string) (int64, error) {
+	return r.querier.FailProjectJobs(ctx, db.FailProjectJobsParams{
+		LastError: errText,
+		ProjectID: projectID,
+	})
+}
+
+// CreateJobSchedule создает расписание запуска задачи проекта
+func (r *Repository) CreateJobSchedule(ctx context.Context, projectID int32, kind, cronExpr string, nextRunAt time.Time) (*db.JobSchedule, error) {
+	arg := db.CreateJobScheduleParams{
+		ProjectID: projectID,
+		Kind:      kind,
+		CronExpr:  cronExpr,
+		NextRunAt: nextRunAt,
+	}
+
+	schedule, err := r.querier.CreateJobSchedule(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ListJobSchedules получает расписания проекта
+func (r *Repository) ListJobSchedules(ctx context.Context, projectID int32) ([]db.JobSchedule, error) {
+	return r.querier.ListJobSchedulesByProject(ctx, projectID)
+}
+
+// DeleteJobSchedule удаляет расписание проекта
+// Возвращает sql.ErrNoRows, если у проекта нет такого расписания
+func (r *Repository) DeleteJobSchedule(ctx context.Context, projectID, scheduleID int32) error {
+	rows, err := r.querier.DeleteJobSchedule(ctx, db.DeleteJobScheduleParams{
+		ID:        scheduleID,
+		ProjectID: projectID,
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListDueSchedules получает расписания, время запуска которых наступило
+func (r *Repository) ListDueSchedules(ctx context.Context) ([]db.JobSchedule, error) {
+	return r.querier.ListDueJobSchedules(ctx)
+}
+
+// AdvanceSchedule переносит расписание на nextRunAt
+// Возвращает false, если расписание уже перенес другой экземпляр сервиса или оно удалено
+func (r *Repository) AdvanceSchedule(ctx context.Context, scheduleID int32, expectedRunAt, nextRunAt time.Time) (bool, error) {
+	rows, err := r.querier.AdvanceJobSchedule(ctx, db.AdvanceJobScheduleParams{
+		NextRunAt:     nextRunAt,
+		ID:            scheduleID,
+		ExpectedRunAt: expectedRunAt,
+	})
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// CreateReportTemplate создает шаблон отчетов
+// Возвращает models.ErrReportTemplateExists, если шаблон с таким названием уже есть
+func (r *Repository) CreateReportTemplate(ctx context.Context, arg db.CreateReportTemplateParams) (*db.ReportTemplate, error) {
+	template, err := r.querier.CreateReportTemplate(ctx, arg)
+	if err != nil {
+		return nil, reportTemplateError(err)
+	}
+	return &template, nil
+}
+
+// GetReportTemplate получает шаблон отчетов по ID
+func (r *Repository) GetReportTemplate(ctx context.Context, id int32) (*db.ReportTemplate, error) {
+	template, err := r.querier.GetReportTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ListReportTemplates получает все шаблоны отчетов
+func (r *Repository) ListReportTemplates(ctx context.Context) ([]db.ReportTemplate, error) {
+	return r.querier.ListReportTemplates(ctx)
+}
+
+// UpdateReportTemplate заменяет название и тексты шаблона отчетов
+// Возвращает models.ErrReportTemplateExists, если шаблон с таким названием уже есть
+func (r *Repository) UpdateReportTemplate(ctx context.Context, arg db.UpdateReportTemplateParams) (*db.ReportTemplate, error) {
+	template, err := r.querier.UpdateReportTemplate(ctx, arg)
+	if err != nil {
+		return nil, reportTemplateError(err)
+	}
+	return &template, nil
+}
+
+// DeleteReportTemplate удаляет шаблон отчетов вместе с его выбором в проектах
+// Возвращает sql.ErrNoRows, если шаблона нет
+func (r *Repository) DeleteReportTemplate(ctx context.Context, id int32) error {
+	rows, err := r.querier.DeleteReportTemplate(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetDefaultReportTemplate делает шаблон шаблоном по умолчанию или снимает с него этот признак
+func (r *Repository) SetDefaultReportTemplate(ctx context.Context, id int32, isDefault bool) error {
+	if !isDefault {
+		return r.querier.UnsetDefaultReportTemplate(ctx, id)
+	}
+
+	rows, err := r.querier.SetDefaultReportTemplate(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetProjectReportTemplate выбирает шаблон отчетов проекта, nil возвращает проект к шаблону по умолчанию
+func (r *Repository) SetProjectReportTemplate(ctx context.Context, projectID int32, templateID *int32) error {
+	if templateID == nil {
+		return r.querier.ClearProjectReportTemplate(ctx, projectID)
+	}
+	return r.querier.SetProjectReportTemplate(ctx, db.SetProjectReportTemplateParams{
+		ProjectID:  projectID,
+		TemplateID: *templateID,
+	})
+}
+
+// GetProjectReportTemplateID получает ID шаблона, выбранного для проекта, nil если шаблон не выбран
+func (r *Repository) GetProjectReportTemplateID(ctx context.Context, projectID int32) (*int32, error) {
+	templateID, err := r.querier.GetProjectReportTemplateID(ctx, projectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &templateID, nil
+}
+
+// GetEffectiveReportTemplate получает шаблон отчетов проекта: выбранный для проекта или шаблон по умолчанию
+// Возвращает sql.ErrNoRows, если проекту не выбран шаблон и шаблона по умолчанию нет
+func (r *Repository) GetEffectiveReportTemplate(ctx context.Context, projectID int32) (*db.ReportTemplate, error) {
+	template, err := r.querier.GetEffectiveReportTemplate(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ListDocumentIndex получает индекс файлов документации проекта
+func (r *Repository) ListDocumentIndex(ctx context.Context, projectID int32) ([]db.DocumentIndex, error) {
+	return r.querier.ListDocumentIndex(ctx, projectID)
+}
+
+// FindDocumentIndexByHash находит индекс файла с тем же содержимым и параметрами разбиения, nil если его нет
+func (r *Repository) FindDocumentIndexByHash(ctx context.Context, arg db.FindDocumentIndexByHashParams) (*db.DocumentIndex, error) {
+	index, err := r.querier.FindDocumentIndexByHash(ctx, arg)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+// SaveDocumentIndex сохраняет индекс файла документации, заменяя предыдущий
+func (r *Repository) SaveDocumentIndex(ctx context.Context, arg db.UpsertDocumentIndexParams) error {
+	return r.querier.UpsertDocumentIndex(ctx, arg)
+}
+
+// reportTemplateError преобразует нарушение уникальности названия шаблона в models.ErrReportTemplateExists
+func reportTemplateError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return models.ErrReportTemplateExists
+	}
+	return err
 }
 
 // SaveAttach сохраняет информацию о загруженном файле
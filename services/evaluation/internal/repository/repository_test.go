@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
@@ -9,6 +10,8 @@ import (
 	"evaluation/internal/models"
 	db "evaluation/internal/postgres/sqlc"
 
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -33,14 +36,24 @@ func (m *MockQuerier) GetProject(ctx context.Context, id int32) (db.Project, err
 	return args.Get(0).(db.Project), args.Error(1)
 }
 
-func (m *MockQuerier) CheckAndUpdateProjectStatus(ctx context.Context, arg db.CheckAndUpdateProjectStatusParams) (db.Project, error) {
+func (m *MockQuerier) TransitionPipelineStatus(ctx context.Context, arg db.TransitionPipelineStatusParams) (db.ProjectPipeline, error) {
 	args := m.Called(ctx, arg)
-	return args.Get(0).(db.Project), args.Error(1)
+	return args.Get(0).(db.ProjectPipeline), args.Error(1)
 }
 
-func (m *MockQuerier) UpdateProjectStatus(ctx context.Context, arg db.UpdateProjectStatusParams) (db.Project, error) {
+func (m *MockQuerier) GetProjectPipeline(ctx context.Context, arg db.GetProjectPipelineParams) (db.ProjectPipeline, error) {
 	args := m.Called(ctx, arg)
-	return args.Get(0).(db.Project), args.Error(1)
+	return args.Get(0).(db.ProjectPipeline), args.Error(1)
+}
+
+func (m *MockQuerier) ListProjectPipelines(ctx context.Context, projectID int32) ([]db.ProjectPipeline, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]db.ProjectPipeline), args.Error(1)
+}
+
+func (m *MockQuerier) ListProjectStatusHistory(ctx context.Context, projectID int32) ([]db.ProjectStatusHistory, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]db.ProjectStatusHistory), args.Error(1)
 }
 
 func (m *MockQuerier) GetProjectFilesByType(ctx context.Context, arg db.GetProjectFilesByTypeParams) ([]db.ProjectFile, error) {
@@ -53,6 +66,46 @@ func (m *MockQuerier) CreateProjectFile(ctx context.Context, arg db.CreateProjec
 	return args.Get(0).(db.ProjectFile), args.Error(1)
 }
 
+func (m *MockQuerier) ListStuckPipelines(ctx context.Context, graceSeconds int32) ([]db.ProjectPipeline, error) {
+	args := m.Called(ctx, graceSeconds)
+	return args.Get(0).([]db.ProjectPipeline), args.Error(1)
+}
+
+func (m *MockQuerier) PublishProjectEvent(ctx context.Context, payload string) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) FailProjectJobs(ctx context.Context, arg db.FailProjectJobsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) CancelJob(ctx context.Context, arg db.CancelJobParams) (db.Job, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Job), args.Error(1)
+}
+
+func (m *MockQuerier) RetryJob(ctx context.Context, arg db.RetryJobParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) MarkJobDead(ctx context.Context, arg db.MarkJobDeadParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) ListDeadJobs(ctx context.Context) ([]db.Job, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]db.Job), args.Error(1)
+}
+
+func (m *MockQuerier) RequeueDeadJob(ctx context.Context, id uuid.UUID) (db.Job, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(db.Job), args.Error(1)
+}
+
 func (m *MockQuerier) CreateRemark(ctx context.Context, arg db.CreateRemarkParams) (db.Remark, error) {
 	args := m.Called(ctx, arg)
 	return args.Get(0).(db.Remark), args.Error(1)
@@ -68,6 +121,161 @@ func (m *MockQuerier) GetProjectFiles(ctx context.Context, projectID int32) ([]d
 	return args.Get(0).([]db.ProjectFile), args.Error(1)
 }
 
+func (m *MockQuerier) EnqueueJob(ctx context.Context, arg db.EnqueueJobParams) (db.Job, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Job), args.Error(1)
+}
+
+func (m *MockQuerier) ClaimJob(ctx context.Context, arg db.ClaimJobParams) (db.Job, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Job), args.Error(1)
+}
+
+func (m *MockQuerier) ExtendJobLock(ctx context.Context, arg db.ExtendJobLockParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) CompleteJob(ctx context.Context, arg db.CompleteJobParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) FailJob(ctx context.Context, arg db.FailJobParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) CountJobsByState(ctx context.Context, state db.JobState) (int64, error) {
+	args := m.Called(ctx, state)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) GetJob(ctx context.Context, id uuid.UUID) (db.Job, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(db.Job), args.Error(1)
+}
+
+func (m *MockQuerier) ListJobsByProject(ctx context.Context, projectID int32) ([]db.Job, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]db.Job), args.Error(1)
+}
+
+func (m *MockQuerier) CreateJobRun(ctx context.Context, arg db.CreateJobRunParams) (db.JobRun, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.JobRun), args.Error(1)
+}
+
+func (m *MockQuerier) ListJobRuns(ctx context.Context, jobID uuid.UUID) ([]db.JobRun, error) {
+	args := m.Called(ctx, jobID)
+	return args.Get(0).([]db.JobRun), args.Error(1)
+}
+
+func (m *MockQuerier) CreateJobSchedule(ctx context.Context, arg db.CreateJobScheduleParams) (db.JobSchedule, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.JobSchedule), args.Error(1)
+}
+
+func (m *MockQuerier) ListJobSchedulesByProject(ctx context.Context, projectID int32) ([]db.JobSchedule, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]db.JobSchedule), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteJobSchedule(ctx context.Context, arg db.DeleteJobScheduleParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) ListDueJobSchedules(ctx context.Context) ([]db.JobSchedule, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]db.JobSchedule), args.Error(1)
+}
+
+func (m *MockQuerier) AdvanceJobSchedule(ctx context.Context, arg db.AdvanceJobScheduleParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) CreateReportTemplate(ctx context.Context, arg db.CreateReportTemplateParams) (db.ReportTemplate, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.ReportTemplate), args.Error(1)
+}
+
+func (m *MockQuerier) GetReportTemplate(ctx context.Context, id int32) (db.ReportTemplate, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(db.ReportTemplate), args.Error(1)
+}
+
+func (m *MockQuerier) ListReportTemplates(ctx context.Context) ([]db.ReportTemplate, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]db.ReportTemplate), args.Error(1)
+}
+
+func (m *MockQuerier) UpdateReportTemplate(ctx context.Context, arg db.UpdateReportTemplateParams) (db.ReportTemplate, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.ReportTemplate), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteReportTemplate(ctx context.Context, id int32) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) SetDefaultReportTemplate(ctx context.Context, id int32) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) UnsetDefaultReportTemplate(ctx context.Context, id int32) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) SetProjectReportTemplate(ctx context.Context, arg db.SetProjectReportTemplateParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) ClearProjectReportTemplate(ctx context.Context, projectID int32) error {
+	args := m.Called(ctx, projectID)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) GetProjectReportTemplateID(ctx context.Context, projectID int32) (int32, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *MockQuerier) GetEffectiveReportTemplate(ctx context.Context, projectID int32) (db.ReportTemplate, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).(db.ReportTemplate), args.Error(1)
+}
+
+func (m *MockQuerier) ListDocumentIndex(ctx context.Context, projectID int32) ([]db.DocumentIndex, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]db.DocumentIndex), args.Error(1)
+}
+
+func (m *MockQuerier) FindDocumentIndexByHash(ctx context.Context, arg db.FindDocumentIndexByHashParams) (db.DocumentIndex, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.DocumentIndex), args.Error(1)
+}
+
+func (m *MockQuerier) UpsertDocumentIndex(ctx context.Context, arg db.UpsertDocumentIndexParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) DeleteRemarksByProject(ctx context.Context, projectID int32) error {
+	args := m.Called(ctx, projectID)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) GetQueueDepth(ctx context.Context) (db.GetQueueDepthRow, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(db.GetQueueDepthRow), args.Error(1)
+}
+
 // TestRepository_CreateProject тестирует создание проекта
 func TestRepository_CreateProject(t *testing.T) {
 	tests := []struct {
@@ -104,8 +312,9 @@ func TestRepository_CreateProject(t *testing.T) {
 			repo := &Repository{querier: mockQuerier}
 
 			expectedArg := db.CreateProjectParams{
-				Name:   tt.projectName,
-				Status: db.ProjectStatusReady,
+				Name:      tt.projectName,
+				Status:    db.ProjectStatusReady,
+				Pipelines: []string{"remarks", "checklist", "final_report"},
 			}
 
 			mockQuerier.On("CreateProject", mock.Anything, expectedArg).Return(tt.mockProject, tt.mockError)
@@ -246,36 +455,28 @@ func TestRepository_GetProject(t *testing.T) {
 	}
 }
 
-// TestRepository_CheckAndUpdateProjectStatus тестирует проверку и обновление статуса проекта
-func TestRepository_CheckAndUpdateProjectStatus(t *testing.T) {
+// TestRepository_TransitionPipelineStatus тестирует переход статуса конвейера с записью в историю
+func TestRepository_TransitionPipelineStatus(t *testing.T) {
 	tests := []struct {
 		name          string
-		projectID     int32
-		newStatus     db.ProjectStatus
-		mockProject   db.Project
+		mockState     db.ProjectPipeline
 		mockError     error
-		expectedError bool
+		expectedError error
 	}{
 		{
-			name:      "Успешное обновление статуса проекта",
-			projectID: 1,
-			newStatus: db.ProjectStatusProcessingRemarks,
-			mockProject: db.Project{
-				ID:        1,
-				Name:      "Test Project",
-				Status:    db.ProjectStatusProcessingRemarks,
-				CreatedAt: time.Now(),
+			name: "Успешный переход статуса конвейера",
+			mockState: db.ProjectPipeline{
+				ProjectID: 1,
+				Pipeline:  "checklist",
+				Status:    db.ProjectStatusProcessingChecklist,
+				UpdatedAt: time.Now(),
 			},
-			mockError:     nil,
-			expectedError: false,
 		},
 		{
-			name:          "Ошибка при обновлении статуса",
-			projectID:     1,
-			newStatus:     db.ProjectStatusProcessingRemarks,
-			mockProject:   db.Project{},
-			mockError:     errors.New("status update failed"),
-			expectedError: true,
+			name:          "Статус конвейера отличается от ожидаемого",
+			mockState:     db.ProjectPipeline{},
+			mockError:     sql.ErrNoRows,
+			expectedError: sql.ErrNoRows,
 		},
 	}
 
@@ -284,22 +485,26 @@ func TestRepository_CheckAndUpdateProjectStatus(t *testing.T) {
 			mockQuerier := new(MockQuerier)
 			repo := &Repository{querier: mockQuerier}
 
-			expectedArg := db.CheckAndUpdateProjectStatusParams{
-				ID:     tt.projectID,
-				Status: tt.newStatus,
+			expectedArg := db.TransitionPipelineStatusParams{
+				ProjectID:  1,
+				Pipeline:   "checklist",
+				FromStatus: db.ProjectStatusReady,
+				ToStatus:   db.ProjectStatusProcessingChecklist,
+				Actor:      "user",
+				Reason:     "checklist generation requested",
 			}
 
-			mockQuerier.On("CheckAndUpdateProjectStatus", mock.Anything, expectedArg).Return(tt.mockProject, tt.mockError)
+			mockQuerier.On("TransitionPipelineStatus", mock.Anything, expectedArg).Return(tt.mockState, tt.mockError)
 
-			result, err := repo.CheckAndUpdateProjectStatus(context.Background(), tt.projectID, tt.newStatus)
+			result, err := repo.TransitionPipelineStatus(context.Background(), 1, "checklist",
+				db.ProjectStatusReady, db.ProjectStatusProcessingChecklist, "user", "checklist generation requested")
 
-			if tt.expectedError {
-				assert.Error(t, err)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
 				assert.Nil(t, result)
 			} else {
 				assert.NoError(t, err)
-				assert.NotNil(t, result)
-				assert.Equal(t, tt.newStatus, result.Status)
+				assert.Equal(t, db.ProjectStatusProcessingChecklist, result.Status)
 			}
 
 			mockQuerier.AssertExpectations(t)
@@ -307,65 +512,56 @@ func TestRepository_CheckAndUpdateProjectStatus(t *testing.T) {
 	}
 }
 
-// TestRepository_UpdateProjectStatus тестирует обновление статуса проекта
-func TestRepository_UpdateProjectStatus(t *testing.T) {
-	tests := []struct {
-		name          string
-		projectID     int32
-		newStatus     db.ProjectStatus
-		mockProject   db.Project
-		mockError     error
-		expectedError bool
-	}{
-		{
-			name:      "Успешное обновление статуса проекта",
-			projectID: 1,
-			newStatus: db.ProjectStatusGeneratingFinalReport,
-			mockProject: db.Project{
-				ID:        1,
-				Name:      "Test Project",
-				Status:    db.ProjectStatusGeneratingFinalReport,
-				CreatedAt: time.Now(),
-			},
-			mockError:     nil,
-			expectedError: false,
-		},
-		{
-			name:          "Ошибка при обновлении статуса",
-			projectID:     1,
-			newStatus:     db.ProjectStatusGeneratingFinalReport,
-			mockProject:   db.Project{},
-			mockError:     errors.New("update failed"),
-			expectedError: true,
-		},
-	}
+// TestRepository_GetProjectPipeline тестирует получение состояния конвейера проекта
+func TestRepository_GetProjectPipeline(t *testing.T) {
+	mockQuerier := new(MockQuerier)
+	repo := &Repository{querier: mockQuerier}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mockQuerier := new(MockQuerier)
-			repo := &Repository{querier: mockQuerier}
+	state := db.ProjectPipeline{ProjectID: 1, Pipeline: "remarks", Status: db.ProjectStatusProcessingRemarks}
+	mockQuerier.On("GetProjectPipeline", mock.Anything, db.GetProjectPipelineParams{ProjectID: 1, Pipeline: "remarks"}).Return(state, nil)
+	mockQuerier.On("GetProjectPipeline", mock.Anything, db.GetProjectPipelineParams{ProjectID: 2, Pipeline: "remarks"}).Return(db.ProjectPipeline{}, sql.ErrNoRows)
 
-			expectedArg := db.UpdateProjectStatusParams{
-				ID:     tt.projectID,
-				Status: tt.newStatus,
-			}
+	result, err := repo.GetProjectPipeline(context.Background(), 1, "remarks")
+	assert.NoError(t, err)
+	assert.Equal(t, &state, result)
 
-			mockQuerier.On("UpdateProjectStatus", mock.Anything, expectedArg).Return(tt.mockProject, tt.mockError)
+	result, err = repo.GetProjectPipeline(context.Background(), 2, "remarks")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Nil(t, result)
 
-			result, err := repo.UpdateProjectStatus(context.Background(), tt.projectID, tt.newStatus)
+	mockQuerier.AssertExpectations(t)
+}
 
-			if tt.expectedError {
-				assert.Error(t, err)
-				assert.Nil(t, result)
-			} else {
-				assert.NoError(t, err)
-				assert.NotNil(t, result)
-				assert.Equal(t, tt.newStatus, result.Status)
-			}
+// TestRepository_ListProjectStatusHistory тестирует получение истории статусов проекта
+func TestRepository_ListProjectStatusHistory(t *testing.T) {
+	mockQuerier := new(MockQuerier)
+	repo := &Repository{querier: mockQuerier}
 
-			mockQuerier.AssertExpectations(t)
-		})
+	history := []db.ProjectStatusHistory{
+		{ID: 1, ProjectID: 1, FromStatus: db.ProjectStatusReady, ToStatus: db.ProjectStatusProcessingRemarks, Actor: "user", Reason: "remarks uploaded"},
+		{ID: 2, ProjectID: 1, FromStatus: db.ProjectStatusProcessingRemarks, ToStatus: db.ProjectStatusReady, Actor: "worker", Reason: "remarks processed"},
 	}
+	mockQuerier.On("ListProjectStatusHistory", mock.Anything, int32(1)).Return(history, nil)
+
+	result, err := repo.ListProjectStatusHistory(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, history, result)
+	mockQuerier.AssertExpectations(t)
+}
+
+// TestRepository_PublishProjectEvent тестирует публикацию события проекта
+func TestRepository_PublishProjectEvent(t *testing.T) {
+	mockQuerier := new(MockQuerier)
+	repo := &Repository{querier: mockQuerier}
+
+	payload := `{"project_id":1,"type":"progress","message":"criterion 1/2"}`
+	mockQuerier.On("PublishProjectEvent", mock.Anything, payload).Return(nil)
+
+	err := repo.PublishProjectEvent(context.Background(), []byte(payload))
+
+	assert.NoError(t, err)
+	mockQuerier.AssertExpectations(t)
 }
 
 // TestRepository_GetProjectFilesByType тестирует получение файлов проекта по типу
@@ -532,6 +728,191 @@ func TestRepository_CreateProjectFile(t *testing.T) {
 	}
 }
 
+// TestRepository_ClaimJob тестирует захват задачи из очереди
+func TestRepository_ClaimJob(t *testing.T) {
+	tests := []struct {
+		name          string
+		excludedKinds []string
+		mockJob       db.Job
+		mockError     error
+		expectedError error
+	}{
+		{
+			name:          "Успешный захват задачи",
+			excludedKinds: []string{"checklist"},
+			mockJob: db.Job{
+				ID:        uuid.New(),
+				ProjectID: 1,
+				Kind:      "remarks",
+				State:     db.JobStateRunning,
+				Attempts:  1,
+			},
+		},
+		{
+			name:          "Очередь пуста",
+			mockJob:       db.Job{},
+			mockError:     sql.ErrNoRows,
+			expectedError: sql.ErrNoRows,
+		},
+		{
+			name:          "Задачу проекта одновременно захватил другой воркер",
+			mockJob:       db.Job{},
+			mockError:     &pq.Error{Code: "23505"},
+			expectedError: sql.ErrNoRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQuerier := new(MockQuerier)
+			repo := &Repository{querier: mockQuerier}
+
+			// Без исключенных типов передается пустой массив, а не NULL
+			expectedKinds := tt.excludedKinds
+			if expectedKinds == nil {
+				expectedKinds = []string{}
+			}
+			expectedArg := db.ClaimJobParams{
+				WorkerID:      "worker-1",
+				LockSeconds:   120,
+				ExcludedKinds: expectedKinds,
+				AgingSeconds:  60,
+			}
+
+			mockQuerier.On("ClaimJob", mock.Anything, expectedArg).Return(tt.mockJob, tt.mockError)
+
+			result, err := repo.ClaimJob(context.Background(), "worker-1", 2*time.Minute, time.Minute, tt.excludedKinds)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+				assert.Equal(t, tt.mockJob.ID, result.ID)
+				assert.Equal(t, db.JobStateRunning, result.State)
+			}
+
+			mockQuerier.AssertExpectations(t)
+		})
+	}
+}
+
+// TestRepository_ExtendJobLock тестирует продление блокировки задачи
+func TestRepository_ExtendJobLock(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		expectedOK   bool
+	}{
+		{
+			name:         "Блокировка продлена",
+			rowsAffected: 1,
+			expectedOK:   true,
+		},
+		{
+			name:         "Задача захвачена другим воркером",
+			rowsAffected: 0,
+			expectedOK:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQuerier := new(MockQuerier)
+			repo := &Repository{querier: mockQuerier}
+
+			jobID := uuid.New()
+			expectedArg := db.ExtendJobLockParams{
+				LockSeconds: 30,
+				ID:          jobID,
+				WorkerID:    "worker-1",
+			}
+
+			mockQuerier.On("ExtendJobLock", mock.Anything, expectedArg).Return(tt.rowsAffected, nil)
+
+			ok, err := repo.ExtendJobLock(context.Background(), jobID, "worker-1", 30*time.Second)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedOK, ok)
+
+			mockQuerier.AssertExpectations(t)
+		})
+	}
+}
+
+// TestRepository_RetryJob тестирует перевод задержки повтора в миллисекунды
+func TestRepository_RetryJob(t *testing.T) {
+	mockQuerier := new(MockQuerier)
+	repo := &Repository{querier: mockQuerier}
+
+	jobID := uuid.New()
+	expectedArg := db.RetryJobParams{
+		LastError: "service unavailable",
+		DelayMs:   1500,
+		ID:        jobID,
+		WorkerID:  "worker-1",
+	}
+	mockQuerier.On("RetryJob", mock.Anything, expectedArg).Return(int64(1), nil)
+
+	err := repo.RetryJob(context.Background(), jobID, "worker-1", "service unavailable", 1500*time.Millisecond)
+
+	assert.NoError(t, err)
+	mockQuerier.AssertExpectations(t)
+}
+
+// TestRepository_GetJob тестирует получение задачи по ID
+func TestRepository_GetJob(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockJob       db.Job
+		mockError     error
+		expectedError bool
+	}{
+		{
+			name: "Успешное получение задачи",
+			mockJob: db.Job{
+				ID:        uuid.New(),
+				ProjectID: 1,
+				Kind:      "remarks",
+				State:     db.JobStateFailed,
+				LastError: sql.NullString{String: "LLM timeout", Valid: true},
+			},
+			mockError:     nil,
+			expectedError: false,
+		},
+		{
+			name:          "Задача не найдена",
+			mockJob:       db.Job{},
+			mockError:     sql.ErrNoRows,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQuerier := new(MockQuerier)
+			repo := &Repository{querier: mockQuerier}
+
+			jobID := uuid.New()
+			mockQuerier.On("GetJob", mock.Anything, jobID).Return(tt.mockJob, tt.mockError)
+
+			result, err := repo.GetJob(context.Background(), jobID)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+				assert.Equal(t, tt.mockJob.LastError, result.LastError)
+			}
+
+			mockQuerier.AssertExpectations(t)
+		})
+	}
+}
+
 // TestRepository_SaveAttach тестирует сохранение информации о загруженном файле
 func TestRepository_SaveAttach(t *testing.T) {
 	tests := []struct {
@@ -587,3 +968,158 @@ func TestRepository_New(t *testing.T) {
 	assert.NotNil(t, repo)
 	assert.Equal(t, mockQuerier, repo.querier)
 }
+
+// TestRepository_DeleteJobSchedule тестирует удаление расписания проекта
+func TestRepository_DeleteJobSchedule(t *testing.T) {
+	tests := []struct {
+		name          string
+		rows          int64
+		expectedError error
+	}{
+		{
+			name: "Расписание удалено",
+			rows: 1,
+		},
+		{
+			name:          "У проекта нет такого расписания",
+			rows:          0,
+			expectedError: sql.ErrNoRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQuerier := new(MockQuerier)
+			repo := &Repository{querier: mockQuerier}
+
+			expectedArg := db.DeleteJobScheduleParams{ID: 7, ProjectID: 1}
+			mockQuerier.On("DeleteJobSchedule", mock.Anything, expectedArg).Return(tt.rows, nil)
+
+			err := repo.DeleteJobSchedule(context.Background(), 1, 7)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockQuerier.AssertExpectations(t)
+		})
+	}
+}
+
+// TestRepository_AdvanceSchedule тестирует перенос расписания на следующий запуск
+func TestRepository_AdvanceSchedule(t *testing.T) {
+	expected := time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC)
+	next := expected.Add(24 * time.Hour)
+
+	mockQuerier := new(MockQuerier)
+	repo := &Repository{querier: mockQuerier}
+
+	arg := db.AdvanceJobScheduleParams{NextRunAt: next, ID: 7, ExpectedRunAt: expected}
+	mockQuerier.On("AdvanceJobSchedule", mock.Anything, arg).Return(int64(1), nil).Once()
+	mockQuerier.On("AdvanceJobSchedule", mock.Anything, arg).Return(int64(0), nil).Once()
+
+	// Первый экземпляр переносит расписание, второй узнает, что его опередили
+	advanced, err := repo.AdvanceSchedule(context.Background(), 7, expected, next)
+	assert.NoError(t, err)
+	assert.True(t, advanced)
+
+	advanced, err = repo.AdvanceSchedule(context.Background(), 7, expected, next)
+	assert.NoError(t, err)
+	assert.False(t, advanced)
+
+	mockQuerier.AssertExpectations(t)
+}
+
+// TestRepository_CreateReportTemplate тестирует создание шаблона отчетов
+func TestRepository_CreateReportTemplate(t *testing.T) {
+	arg := db.CreateReportTemplateParams{Name: "Недра", Organization: "ООО «Недра»"}
+
+	mockQuerier := new(MockQuerier)
+	repo := &Repository{querier: mockQuerier}
+
+	mockQuerier.On("CreateReportTemplate", mock.Anything, arg).Return(db.ReportTemplate{ID: 1, Name: "Недра"}, nil).Once()
+	mockQuerier.On("CreateReportTemplate", mock.Anything, arg).Return(db.ReportTemplate{}, &pq.Error{Code: uniqueViolation}).Once()
+
+	template, err := repo.CreateReportTemplate(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), template.ID)
+
+	// Название шаблона уникально
+	_, err = repo.CreateReportTemplate(context.Background(), arg)
+	assert.ErrorIs(t, err, models.ErrReportTemplateExists)
+
+	mockQuerier.AssertExpectations(t)
+}
+
+// TestRepository_SetProjectReportTemplate тестирует выбор и сброс шаблона отчетов проекта
+func TestRepository_SetProjectReportTemplate(t *testing.T) {
+	mockQuerier := new(MockQuerier)
+	repo := &Repository{querier: mockQuerier}
+
+	templateID := int32(3)
+	mockQuerier.On("SetProjectReportTemplate", mock.Anything, db.SetProjectReportTemplateParams{ProjectID: 1, TemplateID: 3}).Return(nil)
+	mockQuerier.On("ClearProjectReportTemplate", mock.Anything, int32(1)).Return(nil)
+	mockQuerier.On("GetProjectReportTemplateID", mock.Anything, int32(2)).Return(int32(0), sql.ErrNoRows)
+
+	assert.NoError(t, repo.SetProjectReportTemplate(context.Background(), 1, &templateID))
+	assert.NoError(t, repo.SetProjectReportTemplate(context.Background(), 1, nil))
+
+	// Отсутствие выбора шаблона не считается ошибкой
+	selected, err := repo.GetProjectReportTemplateID(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Nil(t, selected)
+
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestRepository_FindDocumentIndexByHash(t *testing.T) {
+	mockQuerier := new(MockQuerier)
+	repo := &Repository{querier: mockQuerier}
+
+	found := db.FindDocumentIndexByHashParams{ContentHash: "abc", ChunkSize: 700, ChunkOverlap: 150}
+	missing := db.FindDocumentIndexByHashParams{ContentHash: "def", ChunkSize: 700, ChunkOverlap: 150}
+	mockQuerier.On("FindDocumentIndexByHash", mock.Anything, found).Return(db.DocumentIndex{ProjectFileID: 5, ContentHash: "abc"}, nil)
+	mockQuerier.On("FindDocumentIndexByHash", mock.Anything, missing).Return(db.DocumentIndex{}, sql.ErrNoRows)
+
+	index, err := repo.FindDocumentIndexByHash(context.Background(), found)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), index.ProjectFileID)
+
+	// Отсутствие индекса с тем же содержимым не считается ошибкой
+	index, err = repo.FindDocumentIndexByHash(context.Background(), missing)
+	assert.NoError(t, err)
+	assert.Nil(t, index)
+
+	mockQuerier.AssertExpectations(t)
+}
+
+// TestRepository_ReplaceRemarks тестирует замену замечаний проекта в одной транзакции
+func TestRepository_ReplaceRemarks(t *testing.T) {
+	mockQuerier := new(MockQuerier)
+	transactions := 0
+	repo := &Repository{
+		querier: mockQuerier,
+		transact: func(ctx context.Context, fn func(q db.Querier) error) error {
+			transactions++
+			return fn(mockQuerier)
+		},
+	}
+
+	remark := db.CreateRemarkParams{ProjectID: 1, Section: "ПЗ", Subsection: "Группа", Content: "Замечание"}
+	mockQuerier.On("DeleteRemarksByProject", mock.Anything, int32(1)).Return(nil).Once()
+	mockQuerier.On("CreateRemark", mock.Anything, remark).Return(db.Remark{ID: 1}, nil).Once()
+
+	// ID проекта берется из аргумента, а не из параметров замечания
+	err := repo.ReplaceRemarks(context.Background(), 1, []db.CreateRemarkParams{{Section: "ПЗ", Subsection: "Группа", Content: "Замечание"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, transactions)
+
+	// Ошибка вставки возвращается, чтобы транзакция откатила удаление старых замечаний
+	mockQuerier.On("DeleteRemarksByProject", mock.Anything, int32(2)).Return(nil).Once()
+	mockQuerier.On("CreateRemark", mock.Anything, mock.Anything).Return(db.Remark{}, errors.New("insert failed")).Once()
+	err = repo.ReplaceRemarks(context.Background(), 2, []db.CreateRemarkParams{{Section: "ПЗ"}})
+	assert.Error(t, err)
+
+	mockQuerier.AssertExpectations(t)
+}
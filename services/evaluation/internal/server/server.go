@@ -17,17 +17,23 @@ import (
 )
 
 type Server struct {
-	httpServer     *http.Server
-	config         *config.Config
-	projectService services.ProjectService
-	fileService    services.FileService
-	healthService  services.HealthService
-	taskManager    tasks.TaskManager
+	httpServer      *http.Server
+	config          *config.Config
+	projectService  services.ProjectService
+	fileService     services.FileService
+	healthService   services.HealthService
+	jobService      services.JobService
+	recoveryService services.RecoveryService
+	eventService    services.EventService
+	scheduleService services.ScheduleService
+	// reportTemplateService шаблоны отчетов
+	reportTemplateService services.ReportTemplateService
+	taskManager           tasks.TaskManager
 }
 
-func New(cfg *config.Config, projectService services.ProjectService, fileService services.FileService, healthService services.HealthService, taskManager tasks.TaskManager) *Server {
+func New(cfg *config.Config, projectService services.ProjectService, fileService services.FileService, healthService services.HealthService, jobService services.JobService, recoveryService services.RecoveryService, eventService services.EventService, scheduleService services.ScheduleService, reportTemplateService services.ReportTemplateService, taskManager tasks.TaskManager) *Server {
 	// Создаем единый хендлер
-	handler := handler.New(projectService, fileService, healthService, taskManager)
+	handler := handler.New(projectService, fileService, healthService, jobService, recoveryService, eventService, scheduleService, reportTemplateService, taskManager)
 
 	// Создаем роутер с gorilla/mux
 	r := mux.NewRouter()
@@ -72,6 +78,8 @@ func New(cfg *config.Config, projectService services.ProjectService, fileService
 
 	// Специфичные пути для проектов с поддержкой параметров
 	r.HandleFunc("/api/projects/{id:[0-9]+}", handler.HandleProject).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/projects/{id:[0-9]+}/pipelines", handler.HandleProjectPipelines).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/projects/{id:[0-9]+}/history", handler.HandleProjectHistory).Methods("GET", "OPTIONS")
 	r.HandleFunc("/api/projects/{id:[0-9]+}/documentation", handler.HandleDocumentation).Methods("POST", "OPTIONS")
 	r.HandleFunc("/api/projects/{id:[0-9]+}/checklist", handler.HandleChecklist).Methods("POST", "OPTIONS")
 	r.HandleFunc("/api/projects/{id:[0-9]+}/remarks", handler.HandleRemarks).Methods("POST", "OPTIONS")
@@ -82,6 +90,29 @@ func New(cfg *config.Config, projectService services.ProjectService, fileService
 	r.HandleFunc("/api/projects/{id:[0-9]+}/remarks_clustered", handler.HandleGetRemarksClustered).Methods("GET", "OPTIONS")
 	r.HandleFunc("/api/projects/{id:[0-9]+}/final_report", handler.HandleGetFinalReport).Methods("GET", "OPTIONS")
 
+	// Состояние фоновых задач
+	r.HandleFunc("/api/projects/{id:[0-9]+}/jobs", handler.HandleProjectJobs).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/projects/{id:[0-9]+}/jobs/{job_id}/cancel", handler.HandleCancelJob).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/jobs/{job_id}", handler.HandleJob).Methods("GET", "OPTIONS")
+
+	// Расписания периодических задач проекта
+	r.HandleFunc("/api/projects/{id:[0-9]+}/schedules", handler.HandleProjectSchedules).Methods("GET", "POST", "OPTIONS")
+	r.HandleFunc("/api/projects/{id:[0-9]+}/schedules/{schedule_id:[0-9]+}", handler.HandleSchedule).Methods("DELETE", "OPTIONS")
+
+	// Шаблон отчетов проекта
+	r.HandleFunc("/api/projects/{id:[0-9]+}/report_template", handler.HandleProjectReportTemplate).Methods("GET", "PUT", "OPTIONS")
+
+	// Поток событий проекта (Server-Sent Events)
+	r.HandleFunc("/api/projects/{id:[0-9]+}/events", handler.HandleProjectEvents).Methods("GET", "OPTIONS")
+
+	// Административные ручки
+	r.HandleFunc("/api/admin/projects/{id:[0-9]+}/reset", handler.HandleAdminResetProject).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/admin/tasks", handler.HandleAdminTasks).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/admin/jobs/dead", handler.HandleDeadJobs).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/admin/jobs/{job_id}/requeue", handler.HandleRequeueJob).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/admin/report_templates", handler.HandleReportTemplates).Methods("GET", "POST", "OPTIONS")
+	r.HandleFunc("/api/admin/report_templates/{template_id:[0-9]+}", handler.HandleReportTemplate).Methods("GET", "PUT", "DELETE", "OPTIONS")
+
 	// Swagger docs
 	r.PathPrefix("/api/docs/").Handler(httpSwagger.WrapHandler)
 
@@ -94,13 +125,21 @@ func New(cfg *config.Config, projectService services.ProjectService, fileService
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
+	// Shutdown ждет завершения активных запросов - потоки событий закрываем сразу
+	httpServer.RegisterOnShutdown(handler.CloseStreams)
+
 	return &Server{
-		httpServer:     httpServer,
-		config:         cfg,
-		projectService: projectService,
-		fileService:    fileService,
-		healthService:  healthService,
-		taskManager:    taskManager,
+		httpServer:            httpServer,
+		config:                cfg,
+		projectService:        projectService,
+		fileService:           fileService,
+		healthService:         healthService,
+		jobService:            jobService,
+		recoveryService:       recoveryService,
+		eventService:          eventService,
+		scheduleService:       scheduleService,
+		reportTemplateService: reportTemplateService,
+		taskManager:           taskManager,
 	}
 }
 
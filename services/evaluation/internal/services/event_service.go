@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"evaluation/internal/models"
+)
+
+// subscriberBuffer размер буфера событий одного подписчика
+// События для подписчика, не успевающего их читать, отбрасываются
+const subscriberBuffer = 32
+
+// EventListener источник событий проектов, опубликованных любым экземпляром сервиса
+type EventListener interface {
+	Notifications() <-chan string
+}
+
+// eventService реализация EventService поверх канала уведомлений PostgreSQL
+type eventService struct {
+	repo        Repository
+	listener    EventListener
+	mu          sync.RWMutex
+	subscribers map[int32]map[chan models.ProjectEvent]struct{}
+}
+
+// NewEventService создает новый экземпляр EventService
+func NewEventService(repo Repository, listener EventListener) EventService {
+	return &eventService{
+		repo:        repo,
+		listener:    listener,
+		subscribers: make(map[int32]map[chan models.ProjectEvent]struct{}),
+	}
+}
+
+// Start запускает рассылку событий подписчикам
+// Рассылка завершается при отмене контекста или закрытии источника событий
+func (s *eventService) Start(ctx context.Context) {
+	go s.dispatch(ctx)
+}
+
+// Subscribe подписывается на события проекта
+// Возвращаемая функция отменяет подписку
+func (s *eventService) Subscribe(projectID int32) (<-chan models.ProjectEvent, func()) {
+	ch := make(chan models.ProjectEvent, subscriberBuffer)
+
+	s.mu.Lock()
+	if s.subscribers[projectID] == nil {
+		s.subscribers[projectID] = make(map[chan models.ProjectEvent]struct{})
+	}
+	s.subscribers[projectID][ch] = struct{}{}
+	s.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			if _, ok := s.subscribers[projectID][ch]; !ok {
+				return
+			}
+			delete(s.subscribers[projectID], ch)
+			if len(s.subscribers[projectID]) == 0 {
+				delete(s.subscribers, projectID)
+			}
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// ReportProgress публикует сообщение о ходе выполнения задачи проекта
+// Ошибка публикации не прерывает задачу и только логируется
+func (s *eventService) ReportProgress(ctx context.Context, projectID int32, message string, current, total int) {
+	payload, err := json.Marshal(models.ProjectEvent{
+		ProjectID: projectID,
+		Type:      models.ProjectEventProgress,
+		Message:   message,
+		Current:   current,
+		Total:     total,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("Failed to encode progress event for project %d: %v", projectID, err)
+		return
+	}
+
+	if err := s.repo.PublishProjectEvent(ctx, payload); err != nil {
+		log.Printf("Failed to publish progress event for project %d: %v", projectID, err)
+	}
+}
+
+// dispatch разбирает уведомления и передает события подписчикам проекта
+func (s *eventService) dispatch(ctx context.Context) {
+	defer s.closeSubscribers()
+
+	notifications := s.listener.Notifications()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case payload, ok := <-notifications:
+			if !ok {
+				return
+			}
+
+			var event models.ProjectEvent
+			if err := json.Unmarshal([]byte(payload), &event); err != nil {
+				log.Printf("Failed to decode project event %q: %v", payload, err)
+				continue
+			}
+			s.publish(event)
+		}
+	}
+}
+
+// publish передает событие подписчикам проекта, не блокируясь на медленных подписчиках
+func (s *eventService) publish(event models.ProjectEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for ch := range s.subscribers[event.ProjectID] {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("Dropped %s event for project %d: subscriber is too slow", event.Type, event.ProjectID)
+		}
+	}
+}
+
+// closeSubscribers закрывает каналы всех подписчиков после остановки рассылки
+func (s *eventService) closeSubscribers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for projectID, subscribers := range s.subscribers {
+		for ch := range subscribers {
+			close(ch)
+		}
+		delete(s.subscribers, projectID)
+	}
+}
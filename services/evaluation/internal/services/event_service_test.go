@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"evaluation/internal/models"
+)
+
+// fakeEventListener - источник уведомлений для тестов
+type fakeEventListener struct {
+	notifications chan string
+}
+
+func (l *fakeEventListener) Notifications() <-chan string {
+	return l.notifications
+}
+
+// receiveEvent ждет событие из канала подписки
+func receiveEvent(t *testing.T, events <-chan models.ProjectEvent) models.ProjectEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("subscription channel closed")
+		}
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+	return models.ProjectEvent{}
+}
+
+func TestEventService_DeliversEventsToProjectSubscribers(t *testing.T) {
+	listener := &fakeEventListener{notifications: make(chan string, 10)}
+	service := NewEventService(NewMockRepository(), listener)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service.Start(ctx)
+
+	events, unsubscribe := service.Subscribe(1)
+	defer unsubscribe()
+	otherEvents, unsubscribeOther := service.Subscribe(2)
+	defer unsubscribeOther()
+
+	// Невалидное уведомление пропускается
+	listener.notifications <- "not json"
+	listener.notifications <- `{"project_id":1,"type":"status","status":"processing_checklist","created_at":"2026-10-16T18:04:19.123456+00:00"}`
+	listener.notifications <- `{"project_id":1,"type":"progress","message":"criterion 1/2","current":1,"total":2,"created_at":"2026-10-16T18:04:20+00:00"}`
+
+	event := receiveEvent(t, events)
+	if event.Type != models.ProjectEventStatus || event.Status != "processing_checklist" {
+		t.Errorf("first event = %+v, want status processing_checklist", event)
+	}
+
+	event = receiveEvent(t, events)
+	if event.Type != models.ProjectEventProgress || event.Current != 1 || event.Total != 2 {
+		t.Errorf("second event = %+v, want progress 1/2", event)
+	}
+
+	select {
+	case event := <-otherEvents:
+		t.Errorf("subscriber of another project received %+v", event)
+	default:
+	}
+
+	// После отмены подписки канал закрыт
+	unsubscribe()
+	if _, ok := <-events; ok {
+		t.Error("channel must be closed after unsubscribe")
+	}
+
+	// Закрытие источника завершает остальные подписки
+	close(listener.notifications)
+	select {
+	case _, ok := <-otherEvents:
+		if ok {
+			t.Error("channel must be closed after listener is closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscription was not closed")
+	}
+}
+
+func TestEventService_ReportProgress(t *testing.T) {
+	repo := NewMockRepository()
+	service := NewEventService(repo, &fakeEventListener{notifications: make(chan string)})
+
+	service.ReportProgress(context.Background(), 3, "criterion 12/50", 12, 50)
+
+	if len(repo.events) != 1 {
+		t.Fatalf("published events = %d, want 1", len(repo.events))
+	}
+
+	var event models.ProjectEvent
+	if err := json.Unmarshal(repo.events[0], &event); err != nil {
+		t.Fatalf("published payload is not an event: %v", err)
+	}
+	if event.ProjectID != 3 || event.Type != models.ProjectEventProgress || event.Message != "criterion 12/50" ||
+		event.Current != 12 || event.Total != 50 {
+		t.Errorf("published event = %+v", event)
+	}
+}
@@ -2,10 +2,12 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"evaluation/internal/models"
 	"evaluation/internal/postgres"
 	db "evaluation/internal/postgres/sqlc"
+	"evaluation/internal/projectstate"
 	"evaluation/internal/tasks"
 	"fmt"
 	"io"
@@ -19,6 +21,7 @@ import (
 // fileService реализация FileService
 type fileService struct {
 	repo        Repository
+	status      *projectstate.Machine
 	storage     FileStorage
 	taskManager tasks.TaskManager
 	pgClient    *postgres.Client
@@ -28,6 +31,7 @@ type fileService struct {
 func NewFileService(repo Repository, storage FileStorage, taskManager tasks.TaskManager, pgClient *postgres.Client) FileService {
 	return &fileService{
 		repo:        repo,
+		status:      projectstate.New(repo),
 		storage:     storage,
 		taskManager: taskManager,
 		pgClient:    pgClient,
@@ -35,28 +39,22 @@ func NewFileService(repo Repository, storage FileStorage, taskManager tasks.Task
 }
 
 // UploadProjectFile загружает файл в проект
-func (s *fileService) UploadRemarks(ctx context.Context, projectID int32, file io.Reader, filename, fileType string, fileSize int64) (*db.ProjectFile, error) {
-	// Атомарно проверяем статус проекта и изменяем его на "processing_remarks"
-	// Если статус не "ready", возвращаем ошибку
-	project, err := s.repo.CheckAndUpdateProjectStatus(ctx, projectID, db.ProjectStatusProcessingRemarks)
+// format задает формат отчета по замечаниям, пустой формат означает PDF
+func (s *fileService) UploadRemarks(ctx context.Context, projectID int32, file io.Reader, filename, fileType string, fileSize int64, format string) (*db.ProjectFile, error) {
+	format, err := reportFormat(format)
 	if err != nil {
-		// Если проект не найден или статус не "ready", возвращаем соответствующую ошибку
-		if err.Error() == "no rows in result set" {
-			return nil, models.ErrProjectAlreadyProcessing
-		}
 		return nil, err
 	}
 
-	// Логируем успешное изменение статуса проекта
-	log.Printf("Project %d status changed to %s for file upload", project.ID, project.Status)
+	// Атомарно переводим конвейер замечаний из "ready" в "processing_remarks"
+	// Если замечания уже обрабатываются, возвращаем ошибку
+	if err := s.startProcessing(ctx, projectID, tasks.TaskKindRemarks, "remarks upload"); err != nil {
+		return nil, err
+	}
 
-	// Функция для восстановления статуса проекта на 'ready'
-	restoreStatus := func() {
-		if _, err := s.repo.UpdateProjectStatus(ctx, projectID, db.ProjectStatusReady); err != nil {
-			log.Printf("Failed to restore project %d status to ready: %v", projectID, err)
-		} else {
-			log.Printf("Project %d status restored to ready", projectID)
-		}
+	// Функция для восстановления статуса конвейера на 'ready'
+	restoreStatus := func(reason string) {
+		s.restoreReady(ctx, projectID, tasks.TaskKindRemarks, reason)
 	}
 
 	// Валидируем тип файла
@@ -65,14 +63,14 @@ func (s *fileService) UploadRemarks(ctx context.Context, projectID int32, file i
 	case "remarks":
 		dbFileType = db.FileTypeRemarks
 	default:
-		restoreStatus()
+		restoreStatus("remarks upload rejected: invalid file type")
 		return nil, errors.New("invalid file type")
 	}
 
 	// Получаем расширение файла
 	ext := strings.ToLower(filepath.Ext(filename))
 	if ext == "" {
-		restoreStatus()
+		restoreStatus("remarks upload rejected: file has no extension")
 		return nil, errors.New("file must have an extension")
 	}
 
@@ -85,7 +83,7 @@ func (s *fileService) UploadRemarks(ctx context.Context, projectID int32, file i
 	// Загружаем файл в MinIO
 	objectName, err := s.storage.UploadFile(ctx, file, uniqueFileName, contentType)
 	if err != nil {
-		restoreStatus()
+		restoreStatus(fmt.Sprintf("remarks upload failed: %v", err))
 		return nil, err
 	}
 
@@ -93,22 +91,23 @@ func (s *fileService) UploadRemarks(ctx context.Context, projectID int32, file i
 	// Используем objectName как filename, так как это реальное имя файла в MinIO
 	projectFile, err := s.repo.CreateProjectFile(ctx, projectID, objectName, filename, objectName, fileSize, ext, dbFileType)
 	if err != nil {
-		restoreStatus()
+		restoreStatus(fmt.Sprintf("remarks upload failed: %v", err))
 		return nil, err
 	}
 
 	// Создаем и отправляем задачу ProjectProcessorTask в task manager
 	projectTask := tasks.NewProjectProcessorTask(
 		projectID,
-		1, // Приоритет 1 (высокий)
+		tasks.TaskKindRemarks,
+		tasks.PriorityNormal,
 		s.repo,
 		s.storage,
-	)
+	).WithReportFormat(format)
 
-	if err := s.taskManager.SubmitTask(projectTask); err != nil {
+	if _, err := s.taskManager.SubmitTask(ctx, projectTask); err != nil {
 		// Логируем ошибку, но не прерываем выполнение
 		// TODO: добавить proper logging
-		restoreStatus()
+		restoreStatus(fmt.Sprintf("task submission failed: %v", err))
 		return nil, fmt.Errorf("failed to submit project processing task: %w", err)
 	}
 
@@ -117,13 +116,14 @@ func (s *fileService) UploadRemarks(ctx context.Context, projectID int32, file i
 
 // UploadDocumentation загружает файл документации в проект
 func (s *fileService) UploadDocumentation(ctx context.Context, projectID int32, file io.Reader, filename string, fileSize int64) (*db.ProjectFile, error) {
-	// Проверяем статус проекта - должен быть "ready"
-	project, err := s.repo.GetProject(ctx, projectID)
+	// Документация читается генерацией чек-листа - пока она идет, загрузка запрещена
+	// Остальные конвейеры проекта загрузке не мешают
+	status, err := s.pipelineStatus(ctx, projectID, tasks.TaskKindChecklist)
 	if err != nil {
 		return nil, err
 	}
 
-	if project.Status != db.ProjectStatusReady {
+	if status != db.ProjectStatusReady {
 		return nil, models.ErrProjectAlreadyProcessing
 	}
 
@@ -152,40 +152,36 @@ func (s *fileService) UploadDocumentation(ctx context.Context, projectID int32,
 		return nil, err
 	}
 
+	// Индексируем файл в фоне, чтобы генерация чек-листа брала его чанки из индекса документации
+	// Если задачу поставить не удалось, файл проиндексирует следующая генерация чек-листа
+	indexTask := tasks.NewDocumentIndexTask(projectID, projectFile.ID, tasks.PriorityNormal, s.repo, s.storage)
+	if _, err := s.taskManager.SubmitTask(ctx, indexTask); err != nil {
+		log.Printf("Failed to submit indexing task for documentation file %s: %v", projectFile.Filename, err)
+	}
+
 	return projectFile, nil
 }
 
 // GenerateChecklist запускает генерацию чеклиста для проекта
 func (s *fileService) GenerateChecklist(ctx context.Context, projectID int32) error {
-	// Атомарно проверяем статус проекта и изменяем его на "processing_checklist"
-	// Если статус не "ready", возвращаем ошибку
-	project, err := s.repo.CheckAndUpdateProjectStatus(ctx, projectID, db.ProjectStatusProcessingChecklist)
-	if err != nil {
-		// Если проект не найден или статус не "ready", возвращаем соответствующую ошибку
-		if err.Error() == "no rows in result set" {
-			return models.ErrProjectAlreadyProcessing
-		}
+	// Атомарно переводим конвейер чек-листа из "ready" в "processing_checklist"
+	// Если чек-лист уже генерируется, возвращаем ошибку
+	if err := s.startProcessing(ctx, projectID, tasks.TaskKindChecklist, "checklist generation requested"); err != nil {
 		return err
 	}
 
-	// Логируем успешное изменение статуса проекта
-	log.Printf("Project %d status changed to %s for checklist generation", project.ID, project.Status)
-
 	// Создаем и отправляем задачу ProjectProcessorTask в task manager
 	projectTask := tasks.NewProjectProcessorTask(
 		projectID,
-		1, // Приоритет 1 (высокий)
+		tasks.TaskKindChecklist,
+		tasks.PriorityLow, // Долгая обработка не должна задерживать интерактивные задачи
 		s.repo,
 		s.storage,
 	)
 
-	if err := s.taskManager.SubmitTask(projectTask); err != nil {
+	if _, err := s.taskManager.SubmitTask(ctx, projectTask); err != nil {
 		// Восстанавливаем статус проекта на 'ready' в случае ошибки
-		if _, restoreErr := s.repo.UpdateProjectStatus(ctx, projectID, db.ProjectStatusReady); restoreErr != nil {
-			log.Printf("Failed to restore project %d status to ready: %v", projectID, restoreErr)
-		} else {
-			log.Printf("Project %d status restored to ready after task submission failure", projectID)
-		}
+		s.restoreReady(ctx, projectID, tasks.TaskKindChecklist, fmt.Sprintf("task submission failed: %v", err))
 		return fmt.Errorf("failed to submit checklist generation task: %w", err)
 	}
 
@@ -193,52 +189,92 @@ func (s *fileService) GenerateChecklist(ctx context.Context, projectID int32) er
 }
 
 // GenerateFinalReport запускает генерацию финального отчета для проекта
-func (s *fileService) GenerateFinalReport(ctx context.Context, projectID int32) error {
-	// Атомарно проверяем статус проекта и изменяем его на "generating_final_report"
-	// Если статус не "ready", возвращаем ошибку
-	project, err := s.repo.CheckAndUpdateProjectStatus(ctx, projectID, db.ProjectStatusGeneratingFinalReport)
+// format задает формат отчета, пустой формат означает PDF
+func (s *fileService) GenerateFinalReport(ctx context.Context, projectID int32, format string) error {
+	format, err := reportFormat(format)
 	if err != nil {
-		// Если проект не найден или статус не "ready", возвращаем соответствующую ошибку
-		if err.Error() == "no rows in result set" {
-			return models.ErrProjectAlreadyProcessing
-		}
 		return err
 	}
 
-	// Логируем успешное изменение статуса проекта
-	log.Printf("Project %d status changed to %s for final report generation", project.ID, project.Status)
+	// Атомарно переводим конвейер итогового отчета из "ready" в "generating_final_report"
+	// Если отчет уже генерируется, возвращаем ошибку
+	if err := s.startProcessing(ctx, projectID, tasks.TaskKindFinalReport, "final report generation requested"); err != nil {
+		return err
+	}
 
 	// Создаем и отправляем задачу ProjectProcessorTask в task manager
 	projectTask := tasks.NewProjectProcessorTask(
 		projectID,
-		1, // Приоритет 1 (высокий)
+		tasks.TaskKindFinalReport,
+		tasks.PriorityNormal,
 		s.repo,
 		s.storage,
-	)
+	).WithReportFormat(format)
 
-	if err := s.taskManager.SubmitTask(projectTask); err != nil {
+	if _, err := s.taskManager.SubmitTask(ctx, projectTask); err != nil {
 		// Восстанавливаем статус проекта на 'ready' в случае ошибки
-		if _, restoreErr := s.repo.UpdateProjectStatus(ctx, projectID, db.ProjectStatusReady); restoreErr != nil {
-			log.Printf("Failed to restore project %d status to ready: %v", projectID, restoreErr)
-		} else {
-			log.Printf("Project %d status restored to ready after task submission failure", projectID)
-		}
+		s.restoreReady(ctx, projectID, tasks.TaskKindFinalReport, fmt.Sprintf("task submission failed: %v", err))
 		return fmt.Errorf("failed to submit final report generation task: %w", err)
 	}
 
 	return nil
 }
 
+// reportFormat проверяет формат отчета, пустой формат означает PDF
+func reportFormat(format string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		return tasks.ReportFormatPDF, nil
+	}
+	if !tasks.IsReportFormat(format) {
+		return "", models.ErrInvalidReportFormat
+	}
+	return format, nil
+}
+
+// startProcessing атомарно переводит конвейер проекта из ready в статус обработки
+// Возвращает ErrProjectAlreadyProcessing, если проект не найден или конвейер уже обрабатывается
+func (s *fileService) startProcessing(ctx context.Context, projectID int32, pipeline, reason string) error {
+	actor := projectstate.ActorFrom(ctx, projectstate.ActorUser)
+	_, err := s.status.Start(ctx, projectID, pipeline, actor, reason)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrProjectAlreadyProcessing
+	}
+	return err
+}
+
+// restoreReady возвращает конвейер в ready, если обработку не удалось запустить
+func (s *fileService) restoreReady(ctx context.Context, projectID int32, pipeline, reason string) {
+	actor := projectstate.ActorFrom(ctx, projectstate.ActorUser)
+	if _, err := s.status.Finish(ctx, projectID, pipeline, actor, reason); err != nil {
+		log.Printf("Failed to restore project %d %s pipeline status to ready: %v", projectID, pipeline, err)
+	}
+}
+
+// pipelineStatus возвращает статус конвейера проекта
+// Возвращает sql.ErrNoRows, если проект не найден
+func (s *fileService) pipelineStatus(ctx context.Context, projectID int32, pipeline string) (db.ProjectStatus, error) {
+	if _, err := s.repo.GetProject(ctx, projectID); err != nil {
+		return "", err
+	}
+
+	state, err := s.repo.GetProjectPipeline(ctx, projectID, pipeline)
+	if err != nil {
+		return "", err
+	}
+	return state.Status, nil
+}
+
 // GetChecklist получает результат проверки чеклиста для проекта
 func (s *fileService) GetChecklist(ctx context.Context, projectID int32) (interface{}, error) {
-	// Проверяем статус проекта
-	project, err := s.repo.GetProject(ctx, projectID)
+	// Проверяем статус конвейера, формирующего результат
+	status, err := s.pipelineStatus(ctx, projectID, tasks.TaskKindChecklist)
 	if err != nil {
 		return nil, err
 	}
 
 	// Если проект в процессе генерации чеклиста, возвращаем ошибку
-	if project.Status == db.ProjectStatusProcessingChecklist {
+	if status == db.ProjectStatusProcessingChecklist {
 		return nil, models.ErrChecklistStillGenerating
 	}
 
@@ -256,7 +292,7 @@ func (s *fileService) GetChecklist(ctx context.Context, projectID int32) (interf
 	// Возвращаем результат
 	return map[string]interface{}{
 		"project_id": projectID,
-		"status":     project.Status,
+		"status":     status,
 		"files":      files,
 		"message":    "Checklist files found",
 	}, nil
@@ -264,14 +300,14 @@ func (s *fileService) GetChecklist(ctx context.Context, projectID int32) (interf
 
 // GetRemarksClustered получает кластеризированные замечания для проекта
 func (s *fileService) GetRemarksClustered(ctx context.Context, projectID int32) (interface{}, error) {
-	// Проверяем статус проекта
-	project, err := s.repo.GetProject(ctx, projectID)
+	// Проверяем статус конвейера, формирующего результат
+	status, err := s.pipelineStatus(ctx, projectID, tasks.TaskKindRemarks)
 	if err != nil {
 		return nil, err
 	}
 
 	// Если проект в процессе обработки замечаний, возвращаем ошибку
-	if project.Status == db.ProjectStatusProcessingRemarks {
+	if status == db.ProjectStatusProcessingRemarks {
 		return nil, models.ErrRemarksStillProcessing
 	}
 
@@ -289,22 +325,59 @@ func (s *fileService) GetRemarksClustered(ctx context.Context, projectID int32)
 	// Возвращаем результат
 	return map[string]interface{}{
 		"project_id": projectID,
-		"status":     project.Status,
+		"status":     status,
 		"files":      files,
 		"message":    "Clustered remarks files found",
 	}, nil
 }
 
+// DownloadRemarksClustered открывает последний отчет по кластеризованным замечаниям в формате format
+// Вызывающий обязан закрыть возвращенный поток
+func (s *fileService) DownloadRemarksClustered(ctx context.Context, projectID int32, format string) (*db.ProjectFile, io.ReadCloser, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format != tasks.ReportFormatXLSX && !tasks.IsReportFormat(format) {
+		return nil, nil, models.ErrInvalidReportFormat
+	}
+
+	status, err := s.pipelineStatus(ctx, projectID, tasks.TaskKindRemarks)
+	if err != nil {
+		return nil, nil, err
+	}
+	if status == db.ProjectStatusProcessingRemarks {
+		return nil, nil, models.ErrRemarksStillProcessing
+	}
+
+	files, err := s.repo.GetProjectFilesByType(ctx, projectID, db.FileTypeRemarksClustered)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Файлы отсортированы от новых к старым
+	for i := range files {
+		if files[i].Extension != "."+format {
+			continue
+		}
+
+		content, err := s.storage.DownloadFile(ctx, files[i].FilePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to download %s: %w", files[i].FilePath, err)
+		}
+		return &files[i], content, nil
+	}
+
+	return nil, nil, models.ErrNotFound404
+}
+
 // GetFinalReport получает финальный отчет для проекта
 func (s *fileService) GetFinalReport(ctx context.Context, projectID int32) (interface{}, error) {
-	// Проверяем статус проекта
-	project, err := s.repo.GetProject(ctx, projectID)
+	// Проверяем статус конвейера, формирующего результат
+	status, err := s.pipelineStatus(ctx, projectID, tasks.TaskKindFinalReport)
 	if err != nil {
 		return nil, err
 	}
 
 	// Если проект в процессе генерации финального отчета, возвращаем ошибку
-	if project.Status == db.ProjectStatusGeneratingFinalReport {
+	if status == db.ProjectStatusGeneratingFinalReport {
 		return nil, models.ErrFinalReportStillGenerating
 	}
 
@@ -322,7 +395,7 @@ func (s *fileService) GetFinalReport(ctx context.Context, projectID int32) (inte
 	// Возвращаем результат
 	return map[string]interface{}{
 		"project_id": projectID,
-		"status":     project.Status,
+		"status":     status,
 		"files":      files,
 		"message":    "Final report files found",
 	}, nil
@@ -335,6 +408,8 @@ func (s *fileService) getContentType(ext string) string {
 		return "application/pdf"
 	case ".docx":
 		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case ".pptx":
+		return "application/vnd.openxmlformats-officedocument.presentationml.presentation"
 	case ".xlsx":
 		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
 	case ".txt":
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"evaluation/internal/models"
+	db "evaluation/internal/postgres/sqlc"
+	"evaluation/internal/projectstate"
+	"evaluation/internal/tasks"
+
+	"github.com/google/uuid"
+)
+
+// jobService реализация JobService
+type jobService struct {
+	repo        Repository
+	status      *projectstate.Machine
+	taskManager tasks.TaskManager
+}
+
+// NewJobService создает новый экземпляр JobService
+func NewJobService(repo Repository, taskManager tasks.TaskManager) JobService {
+	return &jobService{
+		repo:        repo,
+		status:      projectstate.New(repo),
+		taskManager: taskManager,
+	}
+}
+
+// ListProjectJobs получает задачи проекта, начиная с последних
+func (s *jobService) ListProjectJobs(ctx context.Context, projectID int32) ([]models.JobResponse, error) {
+	// Проверяем существование проекта, чтобы отличать пустой список от несуществующего проекта
+	if _, err := s.repo.GetProject(ctx, projectID); err != nil {
+		return nil, err
+	}
+
+	jobs, err := s.repo.ListJobsByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.JobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		result = append(result, toJobResponse(job))
+	}
+
+	return result, nil
+}
+
+// GetJob получает задачу вместе с историей ее запусков
+func (s *jobService) GetJob(ctx context.Context, jobID uuid.UUID) (*models.JobResponse, error) {
+	job, err := s.repo.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := s.repo.ListJobRuns(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := toJobResponse(*job)
+	result.Runs = make([]models.JobRunResponse, 0, len(runs))
+	for _, run := range runs {
+		result.Runs = append(result.Runs, models.JobRunResponse{
+			Attempt:    run.Attempt,
+			WorkerID:   run.WorkerID,
+			StartedAt:  run.StartedAt,
+			FinishedAt: run.FinishedAt,
+			DurationMs: run.DurationMs,
+			Success:    run.Success,
+			Error:      nullString(run.Error),
+		})
+	}
+
+	return &result, nil
+}
+
+// CancelJob отменяет задачу проекта и возвращает проект в статус ready
+func (s *jobService) CancelJob(ctx context.Context, projectID int32, jobID uuid.UUID) (*models.JobResponse, error) {
+	job, err := s.repo.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Задача другого проекта считается ненайденной
+	if job.ProjectID != projectID {
+		return nil, sql.ErrNoRows
+	}
+
+	if err := s.taskManager.CancelTask(ctx, jobID.String()); err != nil {
+		if errors.Is(err, tasks.ErrTaskNotCancellable) {
+			return nil, models.ErrJobNotCancellable
+		}
+		return nil, err
+	}
+
+	// Возвращаем конвейер отмененной задачи в ready, если он еще в статусе обработки
+	if _, ok := projectstate.ProcessingStatus(job.Kind); ok {
+		reason := fmt.Sprintf("job %s cancelled by user", jobID)
+		_, err := s.status.Finish(ctx, projectID, job.Kind, projectstate.ActorUser, reason)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if err == nil {
+			log.Printf("Project %d %s pipeline restored to ready after cancelling job %s", projectID, job.Kind, jobID)
+		}
+	}
+
+	return s.GetJob(ctx, jobID)
+}
+
+// ListDeadJobs получает задачи, исчерпавшие попытки повтора
+func (s *jobService) ListDeadJobs(ctx context.Context) ([]models.JobResponse, error) {
+	jobs, err := s.repo.ListDeadJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.JobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		result = append(result, toJobResponse(job))
+	}
+
+	return result, nil
+}
+
+// RequeueJob возвращает задачу из dead-letter в очередь и переводит ее конвейер в статус обработки
+func (s *jobService) RequeueJob(ctx context.Context, jobID uuid.UUID) (*models.JobResponse, error) {
+	job, err := s.repo.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.State != db.JobStateDead {
+		return nil, models.ErrJobNotDead
+	}
+
+	// Атомарно занимаем конвейер, как при обычном запуске обработки
+	_, hasPipeline := projectstate.ProcessingStatus(job.Kind)
+	if hasPipeline {
+		reason := fmt.Sprintf("job %s requeued from dead-letter", jobID)
+		if _, err := s.status.Start(ctx, job.ProjectID, job.Kind, projectstate.ActorAdmin, reason); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, models.ErrProjectAlreadyProcessing
+			}
+			return nil, err
+		}
+	}
+
+	if _, err := s.repo.RequeueDeadJob(ctx, jobID); err != nil {
+		if hasPipeline {
+			reason := fmt.Sprintf("requeue of job %s failed: %v", jobID, err)
+			if _, restoreErr := s.status.Finish(ctx, job.ProjectID, job.Kind, projectstate.ActorAdmin, reason); restoreErr != nil {
+				log.Printf("Failed to restore project %d %s pipeline status to ready: %v", job.ProjectID, job.Kind, restoreErr)
+			}
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrJobNotDead
+		}
+		return nil, err
+	}
+
+	log.Printf("Job %s of project %d requeued from dead-letter", jobID, job.ProjectID)
+	return s.GetJob(ctx, jobID)
+}
+
+// toJobResponse преобразует запись очереди в ответ API
+func toJobResponse(job db.Job) models.JobResponse {
+	return models.JobResponse{
+		ID:          job.ID.String(),
+		ProjectID:   job.ProjectID,
+		Kind:        job.Kind,
+		State:       string(job.State),
+		Attempts:    job.Attempts,
+		Priority:    job.Priority,
+		RunAfter:    job.RunAfter,
+		LockedBy:    nullString(job.LockedBy),
+		LockedUntil: nullTime(job.LockedUntil),
+		LastError:   nullString(job.LastError),
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+		FinishedAt:  nullTime(job.FinishedAt),
+	}
+}
+
+func nullString(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
+
+func nullTime(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Time
+}
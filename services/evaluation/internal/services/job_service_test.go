@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"evaluation/internal/models"
+	db "evaluation/internal/postgres/sqlc"
+	"evaluation/internal/tasks"
+
+	"github.com/google/uuid"
+)
+
+func TestJobService_GetJob(t *testing.T) {
+	repo := NewMockRepository()
+	service := NewJobService(repo, &mockTaskManager{})
+
+	jobID := uuid.New()
+	started := time.Now().Add(-time.Second)
+	repo.jobs[jobID] = &db.Job{
+		ID:         jobID,
+		ProjectID:  1,
+		Kind:       "remarks",
+		State:      db.JobStateFailed,
+		Attempts:   1,
+		LastError:  sql.NullString{String: "boom", Valid: true},
+		FinishedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	repo.jobRuns[jobID] = []db.JobRun{{
+		JobID:      jobID,
+		Attempt:    1,
+		StartedAt:  started,
+		FinishedAt: time.Now(),
+		DurationMs: 1000,
+		Success:    false,
+		Error:      sql.NullString{String: "boom", Valid: true},
+	}}
+
+	job, err := service.GetJob(context.Background(), jobID)
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if job.State != "failed" {
+		t.Errorf("GetJob() state = %v, want failed", job.State)
+	}
+	if job.LastError == nil || *job.LastError != "boom" {
+		t.Errorf("GetJob() last_error = %v, want boom", job.LastError)
+	}
+	if job.LockedBy != nil {
+		t.Errorf("GetJob() locked_by = %v, want nil", *job.LockedBy)
+	}
+	if len(job.Runs) != 1 || job.Runs[0].Success || job.Runs[0].DurationMs != 1000 {
+		t.Errorf("GetJob() runs = %+v", job.Runs)
+	}
+
+	// Несуществующая задача
+	if _, err := service.GetJob(context.Background(), uuid.New()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetJob() error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestJobService_ListProjectJobs(t *testing.T) {
+	repo := NewMockRepository()
+	service := NewJobService(repo, &mockTaskManager{})
+
+	project, _ := repo.CreateProject(context.Background(), "Test Project")
+	jobID := uuid.New()
+	repo.jobs[jobID] = &db.Job{ID: jobID, ProjectID: project.ID, Kind: "checklist", State: db.JobStateQueued}
+
+	jobs, err := service.ListProjectJobs(context.Background(), project.ID)
+	if err != nil {
+		t.Fatalf("ListProjectJobs() error = %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != jobID.String() {
+		t.Errorf("ListProjectJobs() = %+v", jobs)
+	}
+
+	// Несуществующий проект
+	if _, err := service.ListProjectJobs(context.Background(), 999); err == nil {
+		t.Error("ListProjectJobs() expected error for missing project")
+	}
+}
+
+func TestJobService_CancelJob(t *testing.T) {
+	repo := NewMockRepository()
+	taskManager := &mockTaskManager{}
+	service := NewJobService(repo, taskManager)
+
+	project, _ := repo.CreateProject(context.Background(), "Test Project")
+	repo.setPipelineStatus(project.ID, tasks.TaskKindChecklist, db.ProjectStatusProcessingChecklist)
+	jobID := uuid.New()
+	repo.jobs[jobID] = &db.Job{ID: jobID, ProjectID: project.ID, Kind: tasks.TaskKindChecklist, State: db.JobStateRunning}
+
+	// Задача чужого проекта
+	if _, err := service.CancelJob(context.Background(), project.ID+1, jobID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("CancelJob() error = %v, want sql.ErrNoRows", err)
+	}
+
+	if _, err := service.CancelJob(context.Background(), project.ID, jobID); err != nil {
+		t.Fatalf("CancelJob() error = %v", err)
+	}
+	if len(taskManager.cancelled) != 1 || taskManager.cancelled[0] != jobID.String() {
+		t.Errorf("CancelJob() cancelled tasks = %v", taskManager.cancelled)
+	}
+	if project.Status != db.ProjectStatusReady {
+		t.Errorf("project status = %v, want ready", project.Status)
+	}
+
+	// Задача уже завершена
+	taskManager.cancelErr = tasks.ErrTaskNotCancellable
+	if _, err := service.CancelJob(context.Background(), project.ID, jobID); !errors.Is(err, models.ErrJobNotCancellable) {
+		t.Errorf("CancelJob() error = %v, want ErrJobNotCancellable", err)
+	}
+}
+
+func TestJobService_RequeueJob(t *testing.T) {
+	repo := NewMockRepository()
+	service := NewJobService(repo, &mockTaskManager{})
+
+	project, _ := repo.CreateProject(context.Background(), "Test Project")
+	deadID := uuid.New()
+	repo.jobs[deadID] = &db.Job{ID: deadID, ProjectID: project.ID, Kind: tasks.TaskKindRemarks, State: db.JobStateDead, Attempts: 3}
+	failedID := uuid.New()
+	repo.jobs[failedID] = &db.Job{ID: failedID, ProjectID: project.ID, Kind: tasks.TaskKindRemarks, State: db.JobStateFailed}
+
+	dead, err := service.ListDeadJobs(context.Background())
+	if err != nil {
+		t.Fatalf("ListDeadJobs() error = %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != deadID.String() {
+		t.Errorf("ListDeadJobs() = %v, want only job %s", dead, deadID)
+	}
+
+	// Задача не в dead-letter
+	if _, err := service.RequeueJob(context.Background(), failedID); !errors.Is(err, models.ErrJobNotDead) {
+		t.Errorf("RequeueJob() error = %v, want ErrJobNotDead", err)
+	}
+
+	job, err := service.RequeueJob(context.Background(), deadID)
+	if err != nil {
+		t.Fatalf("RequeueJob() error = %v", err)
+	}
+	if job.State != string(db.JobStateQueued) || job.Attempts != 0 {
+		t.Errorf("RequeueJob() state = %s, attempts = %d, want queued with 0 attempts", job.State, job.Attempts)
+	}
+	if project.Status != db.ProjectStatusProcessingRemarks {
+		t.Errorf("project status = %v, want processing_remarks", project.Status)
+	}
+}
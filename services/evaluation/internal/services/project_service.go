@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"evaluation/internal/models"
 	db "evaluation/internal/postgres/sqlc"
 )
 
@@ -57,3 +58,54 @@ func (s *projectService) ListProjects(ctx context.Context) ([]db.Project, error)
 
 	return projects, nil
 }
+
+// GetProjectPipelines получает состояние конвейеров обработки проекта
+func (s *projectService) GetProjectPipelines(ctx context.Context, id int32) ([]models.PipelineResponse, error) {
+	if _, err := s.repo.GetProject(ctx, id); err != nil {
+		return nil, err
+	}
+
+	pipelines, err := s.repo.ListProjectPipelines(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.PipelineResponse, 0, len(pipelines))
+	for _, pipeline := range pipelines {
+		result = append(result, models.PipelineResponse{
+			Pipeline:  pipeline.Pipeline,
+			Status:    string(pipeline.Status),
+			UpdatedAt: pipeline.UpdatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// GetProjectHistory получает историю переходов статуса проекта в хронологическом порядке
+func (s *projectService) GetProjectHistory(ctx context.Context, id int32) ([]models.StatusTransitionResponse, error) {
+	// Проверяем существование проекта, чтобы отличать пустую историю от несуществующего проекта
+	if _, err := s.repo.GetProject(ctx, id); err != nil {
+		return nil, err
+	}
+
+	history, err := s.repo.ListProjectStatusHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.StatusTransitionResponse, 0, len(history))
+	for _, entry := range history {
+		result = append(result, models.StatusTransitionResponse{
+			ID:         entry.ID,
+			Pipeline:   entry.Pipeline,
+			FromStatus: string(entry.FromStatus),
+			ToStatus:   string(entry.ToStatus),
+			Actor:      entry.Actor,
+			Reason:     entry.Reason,
+			CreatedAt:  entry.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"strings"
 	"testing"
@@ -9,18 +10,37 @@ import (
 
 	"evaluation/internal/models"
 	db "evaluation/internal/postgres/sqlc"
+	"evaluation/internal/projectstate"
+
+	"github.com/google/uuid"
 )
 
 // MockRepository - мок репозитория для тестирования
 type MockRepository struct {
-	projects map[int32]*db.Project
-	nextID   int32
+	projects  map[int32]*db.Project
+	pipelines map[int32]map[string]*db.ProjectPipeline
+	jobs      map[uuid.UUID]*db.Job
+	jobRuns   map[uuid.UUID][]db.JobRun
+	schedules map[int32]*db.JobSchedule
+	templates map[int32]*db.ReportTemplate
+	// projectTemplates шаблон отчетов, выбранный для проекта
+	projectTemplates map[int32]int32
+	documentIndex    map[int32]db.DocumentIndex
+	history          []db.ProjectStatusHistory
+	events           [][]byte
+	nextID           int32
 }
 
 func NewMockRepository() *MockRepository {
 	return &MockRepository{
-		projects: make(map[int32]*db.Project),
-		nextID:   1,
+		projects:         make(map[int32]*db.Project),
+		pipelines:        make(map[int32]map[string]*db.ProjectPipeline),
+		jobs:             make(map[uuid.UUID]*db.Job),
+		jobRuns:          make(map[uuid.UUID][]db.JobRun),
+		schedules:        make(map[int32]*db.JobSchedule),
+		templates:        make(map[int32]*db.ReportTemplate),
+		projectTemplates: make(map[int32]int32),
+		nextID:           1,
 	}
 }
 
@@ -31,6 +51,14 @@ func (m *MockRepository) CreateProject(ctx context.Context, name string) (*db.Pr
 		Status: db.ProjectStatusReady,
 	}
 	m.projects[m.nextID] = project
+	m.pipelines[m.nextID] = make(map[string]*db.ProjectPipeline)
+	for _, pipeline := range projectstate.Pipelines {
+		m.pipelines[m.nextID][pipeline] = &db.ProjectPipeline{
+			ProjectID: m.nextID,
+			Pipeline:  pipeline,
+			Status:    db.ProjectStatusReady,
+		}
+	}
 	m.nextID++
 	return project, nil
 }
@@ -71,40 +99,345 @@ func (m *MockRepository) SaveAttach(file *models.Attach) (string, error) {
 	return "mock-filename" + file.FileExt, nil
 }
 
-func (m *MockRepository) CheckAndUpdateProjectStatus(ctx context.Context, projectID int32, newStatus db.ProjectStatus) (*db.Project, error) {
-	project, exists := m.projects[projectID]
+func (m *MockRepository) GetProjectFilesByType(ctx context.Context, projectID int32, fileType db.FileType) ([]db.ProjectFile, error) {
+	// Простая реализация для тестов - возвращаем пустой список
+	return []db.ProjectFile{}, nil
+}
+
+func (m *MockRepository) ReplaceRemarks(ctx context.Context, projectID int32, remarks []db.CreateRemarkParams) error {
+	// Простая реализация для тестов
+	return nil
+}
+
+func (m *MockRepository) GetRemarksByProject(ctx context.Context, projectID int32) ([]db.Remark, error) {
+	return []db.Remark{}, nil
+}
+
+func (m *MockRepository) GetJob(ctx context.Context, jobID uuid.UUID) (*db.Job, error) {
+	job, exists := m.jobs[jobID]
 	if !exists {
-		return nil, errors.New("project not found")
+		return nil, sql.ErrNoRows
 	}
-	project.Status = newStatus
-	return project, nil
+	return job, nil
 }
 
-func (m *MockRepository) GetProjectFilesByType(ctx context.Context, projectID int32, fileType db.FileType) ([]db.ProjectFile, error) {
-	// Простая реализация для тестов - возвращаем пустой список
-	return []db.ProjectFile{}, nil
+func (m *MockRepository) ListJobsByProject(ctx context.Context, projectID int32) ([]db.Job, error) {
+	jobs := []db.Job{}
+	for _, job := range m.jobs {
+		if job.ProjectID == projectID {
+			jobs = append(jobs, *job)
+		}
+	}
+	return jobs, nil
 }
 
-func (m *MockRepository) UpdateProjectStatus(ctx context.Context, projectID int32, newStatus db.ProjectStatus) (*db.Project, error) {
-	project, exists := m.projects[projectID]
+func (m *MockRepository) ListJobRuns(ctx context.Context, jobID uuid.UUID) ([]db.JobRun, error) {
+	return m.jobRuns[jobID], nil
+}
+
+func (m *MockRepository) ListDeadJobs(ctx context.Context) ([]db.Job, error) {
+	jobs := []db.Job{}
+	for _, job := range m.jobs {
+		if job.State == db.JobStateDead {
+			jobs = append(jobs, *job)
+		}
+	}
+	return jobs, nil
+}
+
+func (m *MockRepository) RequeueDeadJob(ctx context.Context, jobID uuid.UUID) (*db.Job, error) {
+	job, exists := m.jobs[jobID]
+	if !exists || job.State != db.JobStateDead {
+		return nil, sql.ErrNoRows
+	}
+	job.State = db.JobStateQueued
+	job.Attempts = 0
+	job.FinishedAt = sql.NullTime{}
+	return job, nil
+}
+
+func (m *MockRepository) PublishProjectEvent(ctx context.Context, payload []byte) error {
+	m.events = append(m.events, payload)
+	return nil
+}
+
+func (m *MockRepository) ListStuckPipelines(ctx context.Context, grace time.Duration) ([]db.ProjectPipeline, error) {
+	stuck := []db.ProjectPipeline{}
+	for projectID, pipelines := range m.pipelines {
+		for _, pipeline := range pipelines {
+			if pipeline.Status == db.ProjectStatusReady || !pipeline.UpdatedAt.Before(time.Now().Add(-grace)) {
+				continue
+			}
+			active := false
+			for _, job := range m.jobs {
+				if job.ProjectID == projectID && job.Kind == pipeline.Pipeline && (job.State == db.JobStateQueued || job.State == db.JobStateRunning) {
+					active = true
+				}
+			}
+			if !active {
+				stuck = append(stuck, *pipeline)
+			}
+		}
+	}
+	return stuck, nil
+}
+
+func (m *MockRepository) GetProjectPipeline(ctx context.Context, projectID int32, pipeline string) (*db.ProjectPipeline, error) {
+	state, exists := m.pipelines[projectID][pipeline]
 	if !exists {
-		return nil, errors.New("project not found")
+		return nil, sql.ErrNoRows
 	}
-	project.Status = newStatus
-	return project, nil
+	return state, nil
 }
 
-func (m *MockRepository) CreateRemark(ctx context.Context, arg db.CreateRemarkParams) (db.Remark, error) {
-	// Простая реализация для тестов
-	return db.Remark{
-		ID:         1,
-		ProjectID:  arg.ProjectID,
-		Direction:  arg.Direction,
-		Section:    arg.Section,
-		Subsection: arg.Subsection,
-		Content:    arg.Content,
+func (m *MockRepository) ListProjectPipelines(ctx context.Context, projectID int32) ([]db.ProjectPipeline, error) {
+	result := []db.ProjectPipeline{}
+	for _, pipeline := range projectstate.Pipelines {
+		if state, exists := m.pipelines[projectID][pipeline]; exists {
+			result = append(result, *state)
+		}
+	}
+	return result, nil
+}
+
+// setPipelineStatus выставляет статус конвейера в обход машины состояний
+func (m *MockRepository) setPipelineStatus(projectID int32, pipeline string, status db.ProjectStatus) {
+	m.pipelines[projectID][pipeline].Status = status
+	m.syncProjectStatus(projectID)
+}
+
+// syncProjectStatus пересчитывает сводный статус проекта, как это делает триггер в БД
+func (m *MockRepository) syncProjectStatus(projectID int32) {
+	project := m.projects[projectID]
+	project.Status = db.ProjectStatusReady
+	for _, pipeline := range projectstate.Pipelines {
+		if state := m.pipelines[projectID][pipeline]; state.Status != db.ProjectStatusReady {
+			project.Status = state.Status
+		}
+	}
+}
+
+func (m *MockRepository) TransitionPipelineStatus(ctx context.Context, projectID int32, pipeline string, from, to db.ProjectStatus, actor, reason string) (*db.ProjectPipeline, error) {
+	state, exists := m.pipelines[projectID][pipeline]
+	if !exists || state.Status != from {
+		return nil, sql.ErrNoRows
+	}
+	state.Status = to
+	state.UpdatedAt = time.Now()
+	m.syncProjectStatus(projectID)
+	m.history = append(m.history, db.ProjectStatusHistory{
+		ID:         int32(len(m.history) + 1),
+		ProjectID:  projectID,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      actor,
+		Reason:     reason,
 		CreatedAt:  time.Now(),
-	}, nil
+		Pipeline:   pipeline,
+	})
+	return state, nil
+}
+
+func (m *MockRepository) ListProjectStatusHistory(ctx context.Context, projectID int32) ([]db.ProjectStatusHistory, error) {
+	var result []db.ProjectStatusHistory
+	for _, entry := range m.history {
+		if entry.ProjectID == projectID {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockRepository) FailProjectJobs(ctx context.Context, projectID int32, errText string) (int64, error) {
+	var count int64
+	for _, job := range m.jobs {
+		if job.ProjectID == projectID && (job.State == db.JobStateQueued || job.State == db.JobStateRunning) {
+			job.State = db.JobStateFailed
+			job.LastError = sql.NullString{String: errText, Valid: true}
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MockRepository) CreateJobSchedule(ctx context.Context, projectID int32, kind, cronExpr string, nextRunAt time.Time) (*db.JobSchedule, error) {
+	schedule := &db.JobSchedule{
+		ID:        int32(len(m.schedules) + 1),
+		ProjectID: projectID,
+		Kind:      kind,
+		CronExpr:  cronExpr,
+		NextRunAt: nextRunAt,
+		CreatedAt: time.Now(),
+	}
+	m.schedules[schedule.ID] = schedule
+	return schedule, nil
+}
+
+func (m *MockRepository) ListJobSchedules(ctx context.Context, projectID int32) ([]db.JobSchedule, error) {
+	schedules := []db.JobSchedule{}
+	for _, schedule := range m.schedules {
+		if schedule.ProjectID == projectID {
+			schedules = append(schedules, *schedule)
+		}
+	}
+	return schedules, nil
+}
+
+func (m *MockRepository) DeleteJobSchedule(ctx context.Context, projectID, scheduleID int32) error {
+	schedule, exists := m.schedules[scheduleID]
+	if !exists || schedule.ProjectID != projectID {
+		return sql.ErrNoRows
+	}
+	delete(m.schedules, scheduleID)
+	return nil
+}
+
+func (m *MockRepository) CreateReportTemplate(ctx context.Context, arg db.CreateReportTemplateParams) (*db.ReportTemplate, error) {
+	for _, template := range m.templates {
+		if template.Name == arg.Name {
+			return nil, models.ErrReportTemplateExists
+		}
+	}
+	template := &db.ReportTemplate{
+		ID:                    int32(len(m.templates) + 1),
+		Name:                  arg.Name,
+		Organization:          arg.Organization,
+		RemarksIntro:          arg.RemarksIntro,
+		RemarksConclusion:     arg.RemarksConclusion,
+		FinalReportIntro:      arg.FinalReportIntro,
+		FinalReportConclusion: arg.FinalReportConclusion,
+		CreatedAt:             time.Now(),
+		UpdatedAt:             time.Now(),
+	}
+	m.templates[template.ID] = template
+	copied := *template
+	return &copied, nil
+}
+
+func (m *MockRepository) GetReportTemplate(ctx context.Context, id int32) (*db.ReportTemplate, error) {
+	template, exists := m.templates[id]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	copied := *template
+	return &copied, nil
+}
+
+func (m *MockRepository) ListReportTemplates(ctx context.Context) ([]db.ReportTemplate, error) {
+	templates := []db.ReportTemplate{}
+	for _, template := range m.templates {
+		templates = append(templates, *template)
+	}
+	return templates, nil
+}
+
+func (m *MockRepository) UpdateReportTemplate(ctx context.Context, arg db.UpdateReportTemplateParams) (*db.ReportTemplate, error) {
+	template, exists := m.templates[arg.ID]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	template.Name = arg.Name
+	template.Organization = arg.Organization
+	template.RemarksIntro = arg.RemarksIntro
+	template.RemarksConclusion = arg.RemarksConclusion
+	template.FinalReportIntro = arg.FinalReportIntro
+	template.FinalReportConclusion = arg.FinalReportConclusion
+	template.UpdatedAt = time.Now()
+	copied := *template
+	return &copied, nil
+}
+
+func (m *MockRepository) DeleteReportTemplate(ctx context.Context, id int32) error {
+	if _, exists := m.templates[id]; !exists {
+		return sql.ErrNoRows
+	}
+	delete(m.templates, id)
+	for projectID, templateID := range m.projectTemplates {
+		if templateID == id {
+			delete(m.projectTemplates, projectID)
+		}
+	}
+	return nil
+}
+
+func (m *MockRepository) SetDefaultReportTemplate(ctx context.Context, id int32, isDefault bool) error {
+	if _, exists := m.templates[id]; !exists {
+		return sql.ErrNoRows
+	}
+	if !isDefault {
+		m.templates[id].IsDefault = false
+		return nil
+	}
+	for _, template := range m.templates {
+		template.IsDefault = template.ID == id
+	}
+	return nil
+}
+
+func (m *MockRepository) SetProjectReportTemplate(ctx context.Context, projectID int32, templateID *int32) error {
+	if templateID == nil {
+		delete(m.projectTemplates, projectID)
+		return nil
+	}
+	m.projectTemplates[projectID] = *templateID
+	return nil
+}
+
+func (m *MockRepository) GetProjectReportTemplateID(ctx context.Context, projectID int32) (*int32, error) {
+	templateID, exists := m.projectTemplates[projectID]
+	if !exists {
+		return nil, nil
+	}
+	return &templateID, nil
+}
+
+func (m *MockRepository) GetEffectiveReportTemplate(ctx context.Context, projectID int32) (*db.ReportTemplate, error) {
+	if templateID, exists := m.projectTemplates[projectID]; exists {
+		return m.GetReportTemplate(ctx, templateID)
+	}
+	for _, template := range m.templates {
+		if template.IsDefault {
+			copied := *template
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockRepository) ListDocumentIndex(ctx context.Context, projectID int32) ([]db.DocumentIndex, error) {
+	var entries []db.DocumentIndex
+	for _, entry := range m.documentIndex {
+		if entry.ProjectID == projectID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *MockRepository) FindDocumentIndexByHash(ctx context.Context, arg db.FindDocumentIndexByHashParams) (*db.DocumentIndex, error) {
+	for _, entry := range m.documentIndex {
+		if entry.ContentHash == arg.ContentHash && entry.ChunkSize == arg.ChunkSize && entry.ChunkOverlap == arg.ChunkOverlap {
+			copied := entry
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockRepository) SaveDocumentIndex(ctx context.Context, arg db.UpsertDocumentIndexParams) error {
+	if m.documentIndex == nil {
+		m.documentIndex = make(map[int32]db.DocumentIndex)
+	}
+	m.documentIndex[arg.ProjectFileID] = db.DocumentIndex{
+		ProjectFileID:  arg.ProjectFileID,
+		ProjectID:      arg.ProjectID,
+		ContentHash:    arg.ContentHash,
+		ChunkSize:      arg.ChunkSize,
+		ChunkOverlap:   arg.ChunkOverlap,
+		EmbeddingModel: arg.EmbeddingModel,
+		Chunks:         arg.Chunks,
+	}
+	return nil
 }
 
 // Тесты для ProjectService
@@ -230,3 +563,75 @@ func TestProjectService_ListProjects(t *testing.T) {
 		}
 	}
 }
+
+func TestProjectService_GetProjectHistory(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := NewProjectService(mockRepo)
+	ctx := context.Background()
+
+	project, err := service.CreateProject(ctx, "Test Project")
+	if err != nil {
+		t.Fatalf("Failed to create test project: %v", err)
+	}
+	other, err := service.CreateProject(ctx, "Other Project")
+	if err != nil {
+		t.Fatalf("Failed to create test project: %v", err)
+	}
+
+	mockRepo.TransitionPipelineStatus(ctx, project.ID, projectstate.PipelineChecklist, db.ProjectStatusReady, db.ProjectStatusProcessingChecklist, "user", "checklist generation requested")
+	mockRepo.TransitionPipelineStatus(ctx, other.ID, projectstate.PipelineRemarks, db.ProjectStatusReady, db.ProjectStatusProcessingRemarks, "user", "remarks upload")
+	mockRepo.TransitionPipelineStatus(ctx, project.ID, projectstate.PipelineChecklist, db.ProjectStatusProcessingChecklist, db.ProjectStatusReady, "worker", "checklist generated")
+
+	history, err := service.GetProjectHistory(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("GetProjectHistory() error = %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 transitions, got %d", len(history))
+	}
+	if history[0].Pipeline != projectstate.PipelineChecklist || history[0].ToStatus != string(db.ProjectStatusProcessingChecklist) || history[0].Actor != "user" {
+		t.Errorf("first transition = %+v", history[0])
+	}
+	if history[1].FromStatus != string(db.ProjectStatusProcessingChecklist) || history[1].ToStatus != string(db.ProjectStatusReady) || history[1].Actor != "worker" {
+		t.Errorf("second transition = %+v", history[1])
+	}
+
+	// Несуществующий проект
+	if _, err := service.GetProjectHistory(ctx, 999); err == nil {
+		t.Errorf("Expected error when getting history of non-existent project")
+	}
+}
+
+func TestProjectService_GetProjectPipelines(t *testing.T) {
+	mockRepo := NewMockRepository()
+	service := NewProjectService(mockRepo)
+	ctx := context.Background()
+
+	project, err := service.CreateProject(ctx, "Test Project")
+	if err != nil {
+		t.Fatalf("Failed to create test project: %v", err)
+	}
+	mockRepo.TransitionPipelineStatus(ctx, project.ID, projectstate.PipelineRemarks, db.ProjectStatusReady, db.ProjectStatusProcessingRemarks, "user", "remarks upload")
+
+	pipelines, err := service.GetProjectPipelines(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("GetProjectPipelines() error = %v", err)
+	}
+	if len(pipelines) != len(projectstate.Pipelines) {
+		t.Fatalf("Expected %d pipelines, got %d", len(projectstate.Pipelines), len(pipelines))
+	}
+	for _, pipeline := range pipelines {
+		want := string(db.ProjectStatusReady)
+		if pipeline.Pipeline == projectstate.PipelineRemarks {
+			want = string(db.ProjectStatusProcessingRemarks)
+		}
+		if pipeline.Status != want {
+			t.Errorf("pipeline %s status = %s, want %s", pipeline.Pipeline, pipeline.Status, want)
+		}
+	}
+
+	// Несуществующий проект
+	if _, err := service.GetProjectPipelines(ctx, 999); err == nil {
+		t.Errorf("Expected error when getting pipelines of non-existent project")
+	}
+}
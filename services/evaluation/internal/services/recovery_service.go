@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	db "evaluation/internal/postgres/sqlc"
+	"evaluation/internal/projectstate"
+	"evaluation/internal/tasks"
+)
+
+// Режимы восстановления зависших проектов
+const (
+	RecoveryModeReset   = "reset"   // вернуть проект в ready с записью причины
+	RecoveryModeRequeue = "requeue" // поставить обработку проекта в очередь заново
+)
+
+// defaultResetReason причина сброса, если администратор ее не указал
+const defaultResetReason = "reset by administrator"
+
+// recoveryService реализация RecoveryService
+type recoveryService struct {
+	repo        Repository
+	status      *projectstate.Machine
+	storage     FileStorage
+	taskManager tasks.TaskManager
+	mode        string
+	grace       time.Duration
+}
+
+// NewRecoveryService создает новый экземпляр RecoveryService
+// Конвейер считается зависшим, если его статус не менялся дольше grace: обработка, запущенная
+// другим экземпляром сервиса, переводит конвейер в статус обработки до постановки задачи в очередь
+func NewRecoveryService(repo Repository, storage FileStorage, taskManager tasks.TaskManager, mode string, grace time.Duration) RecoveryService {
+	if mode != RecoveryModeRequeue {
+		mode = RecoveryModeReset
+	}
+
+	return &recoveryService{
+		repo:        repo,
+		status:      projectstate.New(repo),
+		storage:     storage,
+		taskManager: taskManager,
+		mode:        mode,
+		grace:       grace,
+	}
+}
+
+// RecoverStuckProjects находит конвейеры проектов в статусе обработки без активной задачи
+// и ставит их обработку в очередь заново либо возвращает их в ready
+// Возвращает количество восстановленных конвейеров
+func (s *recoveryService) RecoverStuckProjects(ctx context.Context) (int, error) {
+	pipelines, err := s.repo.ListStuckPipelines(ctx, s.grace)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list stuck pipelines: %w", err)
+	}
+
+	recovered := 0
+	for _, pipeline := range pipelines {
+		if s.mode == RecoveryModeRequeue && s.requeue(ctx, pipeline) {
+			recovered++
+			continue
+		}
+
+		reason := fmt.Sprintf("processing interrupted: %s pipeline was left in status %s without an active job", pipeline.Pipeline, pipeline.Status)
+		_, err := s.status.Finish(ctx, pipeline.ProjectID, pipeline.Pipeline, projectstate.ActorRecovery, reason)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Статус уже изменился - конвейер восстановлен другим экземпляром сервиса
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to reset stuck %s pipeline of project %d: %v", pipeline.Pipeline, pipeline.ProjectID, err)
+			continue
+		}
+
+		recovered++
+	}
+
+	return recovered, nil
+}
+
+// requeue ставит обработку конвейера в очередь заново, статус конвейера не меняется
+// Задача повторяется с параметрами и приоритетом последней задачи конвейера,
+// например отчет в DOCX не превращается при повторе в PDF
+func (s *recoveryService) requeue(ctx context.Context, pipeline db.ProjectPipeline) bool {
+	last, err := s.lastPipelineJob(ctx, pipeline)
+	if err != nil {
+		log.Printf("Failed to find last %s job of stuck project %d: %v", pipeline.Pipeline, pipeline.ProjectID, err)
+		return false
+	}
+
+	var jobID string
+	if last != nil {
+		jobID, err = s.taskManager.ResubmitJob(ctx, last)
+	} else {
+		// Задачи конвейера не сохранилось - обработка запускается с параметрами по умолчанию
+		projectTask := tasks.NewProjectProcessorTask(pipeline.ProjectID, pipeline.Pipeline, tasks.PriorityNormal, s.repo, s.storage)
+		jobID, err = s.taskManager.SubmitTask(ctx, projectTask)
+	}
+	if err != nil {
+		log.Printf("Failed to requeue %s for stuck project %d: %v", pipeline.Pipeline, pipeline.ProjectID, err)
+		return false
+	}
+
+	log.Printf("Project %d %s pipeline in status %s requeued as job %s", pipeline.ProjectID, pipeline.Pipeline, pipeline.Status, jobID)
+	return true
+}
+
+// lastPipelineJob возвращает последнюю поставленную в очередь задачу конвейера, nil если задач нет
+func (s *recoveryService) lastPipelineJob(ctx context.Context, pipeline db.ProjectPipeline) (*db.Job, error) {
+	jobs, err := s.repo.ListJobsByProject(ctx, pipeline.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var last *db.Job
+	for i := range jobs {
+		if jobs[i].Kind == pipeline.Pipeline && (last == nil || jobs[i].CreatedAt.After(last.CreatedAt)) {
+			last = &jobs[i]
+		}
+	}
+	return last, nil
+}
+
+// ResetProject принудительно возвращает все конвейеры проекта в ready, проваливая его активные задачи
+func (s *recoveryService) ResetProject(ctx context.Context, projectID int32, reason string) (*db.Project, error) {
+	pipelines, err := s.repo.ListProjectPipelines(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var processing []string
+	for _, pipeline := range pipelines {
+		if pipeline.Status != db.ProjectStatusReady {
+			processing = append(processing, pipeline.Pipeline)
+		}
+	}
+
+	if len(processing) == 0 {
+		return s.repo.GetProject(ctx, projectID)
+	}
+
+	if reason == "" {
+		reason = defaultResetReason
+	}
+
+	// Сначала проваливаем задачи, чтобы они не были захвачены воркерами после сброса
+	failed, err := s.repo.FailProjectJobs(ctx, projectID, "project reset: "+reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fail active jobs of project %d: %w", projectID, err)
+	}
+
+	for _, pipeline := range processing {
+		// sql.ErrNoRows - конвейер уже вернулся в ready между чтением и сбросом
+		_, err := s.status.Finish(ctx, projectID, pipeline, projectstate.ActorAdmin, reason)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	log.Printf("Project %d reset to ready by administrator (%d active jobs failed): %s", projectID, failed, reason)
+	return s.repo.GetProject(ctx, projectID)
+}
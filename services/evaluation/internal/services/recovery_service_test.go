@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "evaluation/internal/postgres/sqlc"
+	"evaluation/internal/projectstate"
+	"evaluation/internal/tasks"
+
+	"github.com/google/uuid"
+)
+
+// mockTaskManager - мок менеджера задач, запоминающий поставленные и отмененные задачи
+type mockTaskManager struct {
+	submitted   []tasks.Task
+	resubmitted []*db.Job
+	cancelled   []string
+	cancelErr   error
+}
+
+func (m *mockTaskManager) RegisterTaskFactory(kind string, factory tasks.TaskFactory) {}
+
+func (m *mockTaskManager) SetRetryPolicy(kind string, policy tasks.RetryPolicy) {}
+
+func (m *mockTaskManager) SubmitTask(ctx context.Context, task tasks.Task) (string, error) {
+	m.submitted = append(m.submitted, task)
+	return uuid.New().String(), nil
+}
+
+func (m *mockTaskManager) SubmitDelayedTask(ctx context.Context, task tasks.Task, delay time.Duration) (string, error) {
+	return m.SubmitTask(ctx, task)
+}
+
+func (m *mockTaskManager) ResubmitJob(ctx context.Context, job *db.Job) (string, error) {
+	m.resubmitted = append(m.resubmitted, job)
+	return uuid.New().String(), nil
+}
+
+func (m *mockTaskManager) SetScheduleHandler(handler tasks.ScheduleHandler) {}
+
+func (m *mockTaskManager) CancelTask(ctx context.Context, taskID string) error {
+	if m.cancelErr != nil {
+		return m.cancelErr
+	}
+	m.cancelled = append(m.cancelled, taskID)
+	return nil
+}
+
+func (m *mockTaskManager) Start(ctx context.Context) error { return nil }
+func (m *mockTaskManager) Stop(ctx context.Context) error  { return nil }
+func (m *mockTaskManager) GetStats() tasks.TaskStats       { return tasks.TaskStats{} }
+
+// newStuckProjects создает проект с конвейером без активной задачи и проект с задачей в работе
+func newStuckProjects(t *testing.T, repo *MockRepository) (stuck, active *db.Project) {
+	t.Helper()
+
+	stuck, _ = repo.CreateProject(context.Background(), "Stuck")
+	repo.setPipelineStatus(stuck.ID, tasks.TaskKindChecklist, db.ProjectStatusProcessingChecklist)
+
+	active, _ = repo.CreateProject(context.Background(), "Active")
+	repo.setPipelineStatus(active.ID, tasks.TaskKindRemarks, db.ProjectStatusProcessingRemarks)
+	jobID := uuid.New()
+	repo.jobs[jobID] = &db.Job{ID: jobID, ProjectID: active.ID, Kind: tasks.TaskKindRemarks, State: db.JobStateRunning}
+
+	return stuck, active
+}
+
+func TestRecoveryService_RecoverStuckProjects_Reset(t *testing.T) {
+	repo := NewMockRepository()
+	taskManager := &mockTaskManager{}
+	service := NewRecoveryService(repo, nil, taskManager, RecoveryModeReset, time.Minute)
+
+	stuck, active := newStuckProjects(t, repo)
+
+	recovered, err := service.RecoverStuckProjects(context.Background())
+	if err != nil {
+		t.Fatalf("RecoverStuckProjects() error = %v", err)
+	}
+	if recovered != 1 {
+		t.Errorf("RecoverStuckProjects() = %d, want 1", recovered)
+	}
+	if stuck.Status != db.ProjectStatusReady {
+		t.Errorf("stuck project status = %v, want ready", stuck.Status)
+	}
+	if active.Status != db.ProjectStatusProcessingRemarks {
+		t.Errorf("project with active job must not be reset, got %v", active.Status)
+	}
+	if len(repo.history) != 1 || repo.history[0].Reason == "" || repo.history[0].Actor != projectstate.ActorRecovery {
+		t.Errorf("reset not recorded in history: %+v", repo.history)
+	}
+	if len(taskManager.submitted) != 0 {
+		t.Errorf("reset mode must not submit tasks, got %d", len(taskManager.submitted))
+	}
+}
+
+func TestRecoveryService_RecoverStuckProjects_OtherPipelineActive(t *testing.T) {
+	repo := NewMockRepository()
+	service := NewRecoveryService(repo, nil, &mockTaskManager{}, RecoveryModeReset, time.Minute)
+
+	_, active := newStuckProjects(t, repo)
+	// Задача конвейера замечаний не защищает зависший конвейер чек-листа того же проекта
+	repo.setPipelineStatus(active.ID, tasks.TaskKindChecklist, db.ProjectStatusProcessingChecklist)
+
+	recovered, err := service.RecoverStuckProjects(context.Background())
+	if err != nil {
+		t.Fatalf("RecoverStuckProjects() error = %v", err)
+	}
+	if recovered != 2 {
+		t.Errorf("RecoverStuckProjects() = %d, want 2", recovered)
+	}
+	checklist, _ := repo.GetProjectPipeline(context.Background(), active.ID, tasks.TaskKindChecklist)
+	if checklist.Status != db.ProjectStatusReady {
+		t.Errorf("stuck checklist pipeline status = %v, want ready", checklist.Status)
+	}
+	remarks, _ := repo.GetProjectPipeline(context.Background(), active.ID, tasks.TaskKindRemarks)
+	if remarks.Status != db.ProjectStatusProcessingRemarks {
+		t.Errorf("remarks pipeline with active job must not be reset, got %v", remarks.Status)
+	}
+}
+
+func TestRecoveryService_RecoverStuckProjects_Requeue(t *testing.T) {
+	repo := NewMockRepository()
+	taskManager := &mockTaskManager{}
+	service := NewRecoveryService(repo, nil, taskManager, RecoveryModeRequeue, time.Minute)
+
+	stuck, _ := newStuckProjects(t, repo)
+
+	recovered, err := service.RecoverStuckProjects(context.Background())
+	if err != nil {
+		t.Fatalf("RecoverStuckProjects() error = %v", err)
+	}
+	if recovered != 1 {
+		t.Errorf("RecoverStuckProjects() = %d, want 1", recovered)
+	}
+	if len(taskManager.submitted) != 1 {
+		t.Fatalf("expected 1 requeued task, got %d", len(taskManager.submitted))
+	}
+	task := taskManager.submitted[0]
+	if task.GetProjectID() != stuck.ID || task.GetKind() != tasks.TaskKindChecklist {
+		t.Errorf("requeued task = project %d kind %s", task.GetProjectID(), task.GetKind())
+	}
+	// Статус не меняется - его вернет в ready сама задача
+	if stuck.Status != db.ProjectStatusProcessingChecklist {
+		t.Errorf("requeued project status = %v, want processing_checklist", stuck.Status)
+	}
+}
+
+func TestRecoveryService_RecoverStuckProjects_RecentlyStarted(t *testing.T) {
+	repo := NewMockRepository()
+	taskManager := &mockTaskManager{}
+	service := NewRecoveryService(repo, nil, taskManager, RecoveryModeRequeue, time.Minute)
+
+	// Обработка только что запущена другим экземпляром, задача еще не поставлена в очередь
+	project, _ := repo.CreateProject(context.Background(), "Starting")
+	_, err := repo.TransitionPipelineStatus(context.Background(), project.ID, tasks.TaskKindRemarks, db.ProjectStatusReady, db.ProjectStatusProcessingRemarks, projectstate.ActorUser, "")
+	if err != nil {
+		t.Fatalf("TransitionPipelineStatus() error = %v", err)
+	}
+
+	recovered, err := service.RecoverStuckProjects(context.Background())
+	if err != nil {
+		t.Fatalf("RecoverStuckProjects() error = %v", err)
+	}
+	if recovered != 0 {
+		t.Errorf("RecoverStuckProjects() = %d, want 0", recovered)
+	}
+	if len(taskManager.submitted) != 0 || len(taskManager.resubmitted) != 0 {
+		t.Errorf("recently started pipeline must not be requeued")
+	}
+	remarks, _ := repo.GetProjectPipeline(context.Background(), project.ID, tasks.TaskKindRemarks)
+	if remarks.Status != db.ProjectStatusProcessingRemarks {
+		t.Errorf("recently started pipeline status = %v, want processing_remarks", remarks.Status)
+	}
+}
+
+func TestRecoveryService_RecoverStuckProjects_RequeueLastJob(t *testing.T) {
+	repo := NewMockRepository()
+	taskManager := &mockTaskManager{}
+	service := NewRecoveryService(repo, nil, taskManager, RecoveryModeRequeue, time.Minute)
+
+	project, _ := repo.CreateProject(context.Background(), "Stuck")
+	repo.setPipelineStatus(project.ID, tasks.TaskKindFinalReport, db.ProjectStatusGeneratingFinalReport)
+	started := time.Now()
+	older, last, other := uuid.New(), uuid.New(), uuid.New()
+	repo.jobs[older] = &db.Job{ID: older, ProjectID: project.ID, Kind: tasks.TaskKindFinalReport, State: db.JobStateCompleted,
+		Payload: []byte(`{}`), Priority: tasks.PriorityNormal, CreatedAt: started.Add(-time.Hour)}
+	repo.jobs[last] = &db.Job{ID: last, ProjectID: project.ID, Kind: tasks.TaskKindFinalReport, State: db.JobStateFailed,
+		Payload: []byte(`{"format":"docx"}`), Priority: tasks.PriorityLow, CreatedAt: started}
+	repo.jobs[other] = &db.Job{ID: other, ProjectID: project.ID, Kind: tasks.TaskKindRemarks, State: db.JobStateCompleted,
+		Payload: []byte(`{}`), CreatedAt: started.Add(time.Hour)}
+
+	recovered, err := service.RecoverStuckProjects(context.Background())
+	if err != nil {
+		t.Fatalf("RecoverStuckProjects() error = %v", err)
+	}
+	if recovered != 1 {
+		t.Errorf("RecoverStuckProjects() = %d, want 1", recovered)
+	}
+	// Повторяется последняя задача конвейера с ее форматом отчета и приоритетом
+	if len(taskManager.resubmitted) != 1 || taskManager.resubmitted[0].ID != last {
+		t.Fatalf("resubmitted jobs = %+v, want last final report job %s", taskManager.resubmitted, last)
+	}
+	if len(taskManager.submitted) != 0 {
+		t.Errorf("stuck pipeline with a stored job must not be requeued with defaults, got %d tasks", len(taskManager.submitted))
+	}
+}
+
+func TestRecoveryService_ResetProject(t *testing.T) {
+	repo := NewMockRepository()
+	service := NewRecoveryService(repo, nil, &mockTaskManager{}, RecoveryModeReset, time.Minute)
+
+	_, active := newStuckProjects(t, repo)
+
+	project, err := service.ResetProject(context.Background(), active.ID, "")
+	if err != nil {
+		t.Fatalf("ResetProject() error = %v", err)
+	}
+	if project.Status != db.ProjectStatusReady {
+		t.Errorf("ResetProject() status = %v, want ready", project.Status)
+	}
+	for _, job := range repo.jobs {
+		if job.State != db.JobStateFailed {
+			t.Errorf("active job must be failed on reset, got %v", job.State)
+		}
+	}
+	if len(repo.history) != 1 || repo.history[0].Reason != defaultResetReason || repo.history[0].Actor != projectstate.ActorAdmin {
+		t.Errorf("reset history = %+v, want default reason by admin", repo.history)
+	}
+
+	// Несуществующий проект
+	if _, err := service.ResetProject(context.Background(), 999, "manual"); err == nil {
+		t.Error("ResetProject() expected error for missing project")
+	}
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"evaluation/internal/models"
+	db "evaluation/internal/postgres/sqlc"
+	"evaluation/internal/reports"
+)
+
+// reportTemplateService реализация ReportTemplateService
+type reportTemplateService struct {
+	repo Repository
+}
+
+// NewReportTemplateService создает новый экземпляр ReportTemplateService
+func NewReportTemplateService(repo Repository) ReportTemplateService {
+	return &reportTemplateService{repo: repo}
+}
+
+// CreateTemplate создает шаблон отчетов
+func (s *reportTemplateService) CreateTemplate(ctx context.Context, req models.ReportTemplateRequest) (*models.ReportTemplateResponse, error) {
+	if err := validateReportTemplate(&req); err != nil {
+		return nil, err
+	}
+
+	template, err := s.repo.CreateReportTemplate(ctx, db.CreateReportTemplateParams{
+		Name:                  req.Name,
+		Organization:          req.Organization,
+		RemarksIntro:          req.RemarksIntro,
+		RemarksConclusion:     req.RemarksConclusion,
+		FinalReportIntro:      req.FinalReportIntro,
+		FinalReportConclusion: req.FinalReportConclusion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IsDefault {
+		if err := s.repo.SetDefaultReportTemplate(ctx, template.ID, true); err != nil {
+			return nil, err
+		}
+		template.IsDefault = true
+	}
+
+	log.Printf("Report template %d (%q) created", template.ID, template.Name)
+
+	result := toReportTemplateResponse(*template)
+	return &result, nil
+}
+
+// ListTemplates получает все шаблоны отчетов
+func (s *reportTemplateService) ListTemplates(ctx context.Context) ([]models.ReportTemplateResponse, error) {
+	templates, err := s.repo.ListReportTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.ReportTemplateResponse, 0, len(templates))
+	for _, template := range templates {
+		result = append(result, toReportTemplateResponse(template))
+	}
+	return result, nil
+}
+
+// GetTemplate получает шаблон отчетов по ID
+func (s *reportTemplateService) GetTemplate(ctx context.Context, id int32) (*models.ReportTemplateResponse, error) {
+	template, err := s.repo.GetReportTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := toReportTemplateResponse(*template)
+	return &result, nil
+}
+
+// UpdateTemplate заменяет шаблон отчетов целиком
+// Отчеты, сформированные ранее, не перегенерируются
+func (s *reportTemplateService) UpdateTemplate(ctx context.Context, id int32, req models.ReportTemplateRequest) (*models.ReportTemplateResponse, error) {
+	if err := validateReportTemplate(&req); err != nil {
+		return nil, err
+	}
+
+	template, err := s.repo.UpdateReportTemplate(ctx, db.UpdateReportTemplateParams{
+		ID:                    id,
+		Name:                  req.Name,
+		Organization:          req.Organization,
+		RemarksIntro:          req.RemarksIntro,
+		RemarksConclusion:     req.RemarksConclusion,
+		FinalReportIntro:      req.FinalReportIntro,
+		FinalReportConclusion: req.FinalReportConclusion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetDefaultReportTemplate(ctx, id, req.IsDefault); err != nil {
+		return nil, err
+	}
+	template.IsDefault = req.IsDefault
+
+	log.Printf("Report template %d (%q) updated", template.ID, template.Name)
+
+	result := toReportTemplateResponse(*template)
+	return &result, nil
+}
+
+// DeleteTemplate удаляет шаблон отчетов
+// Проекты, которым он был выбран, возвращаются к шаблону по умолчанию
+func (s *reportTemplateService) DeleteTemplate(ctx context.Context, id int32) error {
+	if err := s.repo.DeleteReportTemplate(ctx, id); err != nil {
+		return err
+	}
+
+	log.Printf("Report template %d deleted", id)
+	return nil
+}
+
+// GetProjectTemplate получает шаблон отчетов, выбранный для проекта, и шаблон, который действует для него
+func (s *reportTemplateService) GetProjectTemplate(ctx context.Context, projectID int32) (*models.ProjectReportTemplateResponse, error) {
+	if _, err := s.repo.GetProject(ctx, projectID); err != nil {
+		return nil, err
+	}
+
+	templateID, err := s.repo.GetProjectReportTemplateID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ProjectReportTemplateResponse{
+		ProjectID:  projectID,
+		TemplateID: templateID,
+	}
+
+	template, err := s.repo.GetEffectiveReportTemplate(ctx, projectID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Действует шаблон сервиса
+	case err != nil:
+		return nil, err
+	default:
+		response := toReportTemplateResponse(*template)
+		result.Template = &response
+	}
+
+	return result, nil
+}
+
+// SetProjectTemplate выбирает шаблон отчетов проекта, nil возвращает проект к шаблону по умолчанию
+// Шаблон применяется к отчетам, сформированным после выбора
+func (s *reportTemplateService) SetProjectTemplate(ctx context.Context, projectID int32, templateID *int32) (*models.ProjectReportTemplateResponse, error) {
+	if _, err := s.repo.GetProject(ctx, projectID); err != nil {
+		return nil, err
+	}
+
+	if templateID != nil {
+		if _, err := s.repo.GetReportTemplate(ctx, *templateID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.SetProjectReportTemplate(ctx, projectID, templateID); err != nil {
+		return nil, err
+	}
+
+	if templateID != nil {
+		log.Printf("Report template %d selected for project %d", *templateID, projectID)
+	} else {
+		log.Printf("Report template selection cleared for project %d", projectID)
+	}
+
+	return s.GetProjectTemplate(ctx, projectID)
+}
+
+// validateReportTemplate проверяет название и тексты шаблона
+// Тексты проверяются заполнением на примере данных проекта, чтобы ошибка проявилась при загрузке, а не при генерации отчета
+func validateReportTemplate(req *models.ReportTemplateRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fmt.Errorf("%w: empty name", models.ErrInvalidReportTemplate)
+	}
+
+	template := reports.DefaultTemplate().With(reports.Template{
+		RemarksIntro:          req.RemarksIntro,
+		RemarksConclusion:     req.RemarksConclusion,
+		FinalReportIntro:      req.FinalReportIntro,
+		FinalReportConclusion: req.FinalReportConclusion,
+	})
+	if err := template.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", models.ErrInvalidReportTemplate, err)
+	}
+	return nil
+}
+
+// toReportTemplateResponse преобразует шаблон отчетов в ответ API
+func toReportTemplateResponse(template db.ReportTemplate) models.ReportTemplateResponse {
+	return models.ReportTemplateResponse{
+		ID:                    template.ID,
+		Name:                  template.Name,
+		Organization:          template.Organization,
+		RemarksIntro:          template.RemarksIntro,
+		RemarksConclusion:     template.RemarksConclusion,
+		FinalReportIntro:      template.FinalReportIntro,
+		FinalReportConclusion: template.FinalReportConclusion,
+		IsDefault:             template.IsDefault,
+		CreatedAt:             template.CreatedAt,
+		UpdatedAt:             template.UpdatedAt,
+	}
+}
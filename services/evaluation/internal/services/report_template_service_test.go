@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"evaluation/internal/models"
+)
+
+func TestReportTemplateService_CreateTemplate(t *testing.T) {
+	service := NewReportTemplateService(NewMockRepository())
+
+	template, err := service.CreateTemplate(context.Background(), models.ReportTemplateRequest{
+		Name:         " Недра ",
+		Organization: "ООО «Недра»",
+		RemarksIntro: "Проект {{.ProjectName}}: {{.Remarks}} замечаний",
+		IsDefault:    true,
+	})
+	if err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	if template.Name != "Недра" || !template.IsDefault {
+		t.Errorf("CreateTemplate() = %+v", template)
+	}
+
+	tests := []struct {
+		name    string
+		req     models.ReportTemplateRequest
+		wantErr error
+	}{
+		{"Пустое название", models.ReportTemplateRequest{Name: "  "}, models.ErrInvalidReportTemplate},
+		{"Синтаксическая ошибка", models.ReportTemplateRequest{Name: "Ошибка", RemarksIntro: "{{.ProjectName"}, models.ErrInvalidReportTemplate},
+		{"Неизвестное поле", models.ReportTemplateRequest{Name: "Поле", FinalReportConclusion: "{{.Customer}}"}, models.ErrInvalidReportTemplate},
+		{"Название занято", models.ReportTemplateRequest{Name: "Недра"}, models.ErrReportTemplateExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateTemplate(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateTemplate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReportTemplateService_UpdateTemplate_MovesDefault(t *testing.T) {
+	repo := NewMockRepository()
+	service := NewReportTemplateService(repo)
+
+	first, _ := service.CreateTemplate(context.Background(), models.ReportTemplateRequest{Name: "Первый", IsDefault: true})
+	second, _ := service.CreateTemplate(context.Background(), models.ReportTemplateRequest{Name: "Второй"})
+
+	updated, err := service.UpdateTemplate(context.Background(), second.ID, models.ReportTemplateRequest{Name: "Второй", IsDefault: true})
+	if err != nil {
+		t.Fatalf("UpdateTemplate() error = %v", err)
+	}
+	if !updated.IsDefault {
+		t.Errorf("UpdateTemplate() is_default = false, want true")
+	}
+
+	// Шаблон по умолчанию может быть только один
+	previous, _ := service.GetTemplate(context.Background(), first.ID)
+	if previous.IsDefault {
+		t.Errorf("previous default template is still default")
+	}
+
+	if _, err := service.UpdateTemplate(context.Background(), 99, models.ReportTemplateRequest{Name: "Нет"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UpdateTemplate() error = %v, want %v", err, sql.ErrNoRows)
+	}
+}
+
+func TestReportTemplateService_ProjectTemplate(t *testing.T) {
+	repo := NewMockRepository()
+	service := NewReportTemplateService(repo)
+	project, _ := repo.CreateProject(context.Background(), "Test Project")
+
+	// Без шаблонов отчеты формируются по шаблону сервиса
+	selection, err := service.GetProjectTemplate(context.Background(), project.ID)
+	if err != nil {
+		t.Fatalf("GetProjectTemplate() error = %v", err)
+	}
+	if selection.TemplateID != nil || selection.Template != nil {
+		t.Errorf("GetProjectTemplate() = %+v, want service template", selection)
+	}
+
+	organization, _ := service.CreateTemplate(context.Background(), models.ReportTemplateRequest{Name: "Организация", IsDefault: true})
+	custom, _ := service.CreateTemplate(context.Background(), models.ReportTemplateRequest{Name: "Проект"})
+
+	// Без выбора действует шаблон по умолчанию
+	selection, _ = service.GetProjectTemplate(context.Background(), project.ID)
+	if selection.TemplateID != nil || selection.Template == nil || selection.Template.ID != organization.ID {
+		t.Errorf("GetProjectTemplate() = %+v, want default template %d", selection, organization.ID)
+	}
+
+	selection, err = service.SetProjectTemplate(context.Background(), project.ID, &custom.ID)
+	if err != nil {
+		t.Fatalf("SetProjectTemplate() error = %v", err)
+	}
+	if selection.TemplateID == nil || *selection.TemplateID != custom.ID || selection.Template.ID != custom.ID {
+		t.Errorf("SetProjectTemplate() = %+v, want template %d", selection, custom.ID)
+	}
+
+	missing := int32(99)
+	if _, err := service.SetProjectTemplate(context.Background(), project.ID, &missing); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("SetProjectTemplate() error = %v, want %v", err, sql.ErrNoRows)
+	}
+
+	// Удаление шаблона возвращает проект к шаблону по умолчанию
+	if err := service.DeleteTemplate(context.Background(), custom.ID); err != nil {
+		t.Fatalf("DeleteTemplate() error = %v", err)
+	}
+	selection, _ = service.GetProjectTemplate(context.Background(), project.ID)
+	if selection.TemplateID != nil || selection.Template == nil || selection.Template.ID != organization.ID {
+		t.Errorf("GetProjectTemplate() after delete = %+v, want default template %d", selection, organization.ID)
+	}
+}
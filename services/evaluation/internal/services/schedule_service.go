@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"evaluation/internal/models"
+	db "evaluation/internal/postgres/sqlc"
+	"evaluation/internal/projectstate"
+	"evaluation/internal/tasks"
+)
+
+// scheduleService реализация ScheduleService
+type scheduleService struct {
+	repo        Repository
+	fileService FileService
+}
+
+// NewScheduleService создает новый экземпляр ScheduleService
+// Задачи по расписанию запускаются через fileService, как и по запросу пользователя
+func NewScheduleService(repo Repository, fileService FileService) ScheduleService {
+	return &scheduleService{
+		repo:        repo,
+		fileService: fileService,
+	}
+}
+
+// CreateSchedule создает расписание периодического запуска задачи проекта
+// Обработка замечаний по расписанию не запускается: ей нужен загруженный пользователем файл
+func (s *scheduleService) CreateSchedule(ctx context.Context, projectID int32, kind, cronExpr string) (*models.ScheduleResponse, error) {
+	if kind != tasks.TaskKindChecklist && kind != tasks.TaskKindFinalReport {
+		return nil, fmt.Errorf("%w: job kind %q cannot be scheduled", models.ErrInvalidSchedule, kind)
+	}
+
+	cron, err := tasks.ParseCron(cronExpr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidSchedule, err)
+	}
+	next := cron.Next(time.Now())
+	if next.IsZero() {
+		return nil, fmt.Errorf("%w: cron expression %q never fires", models.ErrInvalidSchedule, cronExpr)
+	}
+
+	if _, err := s.repo.GetProject(ctx, projectID); err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.repo.CreateJobSchedule(ctx, projectID, kind, cronExpr, next)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Schedule %d (%s, %q) created for project %d, first run at %s",
+		schedule.ID, kind, cronExpr, projectID, next.Format(time.RFC3339))
+
+	result := toScheduleResponse(*schedule)
+	return &result, nil
+}
+
+// ListSchedules получает расписания проекта
+func (s *scheduleService) ListSchedules(ctx context.Context, projectID int32) ([]models.ScheduleResponse, error) {
+	// Проверяем существование проекта, чтобы отличать пустой список от несуществующего проекта
+	if _, err := s.repo.GetProject(ctx, projectID); err != nil {
+		return nil, err
+	}
+
+	schedules, err := s.repo.ListJobSchedules(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.ScheduleResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		result = append(result, toScheduleResponse(schedule))
+	}
+
+	return result, nil
+}
+
+// DeleteSchedule удаляет расписание проекта
+func (s *scheduleService) DeleteSchedule(ctx context.Context, projectID, scheduleID int32) error {
+	if err := s.repo.DeleteJobSchedule(ctx, projectID, scheduleID); err != nil {
+		return err
+	}
+
+	log.Printf("Schedule %d of project %d deleted", scheduleID, projectID)
+	return nil
+}
+
+// RunSchedule запускает задачу по наступившему расписанию
+// Если проект в это время уже обрабатывается, запуск пропускается до следующего срабатывания
+func (s *scheduleService) RunSchedule(ctx context.Context, schedule *db.JobSchedule) error {
+	// Переходы статуса записываются в историю от имени планировщика
+	ctx = projectstate.WithActor(ctx, projectstate.ActorScheduler)
+
+	var err error
+	switch schedule.Kind {
+	case tasks.TaskKindChecklist:
+		err = s.fileService.GenerateChecklist(ctx, schedule.ProjectID)
+	case tasks.TaskKindFinalReport:
+		err = s.fileService.GenerateFinalReport(ctx, schedule.ProjectID, tasks.ReportFormatPDF)
+	default:
+		return fmt.Errorf("job kind %q cannot be scheduled", schedule.Kind)
+	}
+
+	if errors.Is(err, models.ErrProjectAlreadyProcessing) {
+		log.Printf("Skipping schedule %d: project %d is already being processed", schedule.ID, schedule.ProjectID)
+		return nil
+	}
+	return err
+}
+
+// toScheduleResponse преобразует расписание в ответ API
+func toScheduleResponse(schedule db.JobSchedule) models.ScheduleResponse {
+	return models.ScheduleResponse{
+		ID:        schedule.ID,
+		ProjectID: schedule.ProjectID,
+		Kind:      schedule.Kind,
+		Cron:      schedule.CronExpr,
+		NextRunAt: schedule.NextRunAt,
+		LastRunAt: nullTime(schedule.LastRunAt),
+		CreatedAt: schedule.CreatedAt,
+	}
+}
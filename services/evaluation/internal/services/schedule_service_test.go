@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"evaluation/internal/models"
+	db "evaluation/internal/postgres/sqlc"
+	"evaluation/internal/tasks"
+)
+
+// mockFileService - мок файлового сервиса, запоминающий запущенную обработку
+type mockFileService struct {
+	checklists   []int32
+	finalReports []int32
+	err          error
+}
+
+func (m *mockFileService) UploadRemarks(ctx context.Context, projectID int32, file io.Reader, filename, fileType string, fileSize int64, format string) (*db.ProjectFile, error) {
+	return nil, nil
+}
+
+func (m *mockFileService) UploadDocumentation(ctx context.Context, projectID int32, file io.Reader, filename string, fileSize int64) (*db.ProjectFile, error) {
+	return nil, nil
+}
+
+func (m *mockFileService) DownloadRemarksClustered(ctx context.Context, projectID int32, format string) (*db.ProjectFile, io.ReadCloser, error) {
+	return nil, nil, nil
+}
+
+func (m *mockFileService) GenerateChecklist(ctx context.Context, projectID int32) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.checklists = append(m.checklists, projectID)
+	return nil
+}
+
+func (m *mockFileService) GenerateFinalReport(ctx context.Context, projectID int32, format string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.finalReports = append(m.finalReports, projectID)
+	return nil
+}
+
+func (m *mockFileService) GetChecklist(ctx context.Context, projectID int32) (interface{}, error) {
+	return nil, nil
+}
+
+func (m *mockFileService) GetRemarksClustered(ctx context.Context, projectID int32) (interface{}, error) {
+	return nil, nil
+}
+
+func (m *mockFileService) GetFinalReport(ctx context.Context, projectID int32) (interface{}, error) {
+	return nil, nil
+}
+
+func TestScheduleService_CreateSchedule(t *testing.T) {
+	repo := NewMockRepository()
+	service := NewScheduleService(repo, &mockFileService{})
+	project, _ := repo.CreateProject(context.Background(), "Test Project")
+
+	schedule, err := service.CreateSchedule(context.Background(), project.ID, tasks.TaskKindChecklist, "0 3 * * *")
+	if err != nil {
+		t.Fatalf("CreateSchedule() error = %v", err)
+	}
+	if schedule.Cron != "0 3 * * *" || schedule.Kind != tasks.TaskKindChecklist {
+		t.Errorf("CreateSchedule() = %+v", schedule)
+	}
+	if !schedule.NextRunAt.After(time.Now()) || schedule.NextRunAt.Hour() != 3 || schedule.NextRunAt.Minute() != 0 {
+		t.Errorf("CreateSchedule() next_run_at = %v, want next 03:00 UTC", schedule.NextRunAt)
+	}
+
+	tests := []struct {
+		name string
+		kind string
+		cron string
+	}{
+		{"Неподдерживаемый тип задачи", tasks.TaskKindRemarks, "0 3 * * *"},
+		{"Некорректное cron-выражение", tasks.TaskKindChecklist, "0 25 * * *"},
+		{"Выражение никогда не срабатывает", tasks.TaskKindFinalReport, "0 0 31 2 *"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateSchedule(context.Background(), project.ID, tt.kind, tt.cron)
+			if !errors.Is(err, models.ErrInvalidSchedule) {
+				t.Errorf("CreateSchedule() error = %v, want %v", err, models.ErrInvalidSchedule)
+			}
+		})
+	}
+
+	schedules, err := service.ListSchedules(context.Background(), project.ID)
+	if err != nil {
+		t.Fatalf("ListSchedules() error = %v", err)
+	}
+	if len(schedules) != 1 {
+		t.Errorf("ListSchedules() returned %d schedules, want 1", len(schedules))
+	}
+}
+
+func TestScheduleService_DeleteSchedule(t *testing.T) {
+	repo := NewMockRepository()
+	service := NewScheduleService(repo, &mockFileService{})
+	project, _ := repo.CreateProject(context.Background(), "Test Project")
+	other, _ := repo.CreateProject(context.Background(), "Other Project")
+
+	schedule, err := service.CreateSchedule(context.Background(), project.ID, tasks.TaskKindChecklist, "@daily")
+	if err != nil {
+		t.Fatalf("CreateSchedule() error = %v", err)
+	}
+
+	// Расписание другого проекта считается ненайденным
+	if err := service.DeleteSchedule(context.Background(), other.ID, schedule.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("DeleteSchedule() error = %v, want %v", err, sql.ErrNoRows)
+	}
+	if err := service.DeleteSchedule(context.Background(), project.ID, schedule.ID); err != nil {
+		t.Errorf("DeleteSchedule() error = %v", err)
+	}
+	if len(repo.schedules) != 0 {
+		t.Errorf("DeleteSchedule() left %d schedules", len(repo.schedules))
+	}
+}
+
+func TestScheduleService_RunSchedule(t *testing.T) {
+	repo := NewMockRepository()
+	fileService := &mockFileService{}
+	service := NewScheduleService(repo, fileService)
+
+	checklist := &db.JobSchedule{ID: 1, ProjectID: 5, Kind: tasks.TaskKindChecklist}
+	if err := service.RunSchedule(context.Background(), checklist); err != nil {
+		t.Fatalf("RunSchedule() error = %v", err)
+	}
+	report := &db.JobSchedule{ID: 2, ProjectID: 6, Kind: tasks.TaskKindFinalReport}
+	if err := service.RunSchedule(context.Background(), report); err != nil {
+		t.Fatalf("RunSchedule() error = %v", err)
+	}
+	if len(fileService.checklists) != 1 || fileService.checklists[0] != 5 {
+		t.Errorf("RunSchedule() checklists = %v, want [5]", fileService.checklists)
+	}
+	if len(fileService.finalReports) != 1 || fileService.finalReports[0] != 6 {
+		t.Errorf("RunSchedule() final reports = %v, want [6]", fileService.finalReports)
+	}
+
+	// Занятый проект пропускает запуск без ошибки
+	fileService.err = models.ErrProjectAlreadyProcessing
+	if err := service.RunSchedule(context.Background(), checklist); err != nil {
+		t.Errorf("RunSchedule() error = %v, want nil for busy project", err)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"evaluation/internal/models"
 	db "evaluation/internal/postgres/sqlc"
 	"io"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // Repository интерфейс для репозитория
@@ -13,10 +16,36 @@ type Repository interface {
 	GetProject(ctx context.Context, id int32) (*db.Project, error)
 	ListProjects(ctx context.Context) ([]db.Project, error)
 	CreateProjectFile(ctx context.Context, projectID int32, filename, originalName, filePath string, fileSize int64, extension string, fileType db.FileType) (*db.ProjectFile, error)
-	CheckAndUpdateProjectStatus(ctx context.Context, projectID int32, newStatus db.ProjectStatus) (*db.Project, error)
-	UpdateProjectStatus(ctx context.Context, projectID int32, newStatus db.ProjectStatus) (*db.Project, error)
+	TransitionPipelineStatus(ctx context.Context, projectID int32, pipeline string, from, to db.ProjectStatus, actor, reason string) (*db.ProjectPipeline, error)
+	GetProjectPipeline(ctx context.Context, projectID int32, pipeline string) (*db.ProjectPipeline, error)
+	ListProjectPipelines(ctx context.Context, projectID int32) ([]db.ProjectPipeline, error)
+	ListProjectStatusHistory(ctx context.Context, projectID int32) ([]db.ProjectStatusHistory, error)
 	GetProjectFilesByType(ctx context.Context, projectID int32, fileType db.FileType) ([]db.ProjectFile, error)
-	CreateRemark(ctx context.Context, arg db.CreateRemarkParams) (db.Remark, error)
+	ReplaceRemarks(ctx context.Context, projectID int32, remarks []db.CreateRemarkParams) error
+	GetRemarksByProject(ctx context.Context, projectID int32) ([]db.Remark, error)
+	GetJob(ctx context.Context, jobID uuid.UUID) (*db.Job, error)
+	ListJobsByProject(ctx context.Context, projectID int32) ([]db.Job, error)
+	ListJobRuns(ctx context.Context, jobID uuid.UUID) ([]db.JobRun, error)
+	ListDeadJobs(ctx context.Context) ([]db.Job, error)
+	RequeueDeadJob(ctx context.Context, jobID uuid.UUID) (*db.Job, error)
+	ListStuckPipelines(ctx context.Context, grace time.Duration) ([]db.ProjectPipeline, error)
+	FailProjectJobs(ctx context.Context, projectID int32, errText string) (int64, error)
+	PublishProjectEvent(ctx context.Context, payload []byte) error
+	CreateJobSchedule(ctx context.Context, projectID int32, kind, cronExpr string, nextRunAt time.Time) (*db.JobSchedule, error)
+	ListJobSchedules(ctx context.Context, projectID int32) ([]db.JobSchedule, error)
+	DeleteJobSchedule(ctx context.Context, projectID, scheduleID int32) error
+	CreateReportTemplate(ctx context.Context, arg db.CreateReportTemplateParams) (*db.ReportTemplate, error)
+	GetReportTemplate(ctx context.Context, id int32) (*db.ReportTemplate, error)
+	ListReportTemplates(ctx context.Context) ([]db.ReportTemplate, error)
+	UpdateReportTemplate(ctx context.Context, arg db.UpdateReportTemplateParams) (*db.ReportTemplate, error)
+	DeleteReportTemplate(ctx context.Context, id int32) error
+	SetDefaultReportTemplate(ctx context.Context, id int32, isDefault bool) error
+	SetProjectReportTemplate(ctx context.Context, projectID int32, templateID *int32) error
+	GetProjectReportTemplateID(ctx context.Context, projectID int32) (*int32, error)
+	GetEffectiveReportTemplate(ctx context.Context, projectID int32) (*db.ReportTemplate, error)
+	ListDocumentIndex(ctx context.Context, projectID int32) ([]db.DocumentIndex, error)
+	FindDocumentIndexByHash(ctx context.Context, arg db.FindDocumentIndexByHashParams) (*db.DocumentIndex, error)
+	SaveDocumentIndex(ctx context.Context, arg db.UpsertDocumentIndexParams) error
 	SaveAttach(file *models.Attach) (string, error)
 }
 
@@ -33,19 +62,63 @@ type ProjectService interface {
 	CreateProject(ctx context.Context, name string) (*db.Project, error)
 	GetProject(ctx context.Context, id int32) (*db.Project, error)
 	ListProjects(ctx context.Context) ([]db.Project, error)
+	GetProjectPipelines(ctx context.Context, id int32) ([]models.PipelineResponse, error)
+	GetProjectHistory(ctx context.Context, id int32) ([]models.StatusTransitionResponse, error)
 }
 
 // FileService интерфейс для бизнес-логики файлов
 type FileService interface {
-	UploadRemarks(ctx context.Context, projectID int32, file io.Reader, filename, fileType string, fileSize int64) (*db.ProjectFile, error)
+	UploadRemarks(ctx context.Context, projectID int32, file io.Reader, filename, fileType string, fileSize int64, format string) (*db.ProjectFile, error)
 	UploadDocumentation(ctx context.Context, projectID int32, file io.Reader, filename string, fileSize int64) (*db.ProjectFile, error)
 	GenerateChecklist(ctx context.Context, projectID int32) error
-	GenerateFinalReport(ctx context.Context, projectID int32) error
+	GenerateFinalReport(ctx context.Context, projectID int32, format string) error
 	GetChecklist(ctx context.Context, projectID int32) (interface{}, error)
 	GetRemarksClustered(ctx context.Context, projectID int32) (interface{}, error)
+	DownloadRemarksClustered(ctx context.Context, projectID int32, format string) (*db.ProjectFile, io.ReadCloser, error)
 	GetFinalReport(ctx context.Context, projectID int32) (interface{}, error)
 }
 
+// JobService интерфейс для просмотра состояния фоновых задач
+type JobService interface {
+	ListProjectJobs(ctx context.Context, projectID int32) ([]models.JobResponse, error)
+	GetJob(ctx context.Context, jobID uuid.UUID) (*models.JobResponse, error)
+	CancelJob(ctx context.Context, projectID int32, jobID uuid.UUID) (*models.JobResponse, error)
+	ListDeadJobs(ctx context.Context) ([]models.JobResponse, error)
+	RequeueJob(ctx context.Context, jobID uuid.UUID) (*models.JobResponse, error)
+}
+
+// ScheduleService интерфейс для управления расписаниями задач проекта
+type ScheduleService interface {
+	CreateSchedule(ctx context.Context, projectID int32, kind, cronExpr string) (*models.ScheduleResponse, error)
+	ListSchedules(ctx context.Context, projectID int32) ([]models.ScheduleResponse, error)
+	DeleteSchedule(ctx context.Context, projectID, scheduleID int32) error
+	RunSchedule(ctx context.Context, schedule *db.JobSchedule) error
+}
+
+// ReportTemplateService интерфейс для управления шаблонами отчетов и их выбором в проектах
+type ReportTemplateService interface {
+	CreateTemplate(ctx context.Context, req models.ReportTemplateRequest) (*models.ReportTemplateResponse, error)
+	ListTemplates(ctx context.Context) ([]models.ReportTemplateResponse, error)
+	GetTemplate(ctx context.Context, id int32) (*models.ReportTemplateResponse, error)
+	UpdateTemplate(ctx context.Context, id int32, req models.ReportTemplateRequest) (*models.ReportTemplateResponse, error)
+	DeleteTemplate(ctx context.Context, id int32) error
+	GetProjectTemplate(ctx context.Context, projectID int32) (*models.ProjectReportTemplateResponse, error)
+	SetProjectTemplate(ctx context.Context, projectID int32, templateID *int32) (*models.ProjectReportTemplateResponse, error)
+}
+
+// RecoveryService интерфейс для восстановления зависших проектов
+type RecoveryService interface {
+	RecoverStuckProjects(ctx context.Context) (int, error)
+	ResetProject(ctx context.Context, projectID int32, reason string) (*db.Project, error)
+}
+
+// EventService интерфейс для рассылки событий проектов (смена статуса, прогресс задач)
+type EventService interface {
+	Start(ctx context.Context)
+	Subscribe(projectID int32) (<-chan models.ProjectEvent, func())
+	ReportProgress(ctx context.Context, projectID int32, message string, current, total int)
+}
+
 // HealthService интерфейс для проверки состояния сервиса
 type HealthService interface {
 	CheckHealth(ctx context.Context) (*HealthResponse, error)
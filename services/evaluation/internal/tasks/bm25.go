@@ -0,0 +1,201 @@
+package tasks
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Параметры BM25: насыщение частоты термина и нормализация по длине чанка
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// bm25Document частоты терминов одного чанка
+type bm25Document struct {
+	termFreq map[string]int
+	length   int
+}
+
+// bm25Index индекс BM25 по чанкам документации
+type bm25Index struct {
+	documents []bm25Document
+	docFreq   map[string]int
+	avgLength float64
+}
+
+// bm25Result номер чанка в индексе и его оценка по запросу
+type bm25Result struct {
+	Index int
+	Score float64
+}
+
+// newBM25Index строит индекс по текстам чанков, номера результатов совпадают с позициями текстов
+func newBM25Index(texts []string) *bm25Index {
+	index := &bm25Index{
+		documents: make([]bm25Document, len(texts)),
+		docFreq:   make(map[string]int),
+	}
+
+	totalLength := 0
+	for i, text := range texts {
+		terms := tokenize(text)
+		document := bm25Document{termFreq: make(map[string]int), length: len(terms)}
+		for _, term := range terms {
+			document.termFreq[term]++
+		}
+		for term := range document.termFreq {
+			index.docFreq[term]++
+		}
+		index.documents[i] = document
+		totalLength += len(terms)
+	}
+	if len(texts) > 0 {
+		index.avgLength = float64(totalLength) / float64(len(texts))
+	}
+	return index
+}
+
+// Search возвращает до topK чанков с ненулевой оценкой по убыванию оценки
+// При равной оценке раньше идет чанк, добавленный в индекс первым
+func (idx *bm25Index) Search(query string, topK int) []bm25Result {
+	terms := uniqueTerms(tokenize(query))
+	if len(terms) == 0 || len(idx.documents) == 0 {
+		return nil
+	}
+
+	n := float64(len(idx.documents))
+	var results []bm25Result
+	for i, document := range idx.documents {
+		score := 0.0
+		for _, term := range terms {
+			tf := float64(document.termFreq[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(idx.docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - bm25B + bm25B*float64(document.length)/idx.avgLength
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+		if score > 0 {
+			results = append(results, bm25Result{Index: i, Score: score})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if topK > 0 && len(results) > topK {
+		results = results[:topK]
+	}
+	return results
+}
+
+// uniqueTerms убирает повторы терминов запроса, сохраняя порядок
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	result := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			result = append(result, term)
+		}
+	}
+	return result
+}
+
+// tokenize разбивает текст на термины: слова и числа в нижнем регистре без стоп-слов, русские слова - в виде основы
+// Числа вида "0.3" и "0,3" остаются одним термином
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != ','
+	})
+
+	var terms []string
+	for _, word := range words {
+		if number := strings.Trim(word, ".,"); isNumber(number) {
+			terms = append(terms, strings.ReplaceAll(number, ",", "."))
+			continue
+		}
+		// Вне чисел точки и запятые разделяют слова
+		for _, part := range strings.FieldsFunc(word, func(r rune) bool { return r == '.' || r == ',' }) {
+			part = strings.ReplaceAll(part, "ё", "е")
+			if utf8.RuneCountInString(part) < 2 && !isNumber(part) || stopWords[part] {
+				continue
+			}
+			terms = append(terms, stemRussian(part))
+		}
+	}
+	return terms
+}
+
+// isNumber сообщает, состоит ли слово из цифр с десятичными разделителями
+func isNumber(word string) bool {
+	if word == "" {
+		return false
+	}
+	for _, r := range word {
+		if !unicode.IsDigit(r) && r != '.' && r != ',' {
+			return false
+		}
+	}
+	return true
+}
+
+// russianEndings падежные и личные окончания, отбрасываемые при выделении основы, от длинных к коротким
+var russianEndings = []string{
+	"иями",
+	"ями", "ами", "иям", "иях", "ием", "ией", "ого", "его", "ому", "ему", "ыми", "ими",
+	"ать", "ять", "ить", "еть", "ует", "уют", "ают", "яют",
+	"ая", "яя", "ое", "ее", "ые", "ие", "ой", "ей", "ий", "ый", "ым", "им", "ых", "их", "ую", "юю",
+	"ом", "ем", "ам", "ям", "ах", "ях", "ов", "ев", "ию", "ия", "ии", "ью", "ья", "ют", "ет", "ит", "ат", "ят",
+	"а", "я", "о", "е", "ы", "и", "у", "ю", "ь", "й",
+}
+
+// russianStemMinLength основа не короче этого числа символов
+const russianStemMinLength = 3
+
+// stemRussian упрощенно выделяет основу русского слова, отбрасывая одно окончание
+// Разные формы слова ("скважина", "скважины", "скважинами") получают общую основу
+func stemRussian(word string) string {
+	if !isCyrillic(word) {
+		return word
+	}
+	length := utf8.RuneCountInString(word)
+	for _, ending := range russianEndings {
+		if strings.HasSuffix(word, ending) && length-utf8.RuneCountInString(ending) >= russianStemMinLength {
+			return strings.TrimSuffix(word, ending)
+		}
+	}
+	return word
+}
+
+// isCyrillic сообщает, состоит ли слово из кириллических букв
+func isCyrillic(word string) bool {
+	for _, r := range word {
+		if !unicode.Is(unicode.Cyrillic, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// stopWords служебные русские и английские слова, не влияющие на релевантность
+var stopWords = func() map[string]bool {
+	words := strings.Fields(`
+		а без более бы был была были было быть в вам вас весь во вот все всего всех вы где да даже для до его ее
+		ей ему если есть еще же за здесь и из или им их к как какая какие какой когда кто ли либо между меня мне
+		может можно мы на над нас не него нее нет ни них но ну о об однако он она они оно от по под при про с со
+		так также такой там те тем то того тоже той только том тот у уже хотя чем что чтобы эта эти это этого
+		этой этом этот эту я
+		a an and are as at be by for from in is it of on or that the this to with`)
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}()
@@ -0,0 +1,57 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	// Стоп-слова отбрасываются, формы слова сводятся к общей основе, числа не разрываются
+	assert.Equal(t, []string{"налич", "техническ", "задан"}, tokenize("Наличие и технического задания"))
+	assert.Equal(t, tokenize("скважина"), tokenize("скважинами"))
+	assert.Equal(t, tokenize("задание"), tokenize("заданием"))
+	assert.Equal(t, tokenize("технического"), tokenize("технических"))
+	assert.Equal(t, tokenize("пористость"), tokenize("пористости"))
+	assert.Equal(t, tokenize("ёмкость"), tokenize("емкость"))
+	assert.Equal(t, []string{"пористост", "0.3", "1.5"}, tokenize("Пористость 0.3, 1,5."))
+	assert.Equal(t, []string{"well", "log", "gr"}, tokenize("Well log of the GR"))
+	assert.Empty(t, tokenize("и в на, а также"))
+}
+
+func TestBM25Index_Ranking(t *testing.T) {
+	index := newBM25Index([]string{
+		"Общие сведения о месторождении и районе работ.",
+		"Проект разработки утвержден. Техническое задание на проектирование приложено.",
+		"Техническое задание на проведение работ утверждено заказчиком. Задание согласовано.",
+		"Пористость коллекторов составляет 0.3 по данным керна.",
+	})
+
+	results := index.Search("Наличие технического задания", 5)
+	require.Len(t, results, 2)
+	// Чанк с повторяющимся термином оценивается выше первого по порядку
+	assert.Equal(t, 2, results[0].Index)
+	assert.Equal(t, 1, results[1].Index)
+	assert.Greater(t, results[0].Score, results[1].Score)
+
+	results = index.Search("пористость 0.3", 5)
+	require.Len(t, results, 1)
+	assert.Equal(t, 3, results[0].Index)
+
+	assert.Empty(t, index.Search("сейсморазведка", 5))
+	assert.Empty(t, index.Search("и на", 5))
+	assert.Len(t, index.Search("работ", 1), 1)
+}
+
+func TestBM25Index_RareTermsWeighMore(t *testing.T) {
+	index := newBM25Index([]string{
+		"Программа работ по скважине",
+		"Программа работ по керну",
+		"Программа работ",
+	})
+
+	results := index.Search("программа керн", 3)
+	require.Len(t, results, 3)
+	assert.Equal(t, 1, results[0].Index)
+}
@@ -0,0 +1,208 @@
+package tasks
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"evaluation/internal/extract"
+)
+
+// Значения по умолчанию для RAGConfig без размеров чанков
+const (
+	defaultMaxChunkSize = 700
+	defaultChunkOverlap = 150
+)
+
+// minChunkSize чанки короче этого числа символов (номера страниц, колонтитулы) не индексируются
+const minChunkSize = 10
+
+// headingMaxLength строка не длиннее этого числа символов без завершающей пунктуации считается заголовком
+const headingMaxLength = 120
+
+// sentenceEndPattern конец предложения: знак препинания и пробел, поэтому числа вида "0.3" не разрываются
+var sentenceEndPattern = regexp.MustCompile(`[.!?…]+["»)]*\s+`)
+
+// textChunk чанк текста и фрагмент документа, из которого он взят
+type textChunk struct {
+	Text    string
+	Section extract.Section
+}
+
+// chunkSections разбивает фрагменты документа на чанки не длиннее maxSize символов
+// Соседние чанки одного фрагмента перекрываются на overlap символов по границе слова.
+// Чанк не выходит за пределы фрагмента, поэтому номер страницы или слайда чанка точный.
+// Заголовки присоединяются к следующему за ними абзацу и не отрываются от него
+func chunkSections(sections []extract.Section, maxSize, overlap int) []textChunk {
+	maxSize, overlap = chunkSettings(maxSize, overlap)
+
+	var chunks []textChunk
+	for _, section := range sections {
+		for _, text := range packUnits(sectionUnits(section.Text), maxSize, overlap) {
+			if utf8.RuneCountInString(text) > minChunkSize {
+				chunks = append(chunks, textChunk{Text: text, Section: section})
+			}
+		}
+	}
+	return chunks
+}
+
+// chunkSettings возвращает размер чанка и перекрытие, с которыми разбивается текст:
+// неположительный размер заменяется значением по умолчанию, перекрытие - не больше половины чанка
+func chunkSettings(maxSize, overlap int) (int, int) {
+	if maxSize <= 0 {
+		maxSize = defaultMaxChunkSize
+	}
+	if overlap < 0 || overlap >= maxSize/2 {
+		overlap = min(defaultChunkOverlap, maxSize/4)
+	}
+	return maxSize, overlap
+}
+
+// sectionUnits делит текст на абзацы, заголовки объединяются со следующим абзацем
+func sectionUnits(text string) []string {
+	var (
+		units    []string
+		headings []string
+	)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if isHeading(line) {
+			headings = append(headings, line)
+			continue
+		}
+		if len(headings) > 0 {
+			line = strings.Join(append(headings, line), "\n")
+			headings = nil
+		}
+		units = append(units, line)
+	}
+	if len(headings) > 0 {
+		units = append(units, strings.Join(headings, "\n"))
+	}
+	return units
+}
+
+// isHeading определяет заголовок: короткая строка, не заканчивающаяся точкой или другим концом предложения
+func isHeading(line string) bool {
+	if utf8.RuneCountInString(line) > headingMaxLength {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(line)
+	return !strings.ContainsRune(".!?…;,", last)
+}
+
+// packUnits собирает абзацы в чанки не длиннее maxSize символов
+// Длинный абзац делится по предложениям, а предложение - по словам на части,
+// рядом с которыми всегда помещается перекрытие с предыдущим чанком и перевод строки
+func packUnits(units []string, maxSize, overlap int) []string {
+	var (
+		chunks  []string
+		current []string
+		length  int
+	)
+	for _, unit := range units {
+		for _, piece := range splitText(unit, maxSize-overlap-1) {
+			size := utf8.RuneCountInString(piece)
+			if length > 0 && length+1+size > maxSize {
+				previous := strings.Join(current, "\n")
+				chunks = append(chunks, previous)
+				current, length = nil, 0
+
+				// Новый чанк начинается с конца предыдущего
+				if tail := overlapTail(previous, overlap); tail != "" {
+					current, length = []string{tail}, utf8.RuneCountInString(tail)
+				}
+			}
+			if length > 0 {
+				length++
+			}
+			current = append(current, piece)
+			length += size
+		}
+	}
+	if length > 0 {
+		chunks = append(chunks, strings.Join(current, "\n"))
+	}
+	return chunks
+}
+
+// splitText делит текст длиннее maxSize символов на части по предложениям,
+// слишком длинные предложения - по словам, слишком длинные слова - по символам
+func splitText(text string, maxSize int) []string {
+	if utf8.RuneCountInString(text) <= maxSize {
+		return []string{text}
+	}
+
+	var sentences []string
+	start := 0
+	for _, match := range sentenceEndPattern.FindAllStringIndex(text, -1) {
+		sentences = append(sentences, strings.TrimSpace(text[start:match[1]]))
+		start = match[1]
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+
+	var words []string
+	for _, sentence := range sentences {
+		if utf8.RuneCountInString(sentence) <= maxSize {
+			words = append(words, sentence)
+			continue
+		}
+		for _, word := range strings.Fields(sentence) {
+			for utf8.RuneCountInString(word) > maxSize {
+				runes := []rune(word)
+				words = append(words, string(runes[:maxSize]))
+				word = string(runes[maxSize:])
+			}
+			words = append(words, word)
+		}
+	}
+
+	// Собираем части обратно через пробел, не превышая maxSize
+	var (
+		parts  []string
+		part   strings.Builder
+		length int
+	)
+	for _, word := range words {
+		size := utf8.RuneCountInString(word)
+		if length > 0 && length+1+size > maxSize {
+			parts = append(parts, part.String())
+			part.Reset()
+			length = 0
+		}
+		if length > 0 {
+			part.WriteString(" ")
+			length++
+		}
+		part.WriteString(word)
+		length += size
+	}
+	if length > 0 {
+		parts = append(parts, part.String())
+	}
+	return parts
+}
+
+// overlapTail возвращает последние overlap символов текста, начиная с границы слова
+func overlapTail(text string, overlap int) string {
+	if overlap <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	if len(runes) <= overlap {
+		return text
+	}
+
+	tail := string(runes[len(runes)-overlap:])
+	// Отбрасываем обрезанное слово в начале перекрытия
+	if i := strings.IndexFunc(tail, func(r rune) bool { return r == ' ' || r == '\n' }); i >= 0 {
+		tail = tail[i+1:]
+	}
+	return strings.TrimSpace(tail)
+}
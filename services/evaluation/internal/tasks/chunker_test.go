@@ -0,0 +1,92 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"evaluation/internal/extract"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkSections_SizeAndOverlap(t *testing.T) {
+	var sentences []string
+	for i := 0; i < 40; i++ {
+		sentences = append(sentences, "Пористость пласта составляет 0.3 д.ед. по данным керна")
+	}
+	text := strings.Join(sentences, ". ") + "."
+
+	chunks := chunkSections([]extract.Section{{Page: 4, Location: "стр. 4", Text: text}}, 200, 50)
+
+	require.Greater(t, len(chunks), 1)
+	for i, chunk := range chunks {
+		assert.LessOrEqual(t, utf8.RuneCountInString(chunk.Text), 200, "chunk %d", i)
+		assert.Equal(t, 4, chunk.Section.Page)
+		// Десятичные числа не разрываются
+		assert.NotContains(t, chunk.Text, "0.\n")
+		assert.False(t, strings.HasPrefix(chunk.Text, "3 д"), "chunk %d", i)
+	}
+
+	// Следующий чанк начинается с конца предыдущего
+	for i := 1; i < len(chunks); i++ {
+		overlap := strings.SplitN(chunks[i].Text, "\n", 2)[0]
+		assert.True(t, strings.HasSuffix(chunks[i-1].Text, overlap), "chunk %d", i)
+		assert.LessOrEqual(t, utf8.RuneCountInString(overlap), 50)
+	}
+}
+
+func TestChunkSections_HeadingStaysWithBody(t *testing.T) {
+	body := strings.Repeat("Запасы подсчитаны объемным методом. ", 4)
+	text := strings.Join([]string{
+		body,
+		"2.1 Геологическое строение",
+		body,
+	}, "\n")
+
+	chunks := chunkSections([]extract.Section{{Text: text}}, 200, 0)
+
+	require.Len(t, chunks, 2)
+	assert.True(t, strings.HasPrefix(chunks[1].Text, "2.1 Геологическое строение\nЗапасы"), chunks[1].Text)
+	assert.NotContains(t, chunks[0].Text, "Геологическое строение")
+}
+
+func TestChunkSections_PageBoundaries(t *testing.T) {
+	chunks := chunkSections([]extract.Section{
+		{Page: 1, Location: "стр. 1", Text: "Введение в проект разработки."},
+		{Page: 2, Location: "стр. 2", Text: "12"},
+		{Page: 3, Location: "стр. 3", Text: "Программа доизучения месторождения."},
+	}, 700, 150)
+
+	// Чанки не объединяют страницы, короткие колонтитулы не индексируются
+	require.Len(t, chunks, 2)
+	assert.Equal(t, 1, chunks[0].Section.Page)
+	assert.Equal(t, 3, chunks[1].Section.Page)
+	assert.Equal(t, "Программа доизучения месторождения.", chunks[1].Text)
+}
+
+func TestSplitText_LongWords(t *testing.T) {
+	word := strings.Repeat("а", 25)
+	parts := splitText(word+" "+word, 10)
+
+	require.Len(t, parts, 6)
+	for _, part := range parts {
+		assert.LessOrEqual(t, utf8.RuneCountInString(part), 10)
+	}
+	assert.Equal(t, word+word, strings.Join(parts, ""))
+}
+
+func TestOverlapTail(t *testing.T) {
+	assert.Equal(t, "", overlapTail("первое второе", 0))
+	assert.Equal(t, "коротко", overlapTail("коротко", 20))
+	assert.Equal(t, "третье", overlapTail("первое второе третье", 8))
+}
+
+func TestChunkSections_NeverExceedsMaxSize(t *testing.T) {
+	// Абзацы длиной около перекрытия и около maxSize-overlap дают чанки ровно на границе размера
+	text := strings.Join([]string{strings.Repeat("б", 20) + ".", strings.Repeat("в ", 40) + "."}, "\n")
+	for _, chunk := range chunkSections([]extract.Section{{Text: text}}, 50, 20) {
+		assert.LessOrEqual(t, utf8.RuneCountInString(chunk.Text), 50, chunk.Text)
+	}
+}
@@ -0,0 +1,178 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCronExpr cron-выражение не удалось разобрать
+var ErrInvalidCronExpr = errors.New("invalid cron expression")
+
+// cronMacros сокращения для распространенных расписаний
+var cronMacros = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+// cronSearchLimit насколько далеко вперед ищется следующий запуск:
+// выражения вроде "0 0 30 2 *" не срабатывают никогда
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// CronSchedule разобранное cron-выражение из пяти полей:
+// минута, час, день месяца, месяц, день недели (0 и 7 - воскресенье)
+// Время запуска вычисляется в UTC
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// если ограничены и день месяца, и день недели, достаточно совпадения любого из них
+	domRestricted, dowRestricted bool
+}
+
+// cronField допустимый диапазон значений поля cron-выражения
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = [5]cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseCron разбирает cron-выражение: "*", значения, диапазоны "a-b", шаги "*/n" и "a-b/n",
+// списки через запятую, а также сокращения @hourly, @daily, @weekly, @monthly, @yearly
+func ParseCron(expr string) (*CronSchedule, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("%w %q: expected 5 fields, got %d", ErrInvalidCronExpr, expr, len(fields))
+	}
+
+	var bits [5]uint64
+	for i, field := range fields {
+		b, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidCronExpr, expr, err)
+		}
+		bits[i] = b
+	}
+
+	// Воскресенье допускается как 0 и как 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &CronSchedule{
+		minute:        bits[0],
+		hour:          bits[1],
+		dom:           bits[2],
+		month:         bits[3],
+		dow:           bits[4],
+		domRestricted: !strings.HasPrefix(fields[2], "*"),
+		dowRestricted: !strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseCronField разбирает одно поле cron-выражения в битовую маску допустимых значений
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, spec.name)
+			}
+			step = n
+		}
+
+		var from, to int
+		switch {
+		case rangePart == "*":
+			from, to = spec.min, spec.max
+		case strings.Contains(rangePart, "-"):
+			lo, hi, _ := strings.Cut(rangePart, "-")
+			var err error
+			if from, err = strconv.Atoi(lo); err != nil {
+				return 0, fmt.Errorf("invalid value %q in %s field", lo, spec.name)
+			}
+			if to, err = strconv.Atoi(hi); err != nil {
+				return 0, fmt.Errorf("invalid value %q in %s field", hi, spec.name)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q in %s field", rangePart, spec.name)
+			}
+			from, to = n, n
+			// "5/15" означает "с 5 до конца диапазона с шагом 15"
+			if hasStep {
+				to = spec.max
+			}
+		}
+
+		if from < spec.min || to > spec.max || from > to {
+			return 0, fmt.Errorf("%s field value out of range %d-%d: %q", spec.name, spec.min, spec.max, part)
+		}
+
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next возвращает ближайшее время запуска строго после after
+// Возвращает нулевое время, если выражение не срабатывает в обозримом будущем
+func (s *CronSchedule) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches проверяет день месяца и день недели по правилам cron
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
@@ -0,0 +1,68 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron_Next(t *testing.T) {
+	// Пятница, 16 октября 2026
+	from := time.Date(2026, 10, 16, 14, 37, 20, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 16, 14, 38, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 16, 14, 45, 0, 0, time.UTC)},
+		{"5/20 14 * * *", time.Date(2026, 10, 16, 14, 45, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2026, 10, 16, 17, 0, 0, 0, time.UTC)},
+		{"30 2 * * 0", time.Date(2026, 10, 18, 2, 30, 0, 0, time.UTC)},
+		{"30 2 * * 7", time.Date(2026, 10, 18, 2, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// День месяца и день недели объединяются через "или"
+		{"0 0 20 * 6", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15,31 * *", time.Date(2026, 10, 31, 12, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, schedule.Next(from))
+		})
+	}
+}
+
+func TestParseCron_NeverFires(t *testing.T) {
+	schedule, err := ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(time.Now()).IsZero())
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"a * * * *",
+		"@every 5m",
+	} {
+		_, err := ParseCron(expr)
+		assert.ErrorIs(t, err, ErrInvalidCronExpr, expr)
+	}
+}
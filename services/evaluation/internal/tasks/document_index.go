@@ -0,0 +1,296 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	db "evaluation/internal/postgres/sqlc"
+	"evaluation/internal/storage"
+)
+
+// TaskKindDocumentIndex индексация загруженного файла документации
+// Задача не относится к конвейерам проекта и не меняет его статус
+const TaskKindDocumentIndex = "document_index"
+
+// errDocumentUnavailable файл документации не удалось прочитать из хранилища
+var errDocumentUnavailable = errors.New("documentation file is unavailable")
+
+// DocumentIndexStore хранилище индекса документации проектов
+type DocumentIndexStore interface {
+	ListDocumentIndex(ctx context.Context, projectID int32) ([]db.DocumentIndex, error)
+	FindDocumentIndexByHash(ctx context.Context, arg db.FindDocumentIndexByHashParams) (*db.DocumentIndex, error)
+	SaveDocumentIndex(ctx context.Context, arg db.UpsertDocumentIndexParams) error
+}
+
+// documentIndexer ведет персистентный индекс документации: текст файла извлекается и разбивается на чанки
+// один раз, а следующие проверки чек-листа берут чанки и эмбеддинги из базы данных
+type documentIndexer struct {
+	store   DocumentIndexStore
+	storage storage.FileStorage
+	rag     *RAGSystem
+}
+
+func newDocumentIndexer(store DocumentIndexStore, storage storage.FileStorage, rag *RAGSystem) *documentIndexer {
+	return &documentIndexer{store: store, storage: storage, rag: rag}
+}
+
+// projectIndex возвращает сохраненный индекс файлов проекта по ID файла
+func (ix *documentIndexer) projectIndex(ctx context.Context, projectID int32) (map[int32]db.DocumentIndex, error) {
+	entries, err := ix.store.ListDocumentIndex(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document index: %w", err)
+	}
+
+	index := make(map[int32]db.DocumentIndex, len(entries))
+	for _, entry := range entries {
+		index[entry.ProjectFileID] = entry
+	}
+	return index, nil
+}
+
+// settings возвращает параметры разбиения и модель эмбеддингов, с которыми индексируется документация
+// Пустая модель - поиск не использует эмбеддинги
+func (ix *documentIndexer) settings() (chunkSize, chunkOverlap int32, embeddingModel string) {
+	maxSize, overlap := chunkSettings(ix.rag.config.MaxChunkSize, ix.rag.config.ChunkOverlap)
+	if ix.rag.embedder != nil {
+		embeddingModel = ix.rag.config.EmbeddingModel
+	}
+	return int32(maxSize), int32(overlap), embeddingModel
+}
+
+// fileChunks возвращает чанки файла документации из индекса entry, обновляя индекс, если он устарел:
+// при других параметрах разбиения файл индексируется заново, при другой модели пересчитываются только эмбеддинги
+// Файл без индекса не извлекается, если в базе есть индекс файла с тем же содержимым
+func (ix *documentIndexer) fileChunks(ctx context.Context, file db.ProjectFile, entry *db.DocumentIndex) ([]DocumentChunk, error) {
+	chunkSize, chunkOverlap, embeddingModel := ix.settings()
+
+	if entry != nil && entry.ChunkSize == chunkSize && entry.ChunkOverlap == chunkOverlap {
+		chunks, err := indexedChunks(*entry, file, embeddingModel)
+		if err != nil {
+			return nil, err
+		}
+		// Индекс с эмбеддингами подходит и для поиска без них
+		if embeddingModel == "" || entry.EmbeddingModel == embeddingModel {
+			return chunks, nil
+		}
+		return ix.save(ctx, file, entry.ContentHash, chunks)
+	}
+
+	content, err := ix.download(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+	contentHash := hex.EncodeToString(sum[:])
+
+	same, err := ix.store.FindDocumentIndexByHash(ctx, db.FindDocumentIndexByHashParams{
+		ContentHash:    contentHash,
+		ChunkSize:      chunkSize,
+		ChunkOverlap:   chunkOverlap,
+		EmbeddingModel: embeddingModel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find document index by content hash: %w", err)
+	}
+	if same != nil {
+		log.Printf("Reusing document index of file %d for file %s with the same content", same.ProjectFileID, file.Filename)
+		chunks, err := indexedChunks(*same, file, embeddingModel)
+		if err != nil {
+			return nil, err
+		}
+		return ix.save(ctx, file, contentHash, chunks)
+	}
+
+	// Файл, из которого не удалось извлечь текст, не сохраняется в индексе: индекс переиспользуется
+	// для всех файлов с тем же содержимым, и исправленный или новый извлекатель иначе не применился бы
+	sections, err := ix.rag.extractTextFromFile(bytes.NewReader(content), file.OriginalName)
+	if err != nil {
+		log.Printf("Failed to extract text from file %s: %v", file.Filename, err)
+		return nil, nil
+	}
+	return ix.save(ctx, file, contentHash, ix.rag.splitTextIntoChunks(sections, file.OriginalName))
+}
+
+// download читает файл документации из хранилища
+func (ix *documentIndexer) download(ctx context.Context, file db.ProjectFile) ([]byte, error) {
+	reader, err := ix.storage.DownloadFile(ctx, file.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to download %s: %v", errDocumentUnavailable, file.Filename, err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read %s: %v", errDocumentUnavailable, file.Filename, err)
+	}
+	return content, nil
+}
+
+// save вычисляет недостающие эмбеддинги чанков и заменяет индекс файла
+func (ix *documentIndexer) save(ctx context.Context, file db.ProjectFile, contentHash string, chunks []DocumentChunk) ([]DocumentChunk, error) {
+	chunkSize, chunkOverlap, embeddingModel := ix.settings()
+	if ix.rag.embedder != nil {
+		if err := embedChunks(ctx, ix.rag.embedder, chunks); err != nil {
+			return nil, err
+		}
+	}
+
+	if chunks == nil {
+		chunks = []DocumentChunk{}
+	}
+	data, err := json.Marshal(chunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document index: %w", err)
+	}
+
+	err = ix.store.SaveDocumentIndex(ctx, db.UpsertDocumentIndexParams{
+		ProjectFileID:  file.ID,
+		ProjectID:      file.ProjectID,
+		ContentHash:    contentHash,
+		ChunkSize:      chunkSize,
+		ChunkOverlap:   chunkOverlap,
+		EmbeddingModel: embeddingModel,
+		Chunks:         data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save document index of file %s: %w", file.Filename, err)
+	}
+	return chunks, nil
+}
+
+// indexedChunks декодирует чанки индекса для файла file: имя файла в метаданных заменяется
+// на имя file, так как индекс мог быть построен для другого файла с тем же содержимым
+// Эмбеддинги другой модели отбрасываются
+func indexedChunks(entry db.DocumentIndex, file db.ProjectFile, embeddingModel string) ([]DocumentChunk, error) {
+	var chunks []DocumentChunk
+	if err := json.Unmarshal(entry.Chunks, &chunks); err != nil {
+		return nil, fmt.Errorf("failed to decode document index of file %d: %w", entry.ProjectFileID, err)
+	}
+
+	for i := range chunks {
+		if chunks[i].Metadata == nil {
+			chunks[i].Metadata = make(map[string]string)
+		}
+		chunks[i].Metadata["filename"] = file.OriginalName
+		if entry.EmbeddingModel != embeddingModel {
+			chunks[i].Embedding = nil
+		}
+	}
+	return chunks, nil
+}
+
+// DocumentIndexPayload параметры задачи индексации, сохраняемые в очереди
+type DocumentIndexPayload struct {
+	FileID int32 `json:"file_id"`
+}
+
+// DocumentIndexTask индексирует загруженный файл документации заранее,
+// чтобы проверка чек-листа брала его чанки из индекса
+type DocumentIndexTask struct {
+	projectID int32
+	priority  int
+	payload   DocumentIndexPayload
+	repo      Repository
+	storage   storage.FileStorage
+	rag       RAGConfig
+}
+
+// NewDocumentIndexTask создает задачу индексации файла документации проекта
+func NewDocumentIndexTask(projectID, fileID int32, priority int, repo Repository, storage storage.FileStorage) *DocumentIndexTask {
+	return &DocumentIndexTask{
+		projectID: projectID,
+		priority:  priority,
+		payload:   DocumentIndexPayload{FileID: fileID},
+		repo:      repo,
+		storage:   storage,
+		rag:       DefaultRAGConfig(),
+	}
+}
+
+// NewDocumentIndexTaskFactory создает фабрику, восстанавливающую задачи индексации из очереди
+// Документация индексируется с параметрами RAG-системы rag; пустая конфигурация - DefaultRAGConfig
+func NewDocumentIndexTaskFactory(repo Repository, storage storage.FileStorage, rag RAGConfig) TaskFactory {
+	return func(job *db.Job) (Task, error) {
+		task := NewDocumentIndexTask(job.ProjectID, 0, int(job.Priority), repo, storage)
+		if rag != (RAGConfig{}) {
+			task.rag = rag
+		}
+		if err := json.Unmarshal(job.Payload, &task.payload); err != nil {
+			return nil, fmt.Errorf("failed to decode payload: %w", err)
+		}
+		return task, nil
+	}
+}
+
+// Execute индексирует файл, если его индекс отсутствует или устарел
+func (t *DocumentIndexTask) Execute(ctx context.Context) error {
+	files, err := t.repo.GetProjectFilesByType(ctx, t.projectID, db.FileTypeDocumentation)
+	if err != nil {
+		return fmt.Errorf("failed to get documentation files: %w", err)
+	}
+
+	var file *db.ProjectFile
+	for i := range files {
+		if files[i].ID == t.payload.FileID {
+			file = &files[i]
+			break
+		}
+	}
+	if file == nil {
+		log.Printf("Documentation file %d of project %d not found, skipping indexing", t.payload.FileID, t.projectID)
+		return nil
+	}
+
+	rag, err := NewRAGSystem(t.rag)
+	if err != nil {
+		return fmt.Errorf("failed to create RAG system: %w", err)
+	}
+	indexer := newDocumentIndexer(t.repo, t.storage, rag)
+
+	index, err := indexer.projectIndex(ctx, t.projectID)
+	if err != nil {
+		return err
+	}
+	var entry *db.DocumentIndex
+	if existing, ok := index[file.ID]; ok {
+		entry = &existing
+	}
+
+	chunks, err := indexer.fileChunks(ctx, *file, entry)
+	if errors.Is(err, errDocumentUnavailable) {
+		return Retryable(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Indexed %d chunks of documentation file %s for project %d", len(chunks), file.Filename, t.projectID)
+	return nil
+}
+
+// GetProjectID возвращает ID проекта
+func (t *DocumentIndexTask) GetProjectID() int32 {
+	return t.projectID
+}
+
+// GetPriority возвращает приоритет задачи
+func (t *DocumentIndexTask) GetPriority() int {
+	return t.priority
+}
+
+// GetKind возвращает тип задачи
+func (t *DocumentIndexTask) GetKind() string {
+	return TaskKindDocumentIndex
+}
+
+// GetPayload возвращает параметры задачи для сохранения в очереди
+func (t *DocumentIndexTask) GetPayload() ([]byte, error) {
+	return json.Marshal(t.payload)
+}
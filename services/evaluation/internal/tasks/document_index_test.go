@@ -0,0 +1,219 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"evaluation/internal/extract"
+	db "evaluation/internal/postgres/sqlc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIndexStore индекс документации в памяти
+type fakeIndexStore struct {
+	entries map[int32]db.DocumentIndex
+}
+
+func newFakeIndexStore() *fakeIndexStore {
+	return &fakeIndexStore{entries: make(map[int32]db.DocumentIndex)}
+}
+
+func (s *fakeIndexStore) ListDocumentIndex(_ context.Context, projectID int32) ([]db.DocumentIndex, error) {
+	var entries []db.DocumentIndex
+	for _, entry := range s.entries {
+		if entry.ProjectID == projectID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (s *fakeIndexStore) FindDocumentIndexByHash(_ context.Context, arg db.FindDocumentIndexByHashParams) (*db.DocumentIndex, error) {
+	for _, entry := range s.entries {
+		if entry.ContentHash == arg.ContentHash && entry.ChunkSize == arg.ChunkSize && entry.ChunkOverlap == arg.ChunkOverlap {
+			return &entry, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeIndexStore) SaveDocumentIndex(_ context.Context, arg db.UpsertDocumentIndexParams) error {
+	s.entries[arg.ProjectFileID] = db.DocumentIndex{
+		ProjectFileID:  arg.ProjectFileID,
+		ProjectID:      arg.ProjectID,
+		ContentHash:    arg.ContentHash,
+		ChunkSize:      arg.ChunkSize,
+		ChunkOverlap:   arg.ChunkOverlap,
+		EmbeddingModel: arg.EmbeddingModel,
+		Chunks:         arg.Chunks,
+	}
+	return nil
+}
+
+// fakeFileStorage хранилище файлов в памяти, считающее скачивания
+type fakeFileStorage struct {
+	files     map[string]string
+	downloads int
+}
+
+func (s *fakeFileStorage) UploadFile(_ context.Context, _ io.Reader, fileName, _ string) (string, error) {
+	return fileName, nil
+}
+
+func (s *fakeFileStorage) DownloadFile(_ context.Context, objectName string) (io.ReadCloser, error) {
+	content, ok := s.files[objectName]
+	if !ok {
+		return nil, errors.New("object not found")
+	}
+	s.downloads++
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func (s *fakeFileStorage) DeleteFile(context.Context, string) error { return nil }
+
+func (s *fakeFileStorage) GetFileURL(objectName string) string { return objectName }
+
+// countingEmbedder эмбеддер, возвращающий вектор длины текста и считающий эмбеддинги
+type countingEmbedder struct {
+	texts int
+}
+
+func (e *countingEmbedder) Embed(_ context.Context, texts []string) ([][]float64, error) {
+	e.texts += len(texts)
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float64{float64(len(text))}
+	}
+	return vectors, nil
+}
+
+const indexedDocument = "Техническое задание на проектирование утверждено заказчиком"
+
+func TestDocumentIndexer_ReusesIndex(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeIndexStore()
+	files := &fakeFileStorage{files: map[string]string{"a.txt": indexedDocument, "b.txt": indexedDocument}}
+	rag, err := NewRAGSystem(RAGConfig{})
+	require.NoError(t, err)
+	indexer := newDocumentIndexer(store, files, rag)
+
+	first := db.ProjectFile{ID: 1, ProjectID: 7, Filename: "a.txt", OriginalName: "ТЗ.txt", FilePath: "a.txt"}
+	chunks, err := indexer.fileChunks(ctx, first, nil)
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "ТЗ.txt", chunks[0].Metadata["filename"])
+	assert.Equal(t, int32(defaultMaxChunkSize), store.entries[1].ChunkSize)
+
+	// Следующая проверка берет чанки из индекса, не скачивая файл
+	index, err := indexer.projectIndex(ctx, 7)
+	require.NoError(t, err)
+	entry := index[1]
+	cached, err := indexer.fileChunks(ctx, first, &entry)
+	require.NoError(t, err)
+	assert.Equal(t, chunks, cached)
+	assert.Equal(t, 1, files.downloads)
+
+	// Файл с тем же содержимым получает копию индекса под своим именем
+	second := db.ProjectFile{ID: 2, ProjectID: 8, Filename: "b.txt", OriginalName: "ТЗ (копия).txt", FilePath: "b.txt"}
+	copied, err := indexer.fileChunks(ctx, second, nil)
+	require.NoError(t, err)
+	require.Len(t, copied, 1)
+	assert.Equal(t, chunks[0].Content, copied[0].Content)
+	assert.Equal(t, "ТЗ (копия).txt", copied[0].Metadata["filename"])
+	assert.Equal(t, store.entries[1].ContentHash, store.entries[2].ContentHash)
+	assert.Equal(t, int32(8), store.entries[2].ProjectID)
+
+	// При смене размера чанков файл индексируется заново
+	rag.config.MaxChunkSize = 500
+	_, err = indexer.fileChunks(ctx, first, &entry)
+	require.NoError(t, err)
+	assert.Equal(t, int32(500), store.entries[1].ChunkSize)
+}
+
+func TestDocumentIndexer_StoresEmbeddings(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeIndexStore()
+	files := &fakeFileStorage{files: map[string]string{"a.txt": indexedDocument}}
+	rag, err := NewRAGSystem(RAGConfig{})
+	require.NoError(t, err)
+	indexer := newDocumentIndexer(store, files, rag)
+	file := db.ProjectFile{ID: 1, ProjectID: 7, Filename: "a.txt", OriginalName: "ТЗ.txt", FilePath: "a.txt"}
+
+	// Индекс без эмбеддингов дополняется ими без повторного извлечения текста
+	_, err = indexer.fileChunks(ctx, file, nil)
+	require.NoError(t, err)
+	embedder := &countingEmbedder{}
+	rag.embedder = embedder
+	rag.config.EmbeddingModel = "bge-m3"
+
+	entry := store.entries[1]
+	chunks, err := indexer.fileChunks(ctx, file, &entry)
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.NotEmpty(t, chunks[0].Embedding)
+	assert.Equal(t, "bge-m3", store.entries[1].EmbeddingModel)
+	assert.Equal(t, 1, embedder.texts)
+	assert.Equal(t, 1, files.downloads)
+
+	// Сохраненные эмбеддинги не вычисляются повторно ни индексатором, ни поиском
+	entry = store.entries[1]
+	chunks, err = indexer.fileChunks(ctx, file, &entry)
+	require.NoError(t, err)
+	retriever := newEmbeddingRetriever(embedder)
+	require.NoError(t, retriever.Index(ctx, chunks))
+	assert.Equal(t, 1, embedder.texts)
+
+	// Эмбеддинги другой модели пересчитываются
+	rag.config.EmbeddingModel = "e5"
+	entry = store.entries[1]
+	_, err = indexer.fileChunks(ctx, file, &entry)
+	require.NoError(t, err)
+	assert.Equal(t, "e5", store.entries[1].EmbeddingModel)
+	assert.Equal(t, 2, embedder.texts)
+}
+
+func TestDocumentIndexer_UnavailableAndUnsupported(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeIndexStore()
+	files := &fakeFileStorage{files: map[string]string{"scan.djvu": "binary"}}
+	rag, err := NewRAGSystem(RAGConfig{})
+	require.NoError(t, err)
+	indexer := newDocumentIndexer(store, files, rag)
+
+	_, err = indexer.fileChunks(ctx, db.ProjectFile{ID: 1, ProjectID: 7, Filename: "missing.pdf", FilePath: "missing.pdf"}, nil)
+	assert.ErrorIs(t, err, errDocumentUnavailable)
+	assert.Empty(t, store.entries)
+
+	// Файл неподдерживаемого формата не попадает в индекс: после регистрации извлекателя
+	// следующая проверка извлечет его текст
+	scan := db.ProjectFile{ID: 2, ProjectID: 7, Filename: "scan.djvu", OriginalName: "scan.djvu", FilePath: "scan.djvu"}
+	chunks, err := indexer.fileChunks(ctx, scan, nil)
+	require.NoError(t, err)
+	assert.Empty(t, chunks)
+	assert.Empty(t, store.entries)
+
+	rag.extractors.Register(".djvu", extract.ExtractorFunc(func(content []byte) ([]extract.Section, error) {
+		return []extract.Section{{Text: indexedDocument}}, nil
+	}))
+	chunks, err = indexer.fileChunks(ctx, scan, nil)
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Contains(t, store.entries, int32(2))
+	assert.Equal(t, 2, files.downloads)
+}
+
+func TestDocumentIndexTask_Payload(t *testing.T) {
+	task := NewDocumentIndexTask(3, 42, PriorityNormal, nil, nil)
+	payload, err := task.GetPayload()
+	require.NoError(t, err)
+
+	restored, err := NewDocumentIndexTaskFactory(nil, nil, RAGConfig{})(&db.Job{ProjectID: 3, Kind: TaskKindDocumentIndex, Payload: payload})
+	require.NoError(t, err)
+	assert.Equal(t, int32(42), restored.(*DocumentIndexTask).payload.FileID)
+	assert.Equal(t, TaskKindDocumentIndex, restored.GetKind())
+}
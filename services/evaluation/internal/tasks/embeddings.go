@@ -0,0 +1,83 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Embedder вычисляет эмбеддинги текстов
+type Embedder interface {
+	// Embed возвращает по вектору на каждый текст в порядке текстов
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// EmbeddingClient клиент API эмбеддингов, совместимого с OpenAI (/v1/embeddings) или Ollama (/api/embed)
+// Оба API принимают {"model", "input"}; формат ответа определяется по его полям
+type EmbeddingClient struct {
+	url    string
+	model  string
+	apiKey string
+	client *resty.Client
+}
+
+// NewEmbeddingClient создает клиент для url - полного адреса метода эмбеддингов
+// apiKey передается как Bearer токен, пустое значение - без авторизации
+func NewEmbeddingClient(url, model, apiKey string) *EmbeddingClient {
+	client := resty.New().
+		SetTimeout(120 * time.Second).
+		SetRetryCount(3).
+		SetRetryWaitTime(1 * time.Second)
+
+	return &EmbeddingClient{url: url, model: model, apiKey: apiKey, client: client}
+}
+
+// embeddingResponse ответ OpenAI (data) или Ollama (embeddings)
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// Embed отправляет тексты одним запросом, запрос прерывается при отмене контекста
+func (c *EmbeddingClient) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	request := c.client.R().
+		SetContext(ctx).
+		SetBody(map[string]interface{}{
+			"model": c.model,
+			"input": texts,
+		}).
+		SetResult(&embeddingResponse{}).
+		ForceContentType("application/json")
+	if c.apiKey != "" {
+		request.SetAuthToken(c.apiKey)
+	}
+
+	resp, err := request.Post(c.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call embeddings API: %w", err)
+	}
+
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("embeddings API returned status %d: %s", resp.StatusCode(), resp.String())
+	}
+
+	result := resp.Result().(*embeddingResponse)
+	if len(result.Embeddings) > 0 {
+		return result.Embeddings, nil
+	}
+
+	// OpenAI не гарантирует порядок элементов data, позиция текста передается в index
+	vectors := make([][]float64, len(result.Data))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(vectors) {
+			return nil, fmt.Errorf("embeddings API returned invalid index %d", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return vectors, nil
+}
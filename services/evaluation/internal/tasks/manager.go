@@ -2,79 +2,256 @@ package tasks
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"sort"
 	"sync"
 	"time"
 
+	db "evaluation/internal/postgres/sqlc"
+
 	"github.com/google/uuid"
 )
 
+// errJobLockLost причина прерывания задачи, блокировку которой захватил другой воркер
+var errJobLockLost = errors.New("job lock lost")
+
+// ManagerConfig настройки менеджера задач
+type ManagerConfig struct {
+	WorkerCount  int           // количество воркеров в процессе
+	PollInterval time.Duration // интервал опроса очереди при отсутствии задач
+	LockTimeout  time.Duration // время блокировки задачи без heartbeat
+	// PriorityAging интервал ожидания, за который приоритет задачи повышается на 1,
+	// чтобы низкоприоритетные задачи не голодали
+	PriorityAging time.Duration
+	// KindConcurrency максимальное количество одновременно выполняемых в процессе задач
+	// каждого типа; типы без ограничения могут занять все воркеры
+	KindConcurrency map[string]int
+	// ScheduleInterval интервал проверки наступивших расписаний
+	ScheduleInterval time.Duration
+}
+
 // taskItem элемент очереди задач с приоритетом
 type taskItem struct {
 	task      Task
 	priority  int
 	timestamp time.Time
 	id        string
+	jobID     uuid.UUID
+	kind      string
+	attempt   int32
 }
 
-// taskManager реализация TaskManager
+// runningTask выполняющаяся в процессе задача и функция ее отмены
+type runningTask struct {
+	info   RunningTask
+	cancel context.CancelCauseFunc
+}
+
+// taskManager реализация TaskManager поверх персистентной очереди в PostgreSQL
 type taskManager struct {
-	taskQueue   chan taskItem
-	results     chan TaskResult
-	stopChan    chan struct{}
-	wg          sync.WaitGroup
-	mu          sync.RWMutex
-	stats       TaskStats
-	isRunning   bool
-	workerCount int
+	store        JobStore
+	factories    map[string]TaskFactory
+	onSchedule   ScheduleHandler
+	policies     map[string]RetryPolicy
+	running      map[uuid.UUID]*runningTask
+	kindMetrics  map[string]*kindMetrics
+	kindLimits   map[string]int
+	activeKinds  map[string]int
+	claimMu      sync.Mutex
+	results      chan TaskResult
+	resultsDone  chan struct{}
+	wakeup       chan struct{}
+	stopChan     chan struct{}
+	wg           sync.WaitGroup
+	mu           sync.RWMutex
+	stats        TaskStats
+	isRunning    bool
+	workerCount  int
+	workerID     string
+	pollInterval time.Duration
+	lockTimeout  time.Duration
+	aging        time.Duration
+	scheduleTick time.Duration
 }
 
 // NewTaskManager создает новый менеджер задач
-func NewTaskManager(workerCount int) TaskManager {
-	if workerCount <= 0 {
-		workerCount = 1
+func NewTaskManager(store JobStore, cfg ManagerConfig) TaskManager {
+	if cfg.WorkerCount <= 0 {
+		cfg.WorkerCount = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 2 * time.Second
+	}
+	if cfg.LockTimeout < 3*time.Second {
+		cfg.LockTimeout = 3 * time.Second
+	}
+	if cfg.PriorityAging < time.Second {
+		cfg.PriorityAging = time.Minute
+	}
+	if cfg.ScheduleInterval <= 0 {
+		cfg.ScheduleInterval = 30 * time.Second
 	}
 
 	return &taskManager{
-		taskQueue:   make(chan taskItem, 1000), // буфер на 1000 задач
-		results:     make(chan TaskResult, 1000),
-		stopChan:    make(chan struct{}),
-		workerCount: workerCount,
+		store:        store,
+		factories:    make(map[string]TaskFactory),
+		policies:     make(map[string]RetryPolicy),
+		running:      make(map[uuid.UUID]*runningTask),
+		kindMetrics:  make(map[string]*kindMetrics),
+		kindLimits:   cfg.KindConcurrency,
+		activeKinds:  make(map[string]int),
+		results:      make(chan TaskResult, 1000),
+		resultsDone:  make(chan struct{}),
+		wakeup:       make(chan struct{}, 1),
+		stopChan:     make(chan struct{}),
+		workerCount:  cfg.WorkerCount,
+		workerID:     newWorkerID(),
+		pollInterval: cfg.PollInterval,
+		lockTimeout:  cfg.LockTimeout,
+		aging:        cfg.PriorityAging,
+		scheduleTick: cfg.ScheduleInterval,
 		stats: TaskStats{
 			IsRunning: false,
 		},
 	}
 }
 
-// SubmitTask добавляет задачу в очередь
-func (tm *taskManager) SubmitTask(task Task) error {
+// newWorkerID формирует идентификатор процесса для блокировки задач
+func newWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.New().String()[:8])
+}
+
+// RegisterTaskFactory регистрирует фабрику для восстановления задач указанного типа
+func (tm *taskManager) RegisterTaskFactory(kind string, factory TaskFactory) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
-	if !tm.isRunning {
-		return fmt.Errorf("task manager is not running")
+	tm.factories[kind] = factory
+}
+
+// SetRetryPolicy задает политику повтора для задач указанного типа
+func (tm *taskManager) SetRetryPolicy(kind string, policy RetryPolicy) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tm.policies[kind] = policy
+}
+
+// retryPolicy возвращает политику повтора для типа задачи
+func (tm *taskManager) retryPolicy(kind string) RetryPolicy {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	if policy, ok := tm.policies[kind]; ok {
+		return policy
+	}
+	return noRetryPolicy
+}
+
+// SubmitTask добавляет задачу в персистентную очередь
+func (tm *taskManager) SubmitTask(ctx context.Context, task Task) (string, error) {
+	return tm.SubmitDelayedTask(ctx, task, 0)
+}
+
+// SubmitDelayedTask добавляет задачу в персистентную очередь, откладывая ее запуск на delay
+// Время запуска отсчитывается по часам базы данных, как и при повторе задачи
+func (tm *taskManager) SubmitDelayedTask(ctx context.Context, task Task, delay time.Duration) (string, error) {
+	payload, err := task.GetPayload()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode task payload: %w", err)
 	}
 
-	taskItem := taskItem{
-		task:      task,
-		priority:  task.GetPriority(),
-		timestamp: time.Now(),
-		id:        uuid.New().String(),
+	job, err := tm.store.EnqueueJob(ctx, db.EnqueueJobParams{
+		ID:        uuid.New(),
+		ProjectID: task.GetProjectID(),
+		Kind:      task.GetKind(),
+		Payload:   payload,
+		Priority:  int32(task.GetPriority()),
+		DelayMs:   max(delay, 0).Milliseconds(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	tm.mu.Lock()
+	tm.stats.TotalTasks++
+	tm.mu.Unlock()
+
+	if delay > 0 {
+		log.Printf("Task %s (%s) submitted for project %d, priority: %d, delayed by %s",
+			job.ID, job.Kind, task.GetProjectID(), task.GetPriority(), delay)
+		return job.ID.String(), nil
 	}
 
+	log.Printf("Task %s (%s) submitted for project %d, priority: %d",
+		job.ID, job.Kind, task.GetProjectID(), task.GetPriority())
+
+	// Будим один из локальных воркеров, не дожидаясь следующего опроса
+	tm.notifyWorker()
+
+	return job.ID.String(), nil
+}
+
+// ResubmitJob восстанавливает задачу из записи job фабрикой ее типа и ставит ее в очередь заново
+func (tm *taskManager) ResubmitJob(ctx context.Context, job *db.Job) (string, error) {
+	tm.mu.RLock()
+	factory, ok := tm.factories[job.Kind]
+	tm.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown task kind %q", job.Kind)
+	}
+
+	task, err := factory(job)
+	if err != nil {
+		return "", fmt.Errorf("failed to restore job %s: %w", job.ID, err)
+	}
+	return tm.SubmitTask(ctx, task)
+}
+
+// notifyWorker будит один из ожидающих воркеров, не дожидаясь следующего опроса очереди
+func (tm *taskManager) notifyWorker() {
 	select {
-	case tm.taskQueue <- taskItem:
-		tm.stats.TotalTasks++
-		tm.stats.PendingTasks++
-		log.Printf("Task submitted for project %d, priority: %d",
-			task.GetProjectID(), task.GetPriority())
-		return nil
+	case tm.wakeup <- struct{}{}:
 	default:
-		return fmt.Errorf("task queue is full")
 	}
 }
 
+// CancelTask отменяет задачу в очереди и прерывает ее выполнение, если она выполняется в этом процессе
+// Задачи, выполняющиеся в других процессах, прерываются при следующем продлении блокировки
+func (tm *taskManager) CancelTask(ctx context.Context, taskID string) error {
+	jobID, err := uuid.Parse(taskID)
+	if err != nil {
+		return fmt.Errorf("invalid task ID %q: %w", taskID, err)
+	}
+
+	if _, err := tm.store.CancelJob(ctx, jobID, ErrTaskCancelled.Error()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTaskNotCancellable
+		}
+		return fmt.Errorf("failed to cancel task %s: %w", taskID, err)
+	}
+
+	tm.mu.Lock()
+	tm.stats.CancelledTasks++
+	running, ok := tm.running[jobID]
+	tm.mu.Unlock()
+
+	if ok {
+		running.cancel(ErrTaskCancelled)
+	}
+
+	log.Printf("Task %s cancelled", taskID)
+	return nil
+}
+
 // Start запускает обработчик задач
 func (tm *taskManager) Start(ctx context.Context) error {
 	tm.mu.Lock()
@@ -93,32 +270,40 @@ func (tm *taskManager) Start(ctx context.Context) error {
 		go tm.worker(ctx, i)
 	}
 
-	// Запускаем обработчик результатов
+	// Запускаем обработчик расписаний
 	tm.wg.Add(1)
-	go tm.resultHandler(ctx)
+	go tm.scheduler(ctx)
+
+	// Запускаем обработчик результатов
+	go tm.resultHandler()
 
-	log.Printf("Task manager started with %d workers", tm.workerCount)
+	log.Printf("Task manager %s started with %d workers", tm.workerID, tm.workerCount)
 	return nil
 }
 
 // Stop останавливает обработчик задач
 func (tm *taskManager) Stop(ctx context.Context) error {
 	tm.mu.Lock()
-	defer tm.mu.Unlock()
-
 	if !tm.isRunning {
+		tm.mu.Unlock()
 		return nil
 	}
+	tm.isRunning = false
+	tm.stats.IsRunning = false
+	tm.mu.Unlock()
 
 	log.Println("Stopping task manager...")
 
 	// Сигнализируем остановку
 	close(tm.stopChan)
 
-	// Ждем завершения всех воркеров
+	// Ждем завершения всех воркеров, затем сохранения оставшихся результатов
+	// Блокировку не держим: завершающиеся задачи обновляют статистику
 	done := make(chan struct{})
 	go func() {
 		tm.wg.Wait()
+		close(tm.results)
+		<-tm.resultsDone
 		close(done)
 	}()
 
@@ -129,17 +314,64 @@ func (tm *taskManager) Stop(ctx context.Context) error {
 		log.Println("Task manager stopped due to context timeout")
 	}
 
-	tm.isRunning = false
-	tm.stats.IsRunning = false
 	return nil
 }
 
 // GetStats возвращает статистику выполнения задач
 func (tm *taskManager) GetStats() TaskStats {
+	now := time.Now()
+
 	tm.mu.RLock()
-	defer tm.mu.RUnlock()
+	stats := tm.stats
+	stats.WorkerID = tm.workerID
+	stats.WorkerCount = tm.workerCount
+	stats.Running = make([]RunningTask, 0, len(tm.running))
+	for _, running := range tm.running {
+		info := running.info
+		info.ElapsedMs = now.Sub(info.StartedAt).Milliseconds()
+		stats.Running = append(stats.Running, info)
+	}
+	stats.Kinds = make(map[string]KindStats, len(tm.kindMetrics))
+	for kind, metrics := range tm.kindMetrics {
+		stats.Kinds[kind] = metrics.snapshot()
+	}
+	tm.mu.RUnlock()
+
+	// Дольше всех выполняющиеся задачи - первыми
+	sort.Slice(stats.Running, func(i, j int) bool {
+		return stats.Running[i].StartedAt.Before(stats.Running[j].StartedAt)
+	})
 
-	return tm.stats
+	// Глубину очереди берем из общей очереди всех экземпляров
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	depth, err := tm.store.GetQueueDepth(ctx)
+	if err != nil {
+		log.Printf("Failed to get queue depth: %v", err)
+	} else {
+		stats.Queue = QueueStats{
+			Ready:             depth.Ready,
+			Delayed:           depth.Delayed,
+			Running:           depth.Running,
+			Dead:              depth.Dead,
+			OldestWaitSeconds: depth.OldestWaitSeconds,
+		}
+		stats.PendingTasks = int(depth.Ready + depth.Delayed)
+	}
+
+	return stats
+}
+
+// metricsFor возвращает накопитель результатов для типа задачи
+// Вызывается под tm.mu
+func (tm *taskManager) metricsFor(kind string) *kindMetrics {
+	metrics, ok := tm.kindMetrics[kind]
+	if !ok {
+		metrics = &kindMetrics{}
+		tm.kindMetrics[kind] = metrics
+	}
+	return metrics
 }
 
 // worker основной воркер для выполнения задач
@@ -156,8 +388,140 @@ func (tm *taskManager) worker(ctx context.Context, workerID int) {
 		case <-tm.stopChan:
 			log.Printf("Worker %d stopped due to stop signal", workerID)
 			return
-		case taskItem := <-tm.taskQueue:
-			tm.executeTask(ctx, taskItem, workerID)
+		default:
+		}
+
+		item, err := tm.claimTask(ctx)
+		if err != nil {
+			log.Printf("Worker %d failed to claim task: %v", workerID, err)
+		}
+		if item != nil {
+			tm.executeTask(ctx, *item, workerID)
+			continue
+		}
+
+		// Очередь пуста - ждем новую задачу или следующего опроса
+		select {
+		case <-ctx.Done():
+			log.Printf("Worker %d stopped due to context cancellation", workerID)
+			return
+		case <-tm.stopChan:
+			log.Printf("Worker %d stopped due to stop signal", workerID)
+			return
+		case <-tm.wakeup:
+		case <-time.After(tm.pollInterval):
+		}
+	}
+}
+
+// saturatedKinds возвращает типы задач, достигшие ограничения одновременного выполнения
+func (tm *taskManager) saturatedKinds() []string {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	kinds := []string{}
+	for kind, limit := range tm.kindLimits {
+		if limit > 0 && tm.activeKinds[kind] >= limit {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// claimTask захватывает задачу из очереди и восстанавливает ее через фабрику
+// Возвращает nil без ошибки, если готовых задач нет
+func (tm *taskManager) claimTask(ctx context.Context) (*taskItem, error) {
+	// Захват сериализуется внутри процесса, чтобы воркеры не превысили ограничение по типу задачи
+	tm.claimMu.Lock()
+	defer tm.claimMu.Unlock()
+
+	job, err := tm.store.ClaimJob(ctx, tm.workerID, tm.lockTimeout, tm.aging, tm.saturatedKinds())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tm.mu.RLock()
+	factory, ok := tm.factories[job.Kind]
+	tm.mu.RUnlock()
+
+	var task Task
+	if !ok {
+		err = fmt.Errorf("unknown task kind %q", job.Kind)
+	} else {
+		task, err = factory(job)
+	}
+
+	if err != nil {
+		// Задача не может быть выполнена - помечаем ее как проваленную,
+		// иначе она будет захватываться повторно после истечения блокировки
+		err = fmt.Errorf("failed to restore job %s: %w", job.ID, err)
+		now := time.Now()
+		if failErr := tm.store.FailJob(ctx, job.ID, tm.workerID, err.Error()); failErr != nil {
+			log.Printf("Failed to mark job %s as failed: %v", job.ID, failErr)
+		}
+		tm.mu.Lock()
+		tm.stats.FailedTasks++
+		tm.metricsFor(job.Kind).failed++
+		tm.mu.Unlock()
+		tm.publishResult(TaskResult{
+			TaskID:     job.ID.String(),
+			ProjectID:  job.ProjectID,
+			Kind:       job.Kind,
+			Attempt:    job.Attempts,
+			StartedAt:  now,
+			FinishedAt: now,
+			Success:    false,
+			Error:      err,
+		})
+		return nil, err
+	}
+
+	tm.mu.Lock()
+	tm.activeKinds[job.Kind]++
+	tm.mu.Unlock()
+
+	return &taskItem{
+		task:      task,
+		priority:  task.GetPriority(),
+		timestamp: job.CreatedAt,
+		id:        job.ID.String(),
+		jobID:     job.ID,
+		kind:      job.Kind,
+		attempt:   job.Attempts,
+	}, nil
+}
+
+// heartbeat продлевает блокировку задачи, пока она выполняется
+// Если блокировку продлить не удалось (задача отменена или захвачена другим воркером), выполнение прерывается
+func (tm *taskManager) heartbeat(ctx context.Context, jobID uuid.UUID, cancelTask context.CancelCauseFunc) {
+	ticker := time.NewTicker(tm.lockTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := tm.store.ExtendJobLock(ctx, jobID, tm.workerID, tm.lockTimeout)
+			if err != nil {
+				log.Printf("Failed to extend lock for job %s: %v", jobID, err)
+				continue
+			}
+			if ok {
+				continue
+			}
+
+			cause := errJobLockLost
+			if job, err := tm.store.GetJob(ctx, jobID); err == nil && job.State == db.JobStateCancelled {
+				cause = ErrTaskCancelled
+			}
+			log.Printf("Lock for job %s was lost: %v", jobID, cause)
+			cancelTask(cause)
+			return
 		}
 	}
 }
@@ -166,65 +530,198 @@ func (tm *taskManager) worker(ctx context.Context, workerID int) {
 func (tm *taskManager) executeTask(ctx context.Context, taskItem taskItem, workerID int) {
 	startTime := time.Now()
 
-	log.Printf("Worker %d executing task for project %d",
-		workerID, taskItem.task.GetProjectID())
+	log.Printf("Worker %d executing task %s for project %d",
+		workerID, taskItem.id, taskItem.task.GetProjectID())
 
-	// Выполняем задачу
-	err := taskItem.task.Execute(ctx)
+	// Контекст задачи отменяется через CancelTask или при потере блокировки
+	taskCtx, cancelTask := context.WithCancelCause(ctx)
+	defer cancelTask(nil)
+
+	tm.mu.Lock()
+	tm.running[taskItem.jobID] = &runningTask{
+		info: RunningTask{
+			JobID:     taskItem.id,
+			ProjectID: taskItem.task.GetProjectID(),
+			Kind:      taskItem.kind,
+			Attempt:   taskItem.attempt,
+			Worker:    workerID,
+			StartedAt: startTime,
+		},
+		cancel: cancelTask,
+	}
+	tm.mu.Unlock()
+
+	// Поддерживаем блокировку задачи на время выполнения
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	go tm.heartbeat(heartbeatCtx, taskItem.jobID, cancelTask)
 
-	duration := time.Since(startTime).Milliseconds()
+	// Выполняем задачу
+	err := taskItem.task.Execute(taskCtx)
+	stopHeartbeat()
 
-	// Обновляем статистику
 	tm.mu.Lock()
-	tm.stats.PendingTasks--
-	if err != nil {
-		tm.stats.FailedTasks++
-		log.Printf("Worker %d failed task for project %d: %v",
-			workerID, taskItem.task.GetProjectID(), err)
-	} else {
+	delete(tm.running, taskItem.jobID)
+	tm.activeKinds[taskItem.kind]--
+	tm.mu.Unlock()
+
+	// Освободилось место для задач этого типа и проекта - будим ожидающий воркер
+	tm.notifyWorker()
+
+	finishTime := time.Now()
+	duration := finishTime.Sub(startTime).Milliseconds()
+
+	// Если задача прервана, ее состояние в очереди уже изменено - фиксируем только причину
+	interrupted := false
+	if cause := context.Cause(taskCtx); err != nil && (errors.Is(cause, ErrTaskCancelled) || errors.Is(cause, errJobLockLost)) {
+		interrupted = true
+		err = cause
+	}
+
+	// Длительность прерванных задач не показательна и в перцентили не попадает
+	if !interrupted {
+		tm.mu.Lock()
+		tm.metricsFor(taskItem.kind).observe(duration)
+		tm.mu.Unlock()
+	}
+
+	// Фиксируем результат в очереди и обновляем статистику
+	// Отмененные задачи учитываются в статистике при вызове CancelTask
+	switch {
+	case interrupted:
+		log.Printf("Worker %d interrupted task %s for project %d: %v",
+			workerID, taskItem.id, taskItem.task.GetProjectID(), err)
+	case err != nil:
+		tm.handleFailure(ctx, taskItem, err, workerID)
+	default:
+		if storeErr := tm.store.CompleteJob(ctx, taskItem.jobID, tm.workerID); storeErr != nil {
+			log.Printf("Failed to mark job %s as completed: %v", taskItem.id, storeErr)
+		}
+		tm.mu.Lock()
 		tm.stats.CompletedTasks++
+		tm.metricsFor(taskItem.kind).completed++
+		tm.mu.Unlock()
 		log.Printf("Worker %d completed task for project %d in %dms",
 			workerID, taskItem.task.GetProjectID(), duration)
 	}
-	tm.mu.Unlock()
 
 	// Отправляем результат
-	result := TaskResult{
-		TaskID:    taskItem.id,
-		ProjectID: taskItem.task.GetProjectID(),
-		Success:   err == nil,
-		Error:     err,
-		Duration:  duration,
+	tm.publishResult(TaskResult{
+		TaskID:     taskItem.id,
+		ProjectID:  taskItem.task.GetProjectID(),
+		Kind:       taskItem.kind,
+		Attempt:    taskItem.attempt,
+		StartedAt:  startTime,
+		FinishedAt: finishTime,
+		Success:    err == nil,
+		Error:      err,
+		Duration:   duration,
+	})
+}
+
+// handleFailure возвращает проваленную задачу в очередь по политике повтора,
+// либо помечает ее как проваленную или исчерпавшую попытки (dead-letter)
+func (tm *taskManager) handleFailure(ctx context.Context, taskItem taskItem, err error, workerID int) {
+	policy := tm.retryPolicy(taskItem.kind)
+	retryable := policy.isRetryableError(err)
+
+	if retryable && int(taskItem.attempt) < policy.MaxAttempts {
+		delay := policy.backoff(taskItem.attempt)
+		if storeErr := tm.store.RetryJob(ctx, taskItem.jobID, tm.workerID, err.Error(), delay); storeErr != nil {
+			log.Printf("Failed to schedule retry for job %s: %v", taskItem.id, storeErr)
+		}
+		tm.mu.Lock()
+		tm.stats.RetriedTasks++
+		tm.metricsFor(taskItem.kind).retried++
+		tm.mu.Unlock()
+		log.Printf("Worker %d failed task %s (attempt %d/%d), retrying in %s: %v",
+			workerID, taskItem.id, taskItem.attempt, policy.MaxAttempts, delay, err)
+		return
+	}
+
+	if retryable && policy.MaxAttempts > 1 {
+		if storeErr := tm.store.MarkJobDead(ctx, taskItem.jobID, tm.workerID, err.Error()); storeErr != nil {
+			log.Printf("Failed to mark job %s as dead: %v", taskItem.id, storeErr)
+		}
+		tm.mu.Lock()
+		tm.stats.DeadTasks++
+		tm.metricsFor(taskItem.kind).dead++
+		tm.mu.Unlock()
+		log.Printf("Worker %d moved task %s to dead-letter after %d attempts: %v",
+			workerID, taskItem.id, taskItem.attempt, err)
+	} else {
+		if storeErr := tm.store.FailJob(ctx, taskItem.jobID, tm.workerID, err.Error()); storeErr != nil {
+			log.Printf("Failed to mark job %s as failed: %v", taskItem.id, storeErr)
+		}
+		tm.mu.Lock()
+		tm.stats.FailedTasks++
+		tm.metricsFor(taskItem.kind).failed++
+		tm.mu.Unlock()
+		log.Printf("Worker %d failed task for project %d: %v",
+			workerID, taskItem.task.GetProjectID(), err)
 	}
 
+	// Задача провалилась окончательно - даем ей освободить ресурсы
+	if handler, ok := taskItem.task.(FailureHandler); ok {
+		handler.OnFailure(ctx, err)
+	}
+}
+
+// publishResult передает результат обработчику, а при переполненном канале сохраняет его сразу
+func (tm *taskManager) publishResult(result TaskResult) {
 	select {
 	case tm.results <- result:
 	default:
-		log.Printf("Warning: results channel is full, dropping result for task %s", taskItem.id)
+		log.Printf("Warning: results channel is full, saving result for task %s synchronously", result.TaskID)
+		tm.saveResult(result)
 	}
 }
 
-// resultHandler обрабатывает результаты выполнения задач
-func (tm *taskManager) resultHandler(ctx context.Context) {
-	defer tm.wg.Done()
+// resultHandler обрабатывает результаты выполнения задач до закрытия канала результатов
+func (tm *taskManager) resultHandler() {
+	defer close(tm.resultsDone)
 
 	log.Println("Result handler started")
 
-	for {
-		select {
-		case <-ctx.Done():
-			log.Println("Result handler stopped due to context cancellation")
-			return
-		case <-tm.stopChan:
-			log.Println("Result handler stopped due to stop signal")
-			return
-		case result := <-tm.results:
-			// Здесь можно добавить дополнительную обработку результатов
-			// например, сохранение в БД, отправка уведомлений и т.д.
-			if !result.Success {
-				log.Printf("Task %s failed for project %d: %v",
-					result.TaskID, result.ProjectID, result.Error)
-			}
+	for result := range tm.results {
+		if !result.Success {
+			log.Printf("Task %s failed for project %d: %v",
+				result.TaskID, result.ProjectID, result.Error)
 		}
+		tm.saveResult(result)
+	}
+
+	log.Println("Result handler stopped")
+}
+
+// saveResult сохраняет результат выполнения задачи в историю
+func (tm *taskManager) saveResult(result TaskResult) {
+	jobID, err := uuid.Parse(result.TaskID)
+	if err != nil {
+		log.Printf("Invalid task ID %s in result: %v", result.TaskID, err)
+		return
+	}
+
+	var errText sql.NullString
+	if result.Error != nil {
+		errText = sql.NullString{String: result.Error.Error(), Valid: true}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = tm.store.RecordJobRun(ctx, db.CreateJobRunParams{
+		JobID:      jobID,
+		ProjectID:  result.ProjectID,
+		Kind:       result.Kind,
+		Attempt:    result.Attempt,
+		WorkerID:   tm.workerID,
+		StartedAt:  result.StartedAt,
+		FinishedAt: result.FinishedAt,
+		DurationMs: result.Duration,
+		Success:    result.Success,
+		Error:      errText,
+	})
+	if err != nil {
+		log.Printf("Failed to save result for task %s: %v", result.TaskID, err)
 	}
 }
@@ -0,0 +1,948 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	db "evaluation/internal/postgres/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeJobStore - in-memory реализация JobStore для тестирования
+type fakeJobStore struct {
+	mu        sync.Mutex
+	jobs      map[uuid.UUID]*db.Job
+	runs      []db.CreateJobRunParams
+	schedules map[int32]*db.JobSchedule
+}
+
+func newFakeJobStore() *fakeJobStore {
+	return &fakeJobStore{
+		jobs:      make(map[uuid.UUID]*db.Job),
+		schedules: make(map[int32]*db.JobSchedule),
+	}
+}
+
+func (s *fakeJobStore) EnqueueJob(ctx context.Context, arg db.EnqueueJobParams) (*db.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	job := &db.Job{
+		ID:        arg.ID,
+		ProjectID: arg.ProjectID,
+		Kind:      arg.Kind,
+		Payload:   arg.Payload,
+		Priority:  arg.Priority,
+		State:     db.JobStateQueued,
+		RunAfter:  now.Add(time.Duration(arg.DelayMs) * time.Millisecond),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.jobs[job.ID] = job
+	copied := *job
+	return &copied, nil
+}
+
+func (s *fakeJobStore) ClaimJob(ctx context.Context, workerID string, lockTimeout, agingInterval time.Duration, excludedKinds []string) (*db.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var candidates []*db.Job
+	for _, job := range s.jobs {
+		ready := job.State == db.JobStateQueued && !job.RunAfter.After(now)
+		expired := job.State == db.JobStateRunning && job.LockedUntil.Valid && job.LockedUntil.Time.Before(now)
+		if (ready || expired) && !slices.Contains(excludedKinds, job.Kind) && !s.pipelineBusy(job) {
+			candidates = append(candidates, job)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	// Как и в запросе ClaimJob: приоритет повышается по мере ожидания, при равенстве - порядок постановки
+	effective := func(job *db.Job) int64 {
+		return int64(job.Priority) - int64(now.Sub(job.RunAfter)/agingInterval)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		pi, pj := effective(candidates[i]), effective(candidates[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return candidates[i].CreatedAt.Before(candidates[j].CreatedAt)
+	})
+
+	job := candidates[0]
+	job.State = db.JobStateRunning
+	job.Attempts++
+	job.LockedBy = sql.NullString{String: workerID, Valid: true}
+	job.LockedUntil = sql.NullTime{Time: now.Add(lockTimeout), Valid: true}
+	copied := *job
+	return &copied, nil
+}
+
+// pipelineBusy сообщает, выполняется ли у проекта другая задача того же типа
+func (s *fakeJobStore) pipelineBusy(candidate *db.Job) bool {
+	for _, job := range s.jobs {
+		if job.ProjectID == candidate.ProjectID && job.Kind == candidate.Kind && job.ID != candidate.ID && job.State == db.JobStateRunning {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeJobStore) ExtendJobLock(ctx context.Context, jobID uuid.UUID, workerID string, lockTimeout time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok || job.State != db.JobStateRunning || job.LockedBy.String != workerID {
+		return false, nil
+	}
+	job.LockedUntil = sql.NullTime{Time: time.Now().Add(lockTimeout), Valid: true}
+	return true, nil
+}
+
+func (s *fakeJobStore) finish(jobID uuid.UUID, workerID string, state db.JobState, errText string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok || job.State != db.JobStateRunning || job.LockedBy.String != workerID {
+		return nil
+	}
+	job.State = state
+	job.LockedBy = sql.NullString{}
+	job.LockedUntil = sql.NullTime{}
+	job.LastError = sql.NullString{String: errText, Valid: errText != ""}
+	job.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func (s *fakeJobStore) CompleteJob(ctx context.Context, jobID uuid.UUID, workerID string) error {
+	return s.finish(jobID, workerID, db.JobStateCompleted, "")
+}
+
+func (s *fakeJobStore) FailJob(ctx context.Context, jobID uuid.UUID, workerID string, errText string) error {
+	return s.finish(jobID, workerID, db.JobStateFailed, errText)
+}
+
+func (s *fakeJobStore) RetryJob(ctx context.Context, jobID uuid.UUID, workerID string, errText string, delay time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok || job.State != db.JobStateRunning || job.LockedBy.String != workerID {
+		return nil
+	}
+	job.State = db.JobStateQueued
+	job.LockedBy = sql.NullString{}
+	job.LockedUntil = sql.NullTime{}
+	job.LastError = sql.NullString{String: errText, Valid: true}
+	job.RunAfter = time.Now().Add(delay)
+	return nil
+}
+
+func (s *fakeJobStore) MarkJobDead(ctx context.Context, jobID uuid.UUID, workerID string, errText string) error {
+	return s.finish(jobID, workerID, db.JobStateDead, errText)
+}
+
+func (s *fakeJobStore) CancelJob(ctx context.Context, jobID uuid.UUID, reason string) (*db.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok || (job.State != db.JobStateQueued && job.State != db.JobStateRunning) {
+		return nil, sql.ErrNoRows
+	}
+	job.State = db.JobStateCancelled
+	job.LockedBy = sql.NullString{}
+	job.LockedUntil = sql.NullTime{}
+	job.LastError = sql.NullString{String: reason, Valid: true}
+	copied := *job
+	return &copied, nil
+}
+
+func (s *fakeJobStore) GetJob(ctx context.Context, jobID uuid.UUID) (*db.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (s *fakeJobStore) RecordJobRun(ctx context.Context, arg db.CreateJobRunParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.runs = append(s.runs, arg)
+	return nil
+}
+
+func (s *fakeJobStore) GetQueueDepth(ctx context.Context) (*db.GetQueueDepthRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	depth := &db.GetQueueDepthRow{}
+	for _, job := range s.jobs {
+		switch {
+		case job.State == db.JobStateQueued && !job.RunAfter.After(now):
+			depth.Ready++
+			depth.OldestWaitSeconds = max(depth.OldestWaitSeconds, now.Sub(job.RunAfter).Seconds())
+		case job.State == db.JobStateQueued:
+			depth.Delayed++
+		case job.State == db.JobStateRunning:
+			depth.Running++
+		case job.State == db.JobStateDead:
+			depth.Dead++
+		}
+	}
+	return depth, nil
+}
+
+func (s *fakeJobStore) ListDueSchedules(ctx context.Context) ([]db.JobSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var due []db.JobSchedule
+	for _, schedule := range s.schedules {
+		if !schedule.NextRunAt.After(now) {
+			due = append(due, *schedule)
+		}
+	}
+	return due, nil
+}
+
+func (s *fakeJobStore) AdvanceSchedule(ctx context.Context, scheduleID int32, expectedRunAt, nextRunAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[scheduleID]
+	if !ok || !schedule.NextRunAt.Equal(expectedRunAt) {
+		return false, nil
+	}
+	schedule.NextRunAt = nextRunAt
+	schedule.LastRunAt = sql.NullTime{Time: time.Now(), Valid: true}
+	return true, nil
+}
+
+// addSchedule добавляет расписание, время запуска которого уже наступило
+func (s *fakeJobStore) addSchedule(id, projectID int32, kind, cronExpr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.schedules[id] = &db.JobSchedule{
+		ID:        id,
+		ProjectID: projectID,
+		Kind:      kind,
+		CronExpr:  cronExpr,
+		NextRunAt: time.Now().Add(-time.Minute),
+	}
+}
+
+// schedule возвращает копию расписания
+func (s *fakeJobStore) schedule(id int32) db.JobSchedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return *s.schedules[id]
+}
+
+// jobsByKind возвращает количество задач указанного типа
+func (s *fakeJobStore) jobsByKind(kind string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, job := range s.jobs {
+		if job.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *fakeJobStore) jobRuns(jobID string) []db.CreateJobRunParams {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var runs []db.CreateJobRunParams
+	for _, run := range s.runs {
+		if run.JobID.String() == jobID {
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
+
+func (s *fakeJobStore) state(jobID string) db.JobState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[uuid.MustParse(jobID)]
+	if !ok {
+		return ""
+	}
+	return job.State
+}
+
+// fakeTask - тестовая задача
+type fakeTask struct {
+	projectID int32
+	kind      string
+	priority  int
+	Message   string `json:"message"`
+	err       error
+	executed  chan string
+}
+
+func (t *fakeTask) Execute(ctx context.Context) error {
+	if t.executed != nil {
+		t.executed <- t.Message
+	}
+	return t.err
+}
+
+func (t *fakeTask) GetProjectID() int32 { return t.projectID }
+func (t *fakeTask) GetPriority() int    { return t.priority }
+func (t *fakeTask) GetKind() string     { return t.kind }
+
+func (t *fakeTask) GetPayload() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// blockingTask - тестовая задача, выполняющаяся до отмены контекста
+type blockingTask struct {
+	fakeTask
+	started chan struct{}
+}
+
+func (t *blockingTask) Execute(ctx context.Context) error {
+	t.started <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// blockingTaskFactory восстанавливает blockingTask из записи очереди
+func blockingTaskFactory(started chan struct{}) TaskFactory {
+	return func(job *db.Job) (Task, error) {
+		return &blockingTask{fakeTask: fakeTask{projectID: job.ProjectID, kind: job.Kind}, started: started}, nil
+	}
+}
+
+// flakyTask - тестовая задача, проваливающаяся первые failTimes попыток
+type flakyTask struct {
+	fakeTask
+	attempts  *int
+	failTimes int
+	failErr   error
+	failures  chan error
+}
+
+func (t *flakyTask) Execute(ctx context.Context) error {
+	*t.attempts++
+	if *t.attempts <= t.failTimes {
+		return t.failErr
+	}
+	return nil
+}
+
+func (t *flakyTask) OnFailure(ctx context.Context, err error) {
+	t.failures <- err
+}
+
+// flakyTaskFactory восстанавливает flakyTask, разделяя между попытками счетчик запусков
+func flakyTaskFactory(failTimes int, failErr error, failures chan error) TaskFactory {
+	attempts := 0
+	return func(job *db.Job) (Task, error) {
+		return &flakyTask{
+			fakeTask:  fakeTask{projectID: job.ProjectID, kind: job.Kind},
+			attempts:  &attempts,
+			failTimes: failTimes,
+			failErr:   failErr,
+			failures:  failures,
+		}, nil
+	}
+}
+
+// fakeTaskFactory восстанавливает fakeTask из записи очереди
+func fakeTaskFactory(executed chan string, err error) TaskFactory {
+	return func(job *db.Job) (Task, error) {
+		task := &fakeTask{projectID: job.ProjectID, kind: job.Kind, err: err, executed: executed}
+		if err := json.Unmarshal(job.Payload, task); err != nil {
+			return nil, err
+		}
+		return task, nil
+	}
+}
+
+func waitForState(t *testing.T, store *fakeJobStore, jobID string, state db.JobState) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		return store.state(jobID) == state
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func newTestManager(store JobStore) TaskManager {
+	return NewTaskManager(store, ManagerConfig{
+		WorkerCount:  1,
+		PollInterval: 20 * time.Millisecond,
+		LockTimeout:  3 * time.Second,
+	})
+}
+
+func TestTaskManager_ExecutesPersistedTask(t *testing.T) {
+	store := newFakeJobStore()
+	executed := make(chan string, 1)
+
+	tm := newTestManager(store)
+	tm.RegisterTaskFactory("test", fakeTaskFactory(executed, nil))
+
+	// Задача ставится в очередь до запуска менеджера и должна быть выполнена после старта
+	jobID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 7, kind: "test", Message: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, db.JobStateQueued, store.state(jobID))
+
+	require.NoError(t, tm.Start(context.Background()))
+	defer tm.Stop(context.Background())
+
+	select {
+	case msg := <-executed:
+		assert.Equal(t, "hello", msg)
+	case <-time.After(2 * time.Second):
+		t.Fatal("task was not executed")
+	}
+
+	waitForState(t, store, jobID, db.JobStateCompleted)
+}
+
+func TestTaskManager_ExecutesHigherPriorityFirst(t *testing.T) {
+	store := newFakeJobStore()
+	executed := make(chan string, 3)
+
+	tm := newTestManager(store)
+	tm.RegisterTaskFactory("test", fakeTaskFactory(executed, nil))
+
+	// Задачи ставятся до запуска менеджера, чтобы воркер выбирал из всей очереди
+	for _, task := range []*fakeTask{
+		{projectID: 1, kind: "test", priority: PriorityLow, Message: "low"},
+		{projectID: 2, kind: "test", priority: PriorityNormal, Message: "normal-1"},
+		{projectID: 3, kind: "test", priority: PriorityHigh, Message: "high"},
+		{projectID: 4, kind: "test", priority: PriorityNormal, Message: "normal-2"},
+	} {
+		_, err := tm.SubmitTask(context.Background(), task)
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+	}
+
+	require.NoError(t, tm.Start(context.Background()))
+	defer tm.Stop(context.Background())
+
+	var order []string
+	for range 4 {
+		select {
+		case msg := <-executed:
+			order = append(order, msg)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("tasks were not executed, got %v", order)
+		}
+	}
+	assert.Equal(t, []string{"high", "normal-1", "normal-2", "low"}, order)
+}
+
+func TestTaskManager_FailedTask(t *testing.T) {
+	store := newFakeJobStore()
+
+	tm := newTestManager(store)
+	tm.RegisterTaskFactory("test", fakeTaskFactory(nil, errors.New("boom")))
+	require.NoError(t, tm.Start(context.Background()))
+	defer tm.Stop(context.Background())
+
+	jobID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 1, kind: "test"})
+	require.NoError(t, err)
+
+	waitForState(t, store, jobID, db.JobStateFailed)
+	assert.Eventually(t, func() bool {
+		return tm.GetStats().FailedTasks == 1
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestTaskManager_UnknownKind(t *testing.T) {
+	store := newFakeJobStore()
+
+	tm := newTestManager(store)
+	require.NoError(t, tm.Start(context.Background()))
+	defer tm.Stop(context.Background())
+
+	jobID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 1, kind: "missing"})
+	require.NoError(t, err)
+
+	waitForState(t, store, jobID, db.JobStateFailed)
+}
+
+func TestTaskManager_ResubmitJob(t *testing.T) {
+	store := newFakeJobStore()
+	tm := newTestManager(store)
+	tm.RegisterTaskFactory("test", func(job *db.Job) (Task, error) {
+		task := &fakeTask{projectID: job.ProjectID, kind: job.Kind, priority: int(job.Priority)}
+		return task, json.Unmarshal(job.Payload, task)
+	})
+
+	// Новая задача сохраняет параметры и приоритет исходной записи
+	original := &db.Job{ID: uuid.New(), ProjectID: 3, Kind: "test", Payload: []byte(`{"message":"docx"}`), Priority: PriorityLow}
+	jobID, err := tm.ResubmitJob(context.Background(), original)
+	require.NoError(t, err)
+
+	resubmitted := store.jobs[uuid.MustParse(jobID)]
+	assert.NotEqual(t, original.ID, resubmitted.ID)
+	assert.JSONEq(t, `{"message":"docx"}`, string(resubmitted.Payload))
+	assert.Equal(t, int32(PriorityLow), resubmitted.Priority)
+
+	_, err = tm.ResubmitJob(context.Background(), &db.Job{ID: uuid.New(), ProjectID: 3, Kind: "missing"})
+	assert.Error(t, err)
+}
+
+func TestTaskManager_RecordsJobRuns(t *testing.T) {
+	store := newFakeJobStore()
+
+	tm := newTestManager(store)
+	tm.RegisterTaskFactory("ok", fakeTaskFactory(nil, nil))
+	tm.RegisterTaskFactory("bad", fakeTaskFactory(nil, errors.New("boom")))
+	require.NoError(t, tm.Start(context.Background()))
+
+	okID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 3, kind: "ok"})
+	require.NoError(t, err)
+	badID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 3, kind: "bad"})
+	require.NoError(t, err)
+
+	waitForState(t, store, okID, db.JobStateCompleted)
+	waitForState(t, store, badID, db.JobStateFailed)
+
+	// Stop дожидается сохранения всех результатов
+	require.NoError(t, tm.Stop(context.Background()))
+
+	okRuns := store.jobRuns(okID)
+	require.Len(t, okRuns, 1)
+	assert.True(t, okRuns[0].Success)
+	assert.Equal(t, "ok", okRuns[0].Kind)
+	assert.Equal(t, int32(1), okRuns[0].Attempt)
+	assert.False(t, okRuns[0].Error.Valid)
+
+	badRuns := store.jobRuns(badID)
+	require.Len(t, badRuns, 1)
+	assert.False(t, badRuns[0].Success)
+	assert.Equal(t, "boom", badRuns[0].Error.String)
+	assert.Equal(t, int32(3), badRuns[0].ProjectID)
+}
+
+func TestTaskManager_CancelRunningTask(t *testing.T) {
+	store := newFakeJobStore()
+	started := make(chan struct{})
+
+	tm := newTestManager(store)
+	tm.RegisterTaskFactory("block", blockingTaskFactory(started))
+	require.NoError(t, tm.Start(context.Background()))
+
+	jobID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 5, kind: "block"})
+	require.NoError(t, err)
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("task was not started")
+	}
+
+	require.NoError(t, tm.CancelTask(context.Background(), jobID))
+	require.NoError(t, tm.Stop(context.Background()))
+
+	// Отмененная задача не переводится в failed
+	assert.Equal(t, db.JobStateCancelled, store.state(jobID))
+	assert.Equal(t, int64(1), tm.GetStats().CancelledTasks)
+	assert.Equal(t, int64(0), tm.GetStats().FailedTasks)
+
+	runs := store.jobRuns(jobID)
+	require.Len(t, runs, 1)
+	assert.False(t, runs[0].Success)
+	assert.Equal(t, ErrTaskCancelled.Error(), runs[0].Error.String)
+
+	// Завершенную задачу отменить нельзя
+	assert.ErrorIs(t, tm.CancelTask(context.Background(), jobID), ErrTaskNotCancellable)
+}
+
+func TestTaskManager_CancelledInAnotherProcess(t *testing.T) {
+	store := newFakeJobStore()
+	started := make(chan struct{})
+
+	tm := newTestManager(store)
+	tm.RegisterTaskFactory("block", blockingTaskFactory(started))
+	require.NoError(t, tm.Start(context.Background()))
+	defer tm.Stop(context.Background())
+
+	jobID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 5, kind: "block"})
+	require.NoError(t, err)
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("task was not started")
+	}
+
+	// Отмена только в хранилище - воркер узнает о ней при продлении блокировки
+	_, err = store.CancelJob(context.Background(), uuid.MustParse(jobID), ErrTaskCancelled.Error())
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		runs := store.jobRuns(jobID)
+		return len(runs) == 1 && runs[0].Error.String == ErrTaskCancelled.Error()
+	}, 3*time.Second, 20*time.Millisecond)
+	assert.Equal(t, db.JobStateCancelled, store.state(jobID))
+}
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 10 * time.Millisecond,
+	MaxBackoff:     50 * time.Millisecond,
+	Multiplier:     2,
+}
+
+func TestTaskManager_RetriesTransientError(t *testing.T) {
+	store := newFakeJobStore()
+	failures := make(chan error, 1)
+
+	tm := newTestManager(store)
+	tm.RegisterTaskFactory("flaky", flakyTaskFactory(2, Retryable(errors.New("service unavailable")), failures))
+	tm.SetRetryPolicy("flaky", testRetryPolicy)
+	require.NoError(t, tm.Start(context.Background()))
+
+	jobID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 1, kind: "flaky"})
+	require.NoError(t, err)
+
+	waitForState(t, store, jobID, db.JobStateCompleted)
+	require.NoError(t, tm.Stop(context.Background()))
+
+	// Две неудачные попытки и одна успешная
+	runs := store.jobRuns(jobID)
+	require.Len(t, runs, 3)
+	assert.False(t, runs[0].Success)
+	assert.False(t, runs[1].Success)
+	assert.True(t, runs[2].Success)
+	assert.Equal(t, int32(3), runs[2].Attempt)
+	assert.Equal(t, int64(2), tm.GetStats().RetriedTasks)
+	assert.Empty(t, failures, "OnFailure must not be called for a task that eventually succeeded")
+}
+
+func TestTaskManager_MovesExhaustedTaskToDeadLetter(t *testing.T) {
+	store := newFakeJobStore()
+	failures := make(chan error, 1)
+
+	tm := newTestManager(store)
+	tm.RegisterTaskFactory("flaky", flakyTaskFactory(10, Retryable(errors.New("service unavailable")), failures))
+	tm.SetRetryPolicy("flaky", testRetryPolicy)
+	require.NoError(t, tm.Start(context.Background()))
+	defer tm.Stop(context.Background())
+
+	jobID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 1, kind: "flaky"})
+	require.NoError(t, err)
+
+	waitForState(t, store, jobID, db.JobStateDead)
+	select {
+	case err := <-failures:
+		assert.EqualError(t, err, "service unavailable")
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnFailure was not called")
+	}
+	assert.Len(t, store.jobRuns(jobID), 3)
+	assert.Equal(t, int64(1), tm.GetStats().DeadTasks)
+}
+
+func TestTaskManager_DoesNotRetryPermanentError(t *testing.T) {
+	store := newFakeJobStore()
+	failures := make(chan error, 1)
+
+	tm := newTestManager(store)
+	tm.RegisterTaskFactory("flaky", flakyTaskFactory(10, errors.New("invalid file"), failures))
+	tm.SetRetryPolicy("flaky", testRetryPolicy)
+	require.NoError(t, tm.Start(context.Background()))
+	defer tm.Stop(context.Background())
+
+	jobID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 1, kind: "flaky"})
+	require.NoError(t, err)
+
+	waitForState(t, store, jobID, db.JobStateFailed)
+	select {
+	case <-failures:
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnFailure was not called")
+	}
+	assert.Len(t, store.jobRuns(jobID), 1)
+}
+
+// waitStarted ждет запуска blockingTask
+func waitStarted(t *testing.T, started chan struct{}) {
+	t.Helper()
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("task was not started")
+	}
+}
+
+// assertNotStarted проверяет, что blockingTask не запускается
+func assertNotStarted(t *testing.T, started chan struct{}) {
+	t.Helper()
+	select {
+	case <-started:
+		t.Fatal("task was started concurrently")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestTaskManager_OneRunningTaskPerPipeline(t *testing.T) {
+	store := newFakeJobStore()
+	started := make(chan struct{}, 3)
+
+	tm := NewTaskManager(store, ManagerConfig{
+		WorkerCount:  3,
+		PollInterval: 20 * time.Millisecond,
+		LockTimeout:  3 * time.Second,
+	})
+	tm.RegisterTaskFactory("block", blockingTaskFactory(started))
+	tm.RegisterTaskFactory("block_other", blockingTaskFactory(started))
+	require.NoError(t, tm.Start(context.Background()))
+	defer tm.Stop(context.Background())
+
+	firstID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 1, kind: "block", priority: 10})
+	require.NoError(t, err)
+	waitStarted(t, started)
+
+	// Вторая задача того же типа у того же проекта ждет, хотя свободный воркер есть
+	secondID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 1, kind: "block"})
+	require.NoError(t, err)
+	assertNotStarted(t, started)
+	assert.Equal(t, db.JobStateQueued, store.state(secondID))
+
+	// Задача другого типа того же проекта выполняется параллельно
+	pipelineID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 1, kind: "block_other"})
+	require.NoError(t, err)
+	waitStarted(t, started)
+	assert.Equal(t, db.JobStateRunning, store.state(pipelineID))
+
+	// Задача того же типа другого проекта тоже выполняется параллельно
+	otherID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 2, kind: "block"})
+	require.NoError(t, err)
+	waitStarted(t, started)
+	assert.Equal(t, db.JobStateRunning, store.state(otherID))
+
+	require.NoError(t, tm.CancelTask(context.Background(), firstID))
+	require.NoError(t, tm.CancelTask(context.Background(), pipelineID))
+	require.NoError(t, tm.CancelTask(context.Background(), otherID))
+	waitStarted(t, started)
+	waitForState(t, store, secondID, db.JobStateRunning)
+	require.NoError(t, tm.CancelTask(context.Background(), secondID))
+}
+
+func TestTaskManager_KindConcurrencyLimit(t *testing.T) {
+	store := newFakeJobStore()
+	started := make(chan struct{}, 2)
+	executed := make(chan string, 1)
+
+	tm := NewTaskManager(store, ManagerConfig{
+		WorkerCount:     3,
+		PollInterval:    20 * time.Millisecond,
+		LockTimeout:     3 * time.Second,
+		KindConcurrency: map[string]int{"block": 1},
+	})
+	tm.RegisterTaskFactory("block", blockingTaskFactory(started))
+	tm.RegisterTaskFactory("test", fakeTaskFactory(executed, nil))
+	require.NoError(t, tm.Start(context.Background()))
+	defer tm.Stop(context.Background())
+
+	firstID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 1, kind: "block", priority: 10})
+	require.NoError(t, err)
+	waitStarted(t, started)
+
+	// Лимит типа действует и для задач разных проектов
+	secondID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 2, kind: "block"})
+	require.NoError(t, err)
+	assertNotStarted(t, started)
+	assert.Equal(t, db.JobStateQueued, store.state(secondID))
+
+	// Задачи других типов лимит не задерживает
+	_, err = tm.SubmitTask(context.Background(), &fakeTask{projectID: 3, kind: "test", Message: "other"})
+	require.NoError(t, err)
+	select {
+	case msg := <-executed:
+		assert.Equal(t, "other", msg)
+	case <-time.After(2 * time.Second):
+		t.Fatal("task of another kind was not executed")
+	}
+
+	require.NoError(t, tm.CancelTask(context.Background(), firstID))
+	waitStarted(t, started)
+	waitForState(t, store, secondID, db.JobStateRunning)
+	require.NoError(t, tm.CancelTask(context.Background(), secondID))
+}
+
+func TestTaskManager_DelayedTask(t *testing.T) {
+	store := newFakeJobStore()
+	executed := make(chan string, 1)
+
+	tm := newTestManager(store)
+	tm.RegisterTaskFactory("test", fakeTaskFactory(executed, nil))
+	require.NoError(t, tm.Start(context.Background()))
+	defer tm.Stop(context.Background())
+
+	submitted := time.Now()
+	jobID, err := tm.SubmitDelayedTask(context.Background(), &fakeTask{projectID: 1, kind: "test", Message: "later"}, 200*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, db.JobStateQueued, store.state(jobID))
+
+	select {
+	case msg := <-executed:
+		assert.Equal(t, "later", msg)
+		assert.GreaterOrEqual(t, time.Since(submitted), 200*time.Millisecond)
+	case <-time.After(2 * time.Second):
+		t.Fatal("delayed task was not executed")
+	}
+}
+
+func TestTaskManager_RunsDueSchedule(t *testing.T) {
+	store := newFakeJobStore()
+	store.addSchedule(1, 3, "test", "0 3 * * *")
+	executed := make(chan string, 2)
+
+	tm := NewTaskManager(store, ManagerConfig{
+		WorkerCount:      1,
+		PollInterval:     20 * time.Millisecond,
+		LockTimeout:      3 * time.Second,
+		ScheduleInterval: 20 * time.Millisecond,
+	})
+	tm.RegisterTaskFactory("test", fakeTaskFactory(executed, nil))
+	require.NoError(t, tm.Start(context.Background()))
+	defer tm.Stop(context.Background())
+
+	select {
+	case <-executed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("scheduled task was not executed")
+	}
+
+	// Расписание перенесено на следующий запуск и больше не срабатывает
+	schedule := store.schedule(1)
+	assert.True(t, schedule.LastRunAt.Valid)
+	assert.True(t, schedule.NextRunAt.After(time.Now()))
+	assert.Equal(t, 3, schedule.NextRunAt.Hour())
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, store.jobsByKind("test"))
+}
+
+func TestTaskManager_ScheduleFiresOnceAcrossInstances(t *testing.T) {
+	store := newFakeJobStore()
+	store.addSchedule(1, 3, "checklist", "@daily")
+
+	var mu sync.Mutex
+	var fired []int32
+	handler := func(ctx context.Context, schedule *db.JobSchedule) error {
+		mu.Lock()
+		defer mu.Unlock()
+		fired = append(fired, schedule.ProjectID)
+		return nil
+	}
+
+	for i := 0; i < 3; i++ {
+		tm := NewTaskManager(store, ManagerConfig{
+			PollInterval:     20 * time.Millisecond,
+			ScheduleInterval: 10 * time.Millisecond,
+		})
+		tm.SetScheduleHandler(handler)
+		require.NoError(t, tm.Start(context.Background()))
+		defer tm.Stop(context.Background())
+	}
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(fired) > 0
+	}, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int32{3}, fired)
+	// Обработчик заменяет постановку задачи по умолчанию
+	assert.Equal(t, 0, store.jobsByKind("checklist"))
+}
+
+func TestTaskManager_Stats(t *testing.T) {
+	store := newFakeJobStore()
+	started := make(chan struct{}, 1)
+	executed := make(chan string, 2)
+
+	tm := NewTaskManager(store, ManagerConfig{
+		WorkerCount:  2,
+		PollInterval: 20 * time.Millisecond,
+		LockTimeout:  3 * time.Second,
+	})
+	tm.RegisterTaskFactory("block", blockingTaskFactory(started))
+	tm.RegisterTaskFactory("test", fakeTaskFactory(executed, nil))
+	tm.RegisterTaskFactory("broken", fakeTaskFactory(nil, errors.New("boom")))
+	require.NoError(t, tm.Start(context.Background()))
+	defer tm.Stop(context.Background())
+
+	blockID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 7, kind: "block"})
+	require.NoError(t, err)
+	waitStarted(t, started)
+
+	for i := 0; i < 2; i++ {
+		_, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: int32(10 + i), kind: "test"})
+		require.NoError(t, err)
+		<-executed
+	}
+	brokenID, err := tm.SubmitTask(context.Background(), &fakeTask{projectID: 20, kind: "broken"})
+	require.NoError(t, err)
+	waitForState(t, store, brokenID, db.JobStateFailed)
+
+	// Отложенная задача учитывается в очереди, но не выполняется
+	_, err = tm.SubmitDelayedTask(context.Background(), &fakeTask{projectID: 30, kind: "test"}, time.Hour)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return tm.GetStats().Kinds["test"].Completed == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	stats := tm.GetStats()
+	assert.Equal(t, 2, stats.WorkerCount)
+	assert.NotEmpty(t, stats.WorkerID)
+
+	require.Len(t, stats.Running, 1)
+	assert.Equal(t, blockID, stats.Running[0].JobID)
+	assert.Equal(t, int32(7), stats.Running[0].ProjectID)
+	assert.Equal(t, "block", stats.Running[0].Kind)
+	assert.Greater(t, stats.Running[0].ElapsedMs, int64(0))
+
+	assert.Equal(t, int64(2), stats.Kinds["test"].Completed)
+	assert.Equal(t, 2, stats.Kinds["test"].Samples)
+	assert.Equal(t, int64(1), stats.Kinds["broken"].Failed)
+	assert.Equal(t, QueueStats{Delayed: 1, Running: 1}, stats.Queue)
+	assert.Equal(t, 1, stats.PendingTasks)
+
+	require.NoError(t, tm.CancelTask(context.Background(), blockID))
+}
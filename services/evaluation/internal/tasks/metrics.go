@@ -0,0 +1,55 @@
+package tasks
+
+import (
+	"math"
+	"slices"
+)
+
+// latencyWindow количество последних запусков каждого типа, по которым считаются перцентили длительности
+const latencyWindow = 1000
+
+// kindMetrics накапливает результаты выполнения задач одного типа
+type kindMetrics struct {
+	completed int64
+	failed    int64
+	retried   int64
+	dead      int64
+	latencies []int64 // кольцевой буфер длительностей запусков в миллисекундах
+	next      int
+}
+
+// observe запоминает длительность запуска, вытесняя самый старый при заполненном окне
+func (m *kindMetrics) observe(durationMs int64) {
+	if len(m.latencies) < latencyWindow {
+		m.latencies = append(m.latencies, durationMs)
+		return
+	}
+	m.latencies[m.next] = durationMs
+	m.next = (m.next + 1) % latencyWindow
+}
+
+// snapshot возвращает счетчики и перцентили длительности
+func (m *kindMetrics) snapshot() KindStats {
+	sorted := slices.Clone(m.latencies)
+	slices.Sort(sorted)
+
+	return KindStats{
+		Completed:    m.completed,
+		Failed:       m.failed,
+		Retried:      m.retried,
+		Dead:         m.dead,
+		Samples:      len(sorted),
+		LatencyP50Ms: percentile(sorted, 0.50),
+		LatencyP90Ms: percentile(sorted, 0.90),
+		LatencyP99Ms: percentile(sorted, 0.99),
+	}
+}
+
+// percentile возвращает перцентиль p (от 0 до 1) отсортированной выборки методом ближайшего ранга
+func percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,38 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindMetrics_Percentiles(t *testing.T) {
+	metrics := &kindMetrics{}
+	assert.Equal(t, KindStats{}, metrics.snapshot())
+
+	for i := int64(100); i >= 1; i-- {
+		metrics.observe(i)
+	}
+
+	stats := metrics.snapshot()
+	assert.Equal(t, 100, stats.Samples)
+	assert.Equal(t, int64(50), stats.LatencyP50Ms)
+	assert.Equal(t, int64(90), stats.LatencyP90Ms)
+	assert.Equal(t, int64(99), stats.LatencyP99Ms)
+}
+
+func TestKindMetrics_Window(t *testing.T) {
+	metrics := &kindMetrics{}
+
+	// Старые медленные запуски вытесняются из окна быстрыми
+	for i := 0; i < latencyWindow; i++ {
+		metrics.observe(10_000)
+	}
+	for i := 0; i < latencyWindow; i++ {
+		metrics.observe(5)
+	}
+
+	stats := metrics.snapshot()
+	assert.Equal(t, latencyWindow, stats.Samples)
+	assert.Equal(t, int64(5), stats.LatencyP99Ms)
+}
@@ -3,16 +3,22 @@ package tasks
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"evaluation/internal/extract"
 	db "evaluation/internal/postgres/sqlc"
+	"evaluation/internal/projectstate"
+	"evaluation/internal/reports"
 	"evaluation/internal/storage"
 	"evaluation/internal/utils"
 
@@ -29,12 +35,33 @@ type RAGConfig struct {
 	ChunkOverlap int
 	TopK         int
 	RequestDelay time.Duration
+	// Retriever способ поиска чанков: keyword, embedding или hybrid
+	Retriever string
+	// EmbeddingAPIURL адрес метода эмбеддингов OpenAI (/v1/embeddings) или Ollama (/api/embed)
+	EmbeddingAPIURL string
+	EmbeddingModel  string
+	EmbeddingAPIKey string
+}
+
+// DefaultRAGConfig возвращает конфигурацию RAG-системы по умолчанию: поиск по словам
+func DefaultRAGConfig() RAGConfig {
+	return RAGConfig{
+		LLMAPIURL:    "http://89.108.116.240:11434/api/chat",
+		LLMModelName: "qwen3-8b:latest",
+		MaxChunkSize: defaultMaxChunkSize,
+		ChunkOverlap: defaultChunkOverlap,
+		TopK:         5,
+		RequestDelay: 500 * time.Millisecond,
+		Retriever:    RetrieverKeyword,
+	}
 }
 
 // DocumentChunk чанк документа для индексации
 type DocumentChunk struct {
 	Content  string            `json:"content"`
 	Metadata map[string]string `json:"metadata"`
+	// Embedding эмбеддинг из индекса документации, пустой - вычисляется поиском при индексации
+	Embedding []float64 `json:"embedding,omitempty"`
 }
 
 // ChecklistItem элемент чек-листа
@@ -58,102 +85,81 @@ type LLMResponse struct {
 
 // RAGSystem система для RAG-операций
 type RAGSystem struct {
-	config    RAGConfig
-	client    *resty.Client
-	documents []DocumentChunk
+	config     RAGConfig
+	client     *resty.Client
+	extractors *extract.Registry
+	retriever  Retriever
+	embedder   Embedder // nil, если поиск не использует эмбеддинги
 }
 
-// NewRAGSystem создает новую RAG-систему
-func NewRAGSystem(config RAGConfig) *RAGSystem {
+// NewRAGSystem создает новую RAG-систему с поиском, выбранным в config.Retriever
+func NewRAGSystem(config RAGConfig) (*RAGSystem, error) {
+	retriever, err := NewRetriever(config)
+	if err != nil {
+		return nil, err
+	}
+	embedder, err := newEmbedder(config)
+	if err != nil {
+		return nil, err
+	}
+
 	client := resty.New().
 		SetTimeout(300 * time.Second).
 		SetRetryCount(3).
 		SetRetryWaitTime(1 * time.Second)
 
 	return &RAGSystem{
-		config:    config,
-		client:    client,
-		documents: []DocumentChunk{},
-	}
+		config:     config,
+		client:     client,
+		extractors: extract.NewRegistry(),
+		retriever:  retriever,
+		embedder:   embedder,
+	}, nil
 }
 
-// extractTextFromFile извлекает текст из файла по его расширению
-func (rag *RAGSystem) extractTextFromFile(file io.Reader, filename string) (string, error) {
-	// Простая реализация для текстовых файлов
-	// В реальном проекте здесь можно добавить парсинг PDF, DOCX и других форматов
-	content, err := io.ReadAll(file)
-	if err != nil {
-		return "", fmt.Errorf("failed to read file: %w", err)
-	}
-
-	// Убираем HTML теги если есть
-	contentStr := string(content)
-	re := regexp.MustCompile(`<[^>]*>`)
-	contentStr = re.ReplaceAllString(contentStr, "")
-
-	// Убираем лишние пробелы
-	contentStr = strings.TrimSpace(contentStr)
-
-	return contentStr, nil
+// extractTextFromFile извлекает текст из файла извлекателем, выбранным по расширению
+func (rag *RAGSystem) extractTextFromFile(file io.Reader, filename string) ([]extract.Section, error) {
+	return rag.extractors.Extract(filename, file)
 }
 
-// splitTextIntoChunks разбивает текст на чанки
-func (rag *RAGSystem) splitTextIntoChunks(text string, filename string) []DocumentChunk {
+// splitTextIntoChunks разбивает фрагменты документа на чанки размером MaxChunkSize с перекрытием ChunkOverlap
+// В метаданных чанка сохраняются номер страницы или слайда ("page") и подпись места ("location")
+func (rag *RAGSystem) splitTextIntoChunks(sections []extract.Section, filename string) []DocumentChunk {
 	var chunks []DocumentChunk
-
-	// Простое разбиение по предложениям
-	sentences := regexp.MustCompile(`[.!?]+`).Split(text, -1)
-
-	for i, sentence := range sentences {
-		sentence = strings.TrimSpace(sentence)
-		if len(sentence) > 10 { // Минимальная длина предложения
-			chunk := DocumentChunk{
-				Content: sentence,
-				Metadata: map[string]string{
-					"filename": filename,
-					"chunk_id": fmt.Sprintf("%d", i),
-				},
-			}
-			chunks = append(chunks, chunk)
+	for i, chunk := range chunkSections(sections, rag.config.MaxChunkSize, rag.config.ChunkOverlap) {
+		metadata := map[string]string{
+			"filename": filename,
+			"chunk_id": strconv.Itoa(i),
+			"location": chunk.Section.Location,
+		}
+		if chunk.Section.Page > 0 {
+			metadata["page"] = strconv.Itoa(chunk.Section.Page)
 		}
+		chunks = append(chunks, DocumentChunk{Content: chunk.Text, Metadata: metadata})
 	}
-
 	return chunks
 }
 
-// searchRelevantChunks ищет релевантные чанки по запросу
-func (rag *RAGSystem) searchRelevantChunks(query string) []DocumentChunk {
-	var relevantChunks []DocumentChunk
-
-	// Простой поиск по ключевым словам
-	queryLower := strings.ToLower(query)
-	queryWords := strings.Fields(queryLower)
-
-	for _, chunk := range rag.documents {
-		chunkLower := strings.ToLower(chunk.Content)
-		score := 0
-
-		for _, word := range queryWords {
-			if strings.Contains(chunkLower, word) {
-				score++
-			}
-		}
-
-		if score > 0 {
-			relevantChunks = append(relevantChunks, chunk)
-		}
+// chunkSource возвращает подпись источника для контекста LLM: имя файла и место в документе
+func chunkSource(chunk DocumentChunk) string {
+	if location := chunk.Metadata["location"]; location != "" {
+		return fmt.Sprintf("%s, %s", chunk.Metadata["filename"], location)
 	}
+	return chunk.Metadata["filename"]
+}
 
-	// Ограничиваем количество результатов
-	if len(relevantChunks) > rag.config.TopK {
-		relevantChunks = relevantChunks[:rag.config.TopK]
-	}
+// addDocuments добавляет чанки в индекс поиска RAG-системы
+func (rag *RAGSystem) addDocuments(ctx context.Context, chunks []DocumentChunk) error {
+	return rag.retriever.Index(ctx, chunks)
+}
 
-	return relevantChunks
+// searchRelevantChunks возвращает TopK чанков, наиболее релевантных запросу
+func (rag *RAGSystem) searchRelevantChunks(ctx context.Context, query string) ([]DocumentChunk, error) {
+	return rag.retriever.Retrieve(ctx, query, rag.config.TopK)
 }
 
-// callLLM отправляет запрос к LLM API
-func (rag *RAGSystem) callLLM(messages []map[string]string) (string, error) {
+// callLLM отправляет запрос к LLM API, запрос прерывается при отмене контекста
+func (rag *RAGSystem) callLLM(ctx context.Context, messages []map[string]string) (string, error) {
 	payload := map[string]interface{}{
 		"model":    rag.config.LLMModelName,
 		"messages": messages,
@@ -167,6 +173,7 @@ func (rag *RAGSystem) callLLM(messages []map[string]string) (string, error) {
 	}
 
 	resp, err := rag.client.R().
+		SetContext(ctx).
 		SetBody(payload).
 		SetResult(&LLMResponse{}).
 		Post(rag.config.LLMAPIURL)
@@ -184,9 +191,26 @@ func (rag *RAGSystem) callLLM(messages []map[string]string) (string, error) {
 }
 
 // processCriterion обрабатывает один критерий чек-листа
-func (rag *RAGSystem) processCriterion(criterion string) (*ChecklistItem, error) {
+// Ошибка возвращается только при отмене контекста, остальные ошибки попадают в ответ
+func (rag *RAGSystem) processCriterion(ctx context.Context, criterion string) (*ChecklistItem, error) {
 	// Ищем релевантные документы
-	relevantChunks := rag.searchRelevantChunks(criterion)
+	relevantChunks, err := rag.searchRelevantChunks(ctx, criterion)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	if err != nil {
+		return &ChecklistItem{
+			Criterion: criterion,
+			Status:    "requires_confirmation",
+			Answer:    fmt.Sprintf("Ошибка поиска по документации: %v", err),
+			Sources: []struct {
+				Filename string `json:"filename"`
+				Page     string `json:"page"`
+				Snippet  string `json:"snippet"`
+			}{},
+		}, nil
+	}
 
 	if len(relevantChunks) == 0 {
 		return &ChecklistItem{
@@ -204,7 +228,7 @@ func (rag *RAGSystem) processCriterion(criterion string) (*ChecklistItem, error)
 	// Формируем контекст для LLM
 	var contextBuilder strings.Builder
 	for i, chunk := range relevantChunks {
-		contextBuilder.WriteString(fmt.Sprintf("[ИСТОЧНИК %d: %s]\n", i+1, chunk.Metadata["filename"]))
+		contextBuilder.WriteString(fmt.Sprintf("[ИСТОЧНИК %d: %s]\n", i+1, chunkSource(chunk)))
 		contextBuilder.WriteString(chunk.Content)
 		contextBuilder.WriteString("\n\n")
 	}
@@ -226,10 +250,14 @@ func (rag *RAGSystem) processCriterion(criterion string) (*ChecklistItem, error)
 }`, contextBuilder.String(), criterion)
 
 	// Отправляем запрос к LLM
-	response, err := rag.callLLM([]map[string]string{
+	response, err := rag.callLLM(ctx, []map[string]string{
 		{"role": "user", "content": prompt},
 	})
 
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
 	if err != nil {
 		return &ChecklistItem{
 			Criterion: criterion,
@@ -262,13 +290,18 @@ func (rag *RAGSystem) processCriterion(criterion string) (*ChecklistItem, error)
 	}
 
 	for _, chunk := range relevantChunks {
+		// Номер страницы известен для PDF и слайда - для PPTX, для листов XLSX указывается название листа
+		page := chunk.Metadata["page"]
+		if page == "" {
+			page = chunk.Metadata["location"]
+		}
 		sources = append(sources, struct {
 			Filename string `json:"filename"`
 			Page     string `json:"page"`
 			Snippet  string `json:"snippet"`
 		}{
 			Filename: chunk.Metadata["filename"],
-			Page:     chunk.Metadata["chunk_id"],
+			Page:     page,
 			Snippet:  chunk.Content,
 		})
 	}
@@ -285,9 +318,12 @@ func (rag *RAGSystem) processCriterion(criterion string) (*ChecklistItem, error)
 type Repository interface {
 	GetProject(ctx context.Context, id int32) (*db.Project, error)
 	GetProjectFilesByType(ctx context.Context, projectID int32, fileType db.FileType) ([]db.ProjectFile, error)
-	CreateRemark(ctx context.Context, arg db.CreateRemarkParams) (db.Remark, error)
+	ReplaceRemarks(ctx context.Context, projectID int32, remarks []db.CreateRemarkParams) error
+	GetRemarksByProject(ctx context.Context, projectID int32) ([]db.Remark, error)
+	GetEffectiveReportTemplate(ctx context.Context, projectID int32) (*db.ReportTemplate, error)
 	CreateProjectFile(ctx context.Context, projectID int32, filename, originalName, filePath string, fileSize int64, extension string, fileType db.FileType) (*db.ProjectFile, error)
-	UpdateProjectStatus(ctx context.Context, projectID int32, newStatus db.ProjectStatus) (*db.Project, error)
+	DocumentIndexStore
+	projectstate.Store
 }
 
 // RemarkItem структура для элемента замечания из JSON ответа
@@ -300,32 +336,128 @@ type RemarkItem struct {
 // RemarksResponse структура для JSON ответа от внешнего сервиса
 type RemarksResponse map[string][]RemarkItem
 
+// Sections возвращает разделы в порядке таксономии разделов экспертизы, см. utils.ExpertiseSections
+func (r RemarksResponse) Sections() []string {
+	sections := make([]string, 0, len(r))
+	for section := range r {
+		sections = append(sections, section)
+	}
+	utils.SortSections(sections)
+	return sections
+}
+
+// Типы задач обработки проекта, каждый выполняет одноименный конвейер проекта
+const (
+	TaskKindRemarks     = projectstate.PipelineRemarks     // обработка замечаний
+	TaskKindChecklist   = projectstate.PipelineChecklist   // генерация чек-листа
+	TaskKindFinalReport = projectstate.PipelineFinalReport // генерация итогового отчета
+)
+
+// Форматы отчетов, формируемых задачами обработки проекта
+const (
+	ReportFormatPDF  = "pdf"
+	ReportFormatDOCX = "docx"
+	// ReportFormatXLSX таблица кластеризованных замечаний, формируется при каждой обработке замечаний
+	ReportFormatXLSX = "xlsx"
+)
+
+// reportContentTypes MIME типы отчетов по формату
+var reportContentTypes = map[string]string{
+	ReportFormatPDF:  "application/pdf",
+	ReportFormatDOCX: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	ReportFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// IsReportFormat проверяет, можно ли запросить генерацию отчета в формате
+func IsReportFormat(format string) bool {
+	return format == ReportFormatPDF || format == ReportFormatDOCX
+}
+
+// ReportContentType возвращает MIME тип отчета по формату
+func ReportContentType(format string) string {
+	if contentType, ok := reportContentTypes[format]; ok {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// ProjectTaskPayload параметры задачи обработки проекта, сохраняемые в очереди
+type ProjectTaskPayload struct {
+	// Format формат формируемого отчета, по умолчанию PDF
+	Format string `json:"format,omitempty"`
+}
+
+// remarksServiceURL адрес сервиса кластеризации замечаний
+const remarksServiceURL = "http://127.0.0.1:8083/remarks"
+
 // ProjectProcessorTask задача для обработки проекта
 type ProjectProcessorTask struct {
 	projectID int32
+	kind      string
 	priority  int
+	payload   ProjectTaskPayload
 	repo      Repository
+	status    *projectstate.Machine
 	storage   storage.FileStorage
+	progress  ProgressReporter
+	template  *reports.Template
+	rag       RAGConfig
+	// remarksURL адрес сервиса кластеризации замечаний
+	remarksURL string
 }
 
 // NewProjectProcessorTask создает новую задачу обработки проекта
 func NewProjectProcessorTask(
 	projectID int32,
+	kind string,
 	priority int,
 	repo Repository,
 	storage storage.FileStorage,
 ) *ProjectProcessorTask {
 	return &ProjectProcessorTask{
-		projectID: projectID,
-		priority:  priority,
-		repo:      repo,
-		storage:   storage,
+		projectID:  projectID,
+		kind:       kind,
+		priority:   priority,
+		repo:       repo,
+		status:     projectstate.New(repo),
+		storage:    storage,
+		template:   reports.DefaultTemplate(),
+		rag:        DefaultRAGConfig(),
+		remarksURL: remarksServiceURL,
+	}
+}
+
+// WithReportFormat задает формат отчета, формируемого задачей
+func (pt *ProjectProcessorTask) WithReportFormat(format string) *ProjectProcessorTask {
+	pt.payload.Format = format
+	return pt
+}
+
+// NewProjectProcessorTaskFactory создает фабрику, восстанавливающую задачи обработки проекта из очереди
+// Восстановленные задачи сообщают о ходе выполнения через progress, оформляют отчеты по template
+// и проверяют чек-лист RAG-системой с конфигурацией rag; пустая конфигурация - DefaultRAGConfig
+func NewProjectProcessorTaskFactory(repo Repository, storage storage.FileStorage, progress ProgressReporter, template *reports.Template, rag RAGConfig) TaskFactory {
+	return func(job *db.Job) (Task, error) {
+		task := NewProjectProcessorTask(job.ProjectID, job.Kind, int(job.Priority), repo, storage)
+		task.progress = progress
+		if template != nil {
+			task.template = template
+		}
+		if rag != (RAGConfig{}) {
+			task.rag = rag
+		}
+		if len(job.Payload) > 0 {
+			if err := json.Unmarshal(job.Payload, &task.payload); err != nil {
+				return nil, fmt.Errorf("failed to decode payload: %w", err)
+			}
+		}
+		return task, nil
 	}
 }
 
 // Execute выполняет задачу обработки проекта
 func (pt *ProjectProcessorTask) Execute(ctx context.Context) error {
-	log.Printf("Starting project processing task for project %d", pt.projectID)
+	log.Printf("Starting project processing task (%s) for project %d", pt.kind, pt.projectID)
 
 	// Получаем информацию о проекте
 	project, err := pt.getProject(ctx)
@@ -334,15 +466,22 @@ func (pt *ProjectProcessorTask) Execute(ctx context.Context) error {
 	}
 
 	// Выполняем обработку в зависимости от типа задачи
-	switch project.Status {
-	case db.ProjectStatusProcessingRemarks:
+	switch pt.kind {
+	case TaskKindRemarks:
 		return pt.processRemarks(ctx, project)
-	case db.ProjectStatusProcessingChecklist:
+	case TaskKindChecklist:
 		return pt.generateChecklist(ctx, project)
-	case db.ProjectStatusGeneratingFinalReport:
+	case TaskKindFinalReport:
 		return pt.generateFinalReport(ctx, project)
 	default:
-		return fmt.Errorf("unknown task type: %s", project.Status)
+		return fmt.Errorf("unknown task type: %s", pt.kind)
+	}
+}
+
+// OnFailure возвращает проект в статус ready после окончательного провала задачи
+func (pt *ProjectProcessorTask) OnFailure(ctx context.Context, err error) {
+	if updateErr := pt.finishProcessing(ctx, pt.projectID, fmt.Sprintf("%s failed: %v", pt.kind, err)); updateErr != nil {
+		log.Printf("Failed to set project status to ready after error: %v", updateErr)
 	}
 }
 
@@ -356,6 +495,123 @@ func (pt *ProjectProcessorTask) GetPriority() int {
 	return pt.priority
 }
 
+// GetKind возвращает тип задачи
+func (pt *ProjectProcessorTask) GetKind() string {
+	return pt.kind
+}
+
+// GetPayload возвращает параметры задачи для сохранения в очереди
+func (pt *ProjectProcessorTask) GetPayload() ([]byte, error) {
+	return json.Marshal(pt.payload)
+}
+
+// reportFormat возвращает формат отчета задачи
+func (pt *ProjectProcessorTask) reportFormat() string {
+	if pt.payload.Format == "" {
+		return ReportFormatPDF
+	}
+	return pt.payload.Format
+}
+
+// saveReport сохраняет отчет в S3 и регистрирует его как файл проекта
+// К имени файла и названию для пользователя добавляется расширение формата отчета
+func (pt *ProjectProcessorTask) saveReport(ctx context.Context, projectID int32, report *bytes.Buffer, format, filename, title string, fileType db.FileType) error {
+	ext := "." + format
+	size := int64(report.Len())
+
+	objectName, err := pt.storage.UploadFile(ctx, report, filename+ext, reportContentTypes[format])
+	if err != nil {
+		return fmt.Errorf("failed to upload %s report to S3: %w", format, err)
+	}
+
+	_, err = pt.repo.CreateProjectFile(ctx, projectID, filename+ext, title+ext, objectName, size, ext, fileType)
+	if err != nil {
+		return fmt.Errorf("failed to create project file record: %w", err)
+	}
+
+	log.Printf("Successfully generated and uploaded %s report %s to S3", format, objectName)
+	return nil
+}
+
+// reportProgress сообщает о ходе обработки проекта, если задан ProgressReporter
+func (pt *ProjectProcessorTask) reportProgress(ctx context.Context, message string, current, total int) {
+	if pt.progress != nil {
+		pt.progress.ReportProgress(ctx, pt.projectID, message, current, total)
+	}
+}
+
+// reportTemplate возвращает шаблон отчетов проекта, заполненный данными отчета
+// Непустые тексты шаблона, выбранного для проекта или используемого по умолчанию, заменяют тексты шаблона сервиса
+func (pt *ProjectProcessorTask) reportTemplate(ctx context.Context, projectID int32, data reports.ReportData) (*reports.Template, error) {
+	template := pt.template
+
+	stored, err := pt.repo.GetEffectiveReportTemplate(ctx, projectID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, Retryable(fmt.Errorf("failed to get report template: %w", err))
+	default:
+		template = template.With(reports.Template{
+			Organization:          stored.Organization,
+			RemarksIntro:          stored.RemarksIntro,
+			RemarksConclusion:     stored.RemarksConclusion,
+			FinalReportIntro:      stored.FinalReportIntro,
+			FinalReportConclusion: stored.FinalReportConclusion,
+		})
+	}
+
+	rendered, err := template.Execute(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render report template: %w", err)
+	}
+	return rendered, nil
+}
+
+// remarksReportData собирает данные отчета по замечаниям для текстов шаблона
+func remarksReportData(project *db.Project, remarksResponse RemarksResponse) reports.ReportData {
+	data := reports.ReportData{
+		ProjectName:      project.Name,
+		ProjectCreatedAt: project.CreatedAt,
+		GeneratedAt:      time.Now(),
+	}
+	for _, section := range remarksResponse.Sections() {
+		summary := reports.SectionSummary{Title: utils.SectionTitle(section)}
+		for _, item := range remarksResponse[section] {
+			summary.Groups++
+			summary.Remarks += len(item.OriginalDuplicates)
+			summary.Merged += mergedDuplicates(len(item.OriginalDuplicates))
+		}
+		data.AddSection(summary)
+	}
+	return data
+}
+
+// finalReportData собирает данные итогового отчета для текстов шаблона
+func finalReportData(project *db.Project, report FinalReport) reports.ReportData {
+	data := reports.ReportData{
+		ProjectName:       project.Name,
+		ProjectCreatedAt:  project.CreatedAt,
+		GeneratedAt:       time.Now(),
+		ChecklistCriteria: len(report.Checklist),
+	}
+	for _, section := range report.Sections {
+		summary := reports.SectionSummary{Title: utils.SectionTitle(section.Section), Groups: len(section.Groups)}
+		for _, group := range section.Groups {
+			for _, duplicates := range group.Duplicates {
+				summary.Remarks += duplicates
+				summary.Merged += mergedDuplicates(duplicates)
+			}
+		}
+		data.AddSection(summary)
+	}
+	return data
+}
+
+// mergedDuplicates возвращает количество замечаний группы, объединенных с первым как дубликаты
+func mergedDuplicates(remarks int) int {
+	return max(remarks-1, 0)
+}
+
 // getProject получает информацию о проекте из БД
 func (pt *ProjectProcessorTask) getProject(ctx context.Context) (*db.Project, error) {
 	project, err := pt.repo.GetProject(ctx, pt.projectID)
@@ -366,6 +622,9 @@ func (pt *ProjectProcessorTask) getProject(ctx context.Context) (*db.Project, er
 	return project, nil
 }
 
+// remarksSteps количество этапов обработки замечаний, о которых сообщается прогресс
+const remarksSteps = 5
+
 // processRemarks обрабатывает замечания проекта
 func (pt *ProjectProcessorTask) processRemarks(ctx context.Context, project *db.Project) error {
 	log.Printf("Processing remarks for project %d", pt.projectID)
@@ -376,12 +635,11 @@ func (pt *ProjectProcessorTask) processRemarks(ctx context.Context, project *db.
 	// TODO: process remarks
 	//
 	files, err := pt.repo.GetProjectFilesByType(ctx, project.ID, db.FileTypeRemarks)
-	if err != nil || len(files) == 0 {
-		// Устанавливаем статус ready при ошибке
-		if updateErr := pt.setProjectStatusReady(ctx, project.ID); updateErr != nil {
-			log.Printf("Failed to set project status to ready after error: %v", updateErr)
-		}
-		return err
+	if err != nil {
+		return fmt.Errorf("failed to get remarks files: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no remarks files found for project %d", project.ID)
 	}
 
 	fileRemarks := files[0]
@@ -389,126 +647,107 @@ func (pt *ProjectProcessorTask) processRemarks(ctx context.Context, project *db.
 	// Получаем файл из S3
 	fileReader, err := pt.storage.DownloadFile(ctx, fileRemarks.Filename)
 	if err != nil {
-		// Устанавливаем статус ready при ошибке
-		if updateErr := pt.setProjectStatusReady(ctx, project.ID); updateErr != nil {
-			log.Printf("Failed to set project status to ready after error: %v", updateErr)
-		}
-		return fmt.Errorf("failed to download file %s from S3: %w", fileRemarks.Filename, err)
+		return Retryable(fmt.Errorf("failed to download file %s from S3: %w", fileRemarks.Filename, err))
 	}
 	defer fileReader.Close()
 
 	// Читаем содержимое файла
 	fileContent, err := io.ReadAll(fileReader)
 	if err != nil {
-		// Устанавливаем статус ready при ошибке
-		if updateErr := pt.setProjectStatusReady(ctx, project.ID); updateErr != nil {
-			log.Printf("Failed to set project status to ready after error: %v", updateErr)
-		}
 		return fmt.Errorf("failed to read file content: %w", err)
 	}
 
 	log.Printf("Successfully downloaded file %s from S3, size: %d bytes", fileRemarks.Filename, len(fileContent))
+	pt.reportProgress(ctx, "remarks file downloaded", 1, remarksSteps)
 
 	// Парсим Excel файл и преобразуем в JSON
 	jsonData, err := utils.ParseExcelFromBytes(fileContent)
 	if err != nil {
-		// Устанавливаем статус ready при ошибке
-		if updateErr := pt.setProjectStatusReady(ctx, project.ID); updateErr != nil {
-			log.Printf("Failed to set project status to ready after error: %v", updateErr)
-		}
 		return fmt.Errorf("failed to parse Excel file: %w", err)
 	}
 
-	externalURL := "http://127.0.0.1:8083/remarks"
-
 	// Send request to external service
-	resp, err := http.Post(externalURL, "application/json", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pt.remarksURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		// Устанавливаем статус ready при ошибке
-		if updateErr := pt.setProjectStatusReady(ctx, project.ID); updateErr != nil {
-			log.Printf("Failed to set project status to ready after error: %v", updateErr)
-		}
-		return fmt.Errorf("failed to send remarks to external service: %v", err)
+		return fmt.Errorf("failed to create request to external service: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	pt.reportProgress(ctx, "remarks sent to ML service", 2, remarksSteps)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Retryable(fmt.Errorf("failed to send remarks to external service: %w", err))
 	}
 	defer resp.Body.Close()
 
 	// Check external service response
 	if resp.StatusCode != http.StatusAccepted {
 		body, _ := io.ReadAll(resp.Body)
-		// Устанавливаем статус ready при ошибке
-		if updateErr := pt.setProjectStatusReady(ctx, project.ID); updateErr != nil {
-			log.Printf("Failed to set project status to ready after error: %v", updateErr)
+		err := fmt.Errorf("external service returned status %d: %s", resp.StatusCode, string(body))
+		// Ошибки сервера и перегрузка считаются временными
+		if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+			return Retryable(err)
 		}
-		return fmt.Errorf("external service returned status %d: %s", resp.StatusCode, string(body))
+		return err
 	}
 
 	// Читаем ответ от внешнего сервиса
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		// Устанавливаем статус ready при ошибке
-		if updateErr := pt.setProjectStatusReady(ctx, project.ID); updateErr != nil {
-			log.Printf("Failed to set project status to ready after error: %v", updateErr)
-		}
 		return fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// Парсим JSON ответ
 	var remarksResponse RemarksResponse
 	if err := json.Unmarshal(respBody, &remarksResponse); err != nil {
-		// Устанавливаем статус ready при ошибке
-		if updateErr := pt.setProjectStatusReady(ctx, project.ID); updateErr != nil {
-			log.Printf("Failed to set project status to ready after error: %v", updateErr)
-		}
 		return fmt.Errorf("failed to parse JSON response: %w", err)
 	}
 
 	// Сохраняем замечания в БД
 	if err := pt.saveRemarksToDB(ctx, project.ID, remarksResponse); err != nil {
-		// Устанавливаем статус ready при ошибке
-		if updateErr := pt.setProjectStatusReady(ctx, project.ID); updateErr != nil {
-			log.Printf("Failed to set project status to ready after error: %v", updateErr)
-		}
 		return fmt.Errorf("failed to save remarks to DB: %w", err)
 	}
 
 	log.Printf("Successfully saved %d remark categories to DB", len(remarksResponse))
+	pt.reportProgress(ctx, "remarks saved", 3, remarksSteps)
 
-	// Генерируем PDF отчет из JSON ответа
-	pdfBuffer, err := pt.generatePDFFromRemarks(remarksResponse)
+	data := remarksReportData(project, remarksResponse)
+	template, err := pt.reportTemplate(ctx, project.ID, data)
 	if err != nil {
-		// Устанавливаем статус ready при ошибке
-		if updateErr := pt.setProjectStatusReady(ctx, project.ID); updateErr != nil {
-			log.Printf("Failed to set project status to ready after error: %v", updateErr)
-		}
-		return fmt.Errorf("failed to generate PDF report: %w", err)
+		return err
 	}
 
-	// Сохраняем PDF файл в S3
-	objectName, err := pt.storage.UploadFile(ctx, pdfBuffer, "remarks_report.pdf", "application/pdf")
+	// Генерируем отчет из JSON ответа в запрошенном формате
+	var report *bytes.Buffer
+	if pt.reportFormat() == ReportFormatDOCX {
+		report, err = pt.generateDOCXFromRemarks(template, data, remarksResponse)
+	} else {
+		report, err = pt.generatePDFFromRemarks(template, data, remarksResponse)
+	}
 	if err != nil {
-		// Устанавливаем статус ready при ошибке
-		if updateErr := pt.setProjectStatusReady(ctx, project.ID); updateErr != nil {
-			log.Printf("Failed to set project status to ready after error: %v", updateErr)
-		}
-		return fmt.Errorf("failed to upload PDF file to S3: %w", err)
+		return fmt.Errorf("failed to generate %s report: %w", pt.reportFormat(), err)
 	}
 
-	// Сохраняем запись о файле в БД
-	_, err = pt.repo.CreateProjectFile(ctx, project.ID, "remarks_report.pdf", "Отчет по замечаниям.pdf", objectName, int64(pdfBuffer.Len()), ".pdf", db.FileTypeRemarksClustered)
+	if err := pt.saveReport(ctx, project.ID, report, pt.reportFormat(), "remarks_report", "Отчет по замечаниям", db.FileTypeRemarksClustered); err != nil {
+		return err
+	}
+	pt.reportProgress(ctx, "uploaded remarks report", 4, remarksSteps)
+
+	// Таблица замечаний формируется всегда - эксперты фильтруют и сортируют ее в Excel
+	table, err := pt.generateExcelFromRemarks(remarksResponse)
 	if err != nil {
-		// Устанавливаем статус ready при ошибке
-		if updateErr := pt.setProjectStatusReady(ctx, project.ID); updateErr != nil {
-			log.Printf("Failed to set project status to ready after error: %v", updateErr)
-		}
-		return fmt.Errorf("failed to create project file record: %w", err)
+		return fmt.Errorf("failed to generate Excel report: %w", err)
 	}
 
-	log.Printf("Successfully generated and uploaded PDF report %s to S3", objectName)
+	if err := pt.saveReport(ctx, project.ID, table, ReportFormatXLSX, "remarks_report", "Отчет по замечаниям", db.FileTypeRemarksClustered); err != nil {
+		return err
+	}
+	pt.reportProgress(ctx, "uploaded remarks table", 5, remarksSteps)
 
 	log.Printf("Successfully processed remarks for project %d", pt.projectID)
 
 	// Устанавливаем статус ready после успешной обработки
-	if err := pt.setProjectStatusReady(ctx, project.ID); err != nil {
+	if err := pt.finishProcessing(ctx, project.ID, "remarks processed"); err != nil {
 		log.Printf("Failed to set project status to ready: %v", err)
 		return fmt.Errorf("failed to set project status to ready: %w", err)
 	}
@@ -516,13 +755,30 @@ func (pt *ProjectProcessorTask) processRemarks(ctx context.Context, project *db.
 	return nil
 }
 
-// setProjectStatusReady устанавливает статус проекта на ready
-func (pt *ProjectProcessorTask) setProjectStatusReady(ctx context.Context, projectID int32) error {
-	_, err := pt.repo.UpdateProjectStatus(ctx, projectID, db.ProjectStatusReady)
+// sleepContext ждет указанное время, прерываясь при отмене контекста
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// finishProcessing возвращает конвейер задачи в ready
+func (pt *ProjectProcessorTask) finishProcessing(ctx context.Context, projectID int32, reason string) error {
+	_, err := pt.status.Finish(ctx, projectID, pt.kind, projectstate.ActorWorker, reason)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Конвейер уже вернули в ready (отмена задачи или сброс администратором)
+		log.Printf("Project %d %s pipeline is no longer processing, leaving status unchanged", projectID, pt.kind)
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update project status to ready: %w", err)
+		return fmt.Errorf("failed to update %s pipeline status to ready: %w", pt.kind, err)
 	}
-	log.Printf("Project %d status set to ready", projectID)
 	return nil
 }
 
@@ -539,43 +795,43 @@ func (pt *ProjectProcessorTask) generateChecklist(ctx context.Context, project *
 	if len(docFiles) == 0 {
 		log.Printf("No documentation files found for project %d", pt.projectID)
 		// Устанавливаем статус ready если нет файлов для обработки
-		return pt.setProjectStatusReady(ctx, project.ID)
+		return pt.finishProcessing(ctx, project.ID, "no documentation files to check")
 	}
 
 	// Создаем RAG-систему
-	ragConfig := RAGConfig{
-		LLMAPIURL:    "http://89.108.116.240:11434/api/chat",
-		LLMModelName: "qwen3-8b:latest",
-		MaxChunkSize: 700,
-		ChunkOverlap: 150,
-		TopK:         5,
-		RequestDelay: 500 * time.Millisecond,
+	rag, err := NewRAGSystem(pt.rag)
+	if err != nil {
+		return fmt.Errorf("failed to create RAG system: %w", err)
 	}
 
-	rag := NewRAGSystem(ragConfig)
+	// Чанки файлов берутся из индекса документации, файлы без актуального индекса индексируются сейчас
+	indexer := newDocumentIndexer(pt.repo, pt.storage, rag)
+	index, err := indexer.projectIndex(ctx, pt.projectID)
+	if err != nil {
+		return err
+	}
 
-	// Обрабатываем каждый файл документации
-	for _, docFile := range docFiles {
+	for i, docFile := range docFiles {
 		log.Printf("Processing documentation file: %s", docFile.Filename)
+		pt.reportProgress(ctx, fmt.Sprintf("indexing documentation %d/%d", i+1, len(docFiles)), i+1, len(docFiles))
 
-		// Скачиваем файл из S3
-		fileReader, err := pt.storage.DownloadFile(ctx, docFile.FilePath)
-		if err != nil {
-			log.Printf("Failed to download file %s: %v", docFile.Filename, err)
-			continue
+		var entry *db.DocumentIndex
+		if existing, ok := index[docFile.ID]; ok {
+			entry = &existing
 		}
-		defer fileReader.Close()
 
-		// Извлекаем текст из файла
-		text, err := rag.extractTextFromFile(fileReader, docFile.OriginalName)
-		if err != nil {
-			log.Printf("Failed to extract text from file %s: %v", docFile.Filename, err)
+		chunks, err := indexer.fileChunks(ctx, docFile, entry)
+		if errors.Is(err, errDocumentUnavailable) {
+			log.Printf("Skipping documentation file %s: %v", docFile.Filename, err)
 			continue
 		}
+		if err != nil {
+			return fmt.Errorf("failed to index documentation file %s: %w", docFile.Filename, err)
+		}
 
-		// Разбиваем на чанки и добавляем в RAG-систему
-		chunks := rag.splitTextIntoChunks(text, docFile.OriginalName)
-		rag.documents = append(rag.documents, chunks...)
+		if err := rag.addDocuments(ctx, chunks); err != nil {
+			return fmt.Errorf("failed to index documentation file %s: %w", docFile.Filename, err)
+		}
 
 		log.Printf("Added %d chunks from file %s", len(chunks), docFile.Filename)
 	}
@@ -611,10 +867,14 @@ func (pt *ProjectProcessorTask) createBasicChecklist(ctx context.Context, projec
 	var checklistResults []ChecklistItem
 
 	// Обрабатываем каждый критерий
-	for _, criterion := range basicCriteria {
+	for i, criterion := range basicCriteria {
 		log.Printf("Processing criterion: %s", criterion)
+		pt.reportProgress(ctx, fmt.Sprintf("criterion %d/%d", i+1, len(basicCriteria)), i+1, len(basicCriteria))
 
-		result, err := rag.processCriterion(criterion)
+		result, err := rag.processCriterion(ctx, criterion)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		if err != nil {
 			log.Printf("Failed to process criterion '%s': %v", criterion, err)
 			result = &ChecklistItem{
@@ -632,7 +892,9 @@ func (pt *ProjectProcessorTask) createBasicChecklist(ctx context.Context, projec
 		checklistResults = append(checklistResults, *result)
 
 		// Небольшая задержка между запросами
-		time.Sleep(rag.config.RequestDelay)
+		if err := sleepContext(ctx, rag.config.RequestDelay); err != nil {
+			return err
+		}
 	}
 
 	// Сохраняем результаты в JSON файл
@@ -644,7 +906,7 @@ func (pt *ProjectProcessorTask) processChecklistFile(ctx context.Context, projec
 	// Скачиваем файл чек-листа
 	fileReader, err := pt.storage.DownloadFile(ctx, checklistFile.FilePath)
 	if err != nil {
-		return fmt.Errorf("failed to download checklist file: %w", err)
+		return Retryable(fmt.Errorf("failed to download checklist file: %w", err))
 	}
 	defer fileReader.Close()
 
@@ -681,10 +943,14 @@ func (pt *ProjectProcessorTask) processChecklistFile(ctx context.Context, projec
 	var checklistResults []ChecklistItem
 
 	// Обрабатываем каждый критерий
-	for _, criterion := range criteria {
+	for i, criterion := range criteria {
 		log.Printf("Processing criterion: %s", criterion)
+		pt.reportProgress(ctx, fmt.Sprintf("criterion %d/%d", i+1, len(criteria)), i+1, len(criteria))
 
-		result, err := rag.processCriterion(criterion)
+		result, err := rag.processCriterion(ctx, criterion)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		if err != nil {
 			log.Printf("Failed to process criterion '%s': %v", criterion, err)
 			result = &ChecklistItem{
@@ -702,7 +968,9 @@ func (pt *ProjectProcessorTask) processChecklistFile(ctx context.Context, projec
 		checklistResults = append(checklistResults, *result)
 
 		// Небольшая задержка между запросами
-		time.Sleep(rag.config.RequestDelay)
+		if err := sleepContext(ctx, rag.config.RequestDelay); err != nil {
+			return err
+		}
 	}
 
 	// Сохраняем результаты
@@ -742,89 +1010,460 @@ func (pt *ProjectProcessorTask) saveChecklistResults(ctx context.Context, projec
 	}
 
 	log.Printf("Successfully saved checklist report %s to S3", objectName)
+	pt.reportProgress(ctx, "uploaded checklist report", 0, 0)
 
 	// Устанавливаем статус ready после успешной обработки
-	return pt.setProjectStatusReady(ctx, project.ID)
+	return pt.finishProcessing(ctx, project.ID, "checklist generated")
 }
 
-// generateFinalReport генерирует итоговый отчет
+// finalReportSteps количество этапов генерации итогового отчета, о которых сообщается прогресс
+const finalReportSteps = 4
+
+// FinalReportSection раздел итогового отчета с кластеризованными замечаниями
+type FinalReportSection struct {
+	Section string
+	Groups  []FinalReportGroup
+}
+
+// FinalReportGroup группа замечаний раздела
+// Duplicates - количество исходных замечаний, объединенных в каждое замечание Remarks
+type FinalReportGroup struct {
+	Name       string
+	Remarks    []string
+	Duplicates []int
+}
+
+// FinalReport содержимое итогового отчета проекта
+type FinalReport struct {
+	ProjectName string
+	Sections    []FinalReportSection
+	Checklist   []ChecklistItem
+}
+
+// generateFinalReport собирает кластеризованные замечания и последние результаты проверки чек-листа
+// в итоговый отчет и сохраняет его как файл проекта типа final_report
 func (pt *ProjectProcessorTask) generateFinalReport(ctx context.Context, project *db.Project) error {
 	log.Printf("Generating final report for project %d", pt.projectID)
 
-	// Имитируем генерацию отчета
-	time.Sleep(4 * time.Second)
+	remarks, err := pt.repo.GetRemarksByProject(ctx, project.ID)
+	if err != nil {
+		return Retryable(fmt.Errorf("failed to get remarks: %w", err))
+	}
+	pt.reportProgress(ctx, "remarks collected", 1, finalReportSteps)
 
-	// TODO: generate final report
+	checklist, err := pt.loadChecklistResults(ctx, project.ID)
+	if err != nil {
+		return err
+	}
+	pt.reportProgress(ctx, "checklist results collected", 2, finalReportSteps)
+
+	if len(remarks) == 0 && len(checklist) == 0 {
+		return fmt.Errorf("no remarks or checklist results found for project %d", project.ID)
+	}
+
+	report := buildFinalReport(project, remarks, checklist)
+	template, err := pt.reportTemplate(ctx, project.ID, finalReportData(project, report))
+	if err != nil {
+		return err
+	}
+
+	var document *bytes.Buffer
+	if pt.reportFormat() == ReportFormatDOCX {
+		document, err = pt.generateDOCXFromFinalReport(template, report)
+	} else {
+		document, err = pt.generatePDFFromFinalReport(template, report)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate %s report: %w", pt.reportFormat(), err)
+	}
+	pt.reportProgress(ctx, "final report rendered", 3, finalReportSteps)
+
+	if err := pt.saveReport(ctx, project.ID, document, pt.reportFormat(), "final_report", "Итоговый отчет", db.FileTypeFinalReport); err != nil {
+		return err
+	}
 
 	log.Printf("Successfully generated final report for project %d", pt.projectID)
-	return nil
+	pt.reportProgress(ctx, "final report generated", 4, finalReportSteps)
+
+	// Устанавливаем статус ready после успешной обработки
+	return pt.finishProcessing(ctx, project.ID, "final report generated")
+}
+
+// loadChecklistResults загружает результаты последней проверки чек-листа
+// Результаты проверки сохраняются как JSON файлы типа final_report, см. saveChecklistResults
+func (pt *ProjectProcessorTask) loadChecklistResults(ctx context.Context, projectID int32) ([]ChecklistItem, error) {
+	files, err := pt.repo.GetProjectFilesByType(ctx, projectID, db.FileTypeFinalReport)
+	if err != nil {
+		return nil, Retryable(fmt.Errorf("failed to get checklist reports: %w", err))
+	}
+
+	// Файлы отсортированы от новых к старым
+	var latest *db.ProjectFile
+	for i := range files {
+		if files[i].Extension == ".json" {
+			latest = &files[i]
+			break
+		}
+	}
+	if latest == nil {
+		log.Printf("No checklist results found for project %d", projectID)
+		return nil, nil
+	}
+
+	fileReader, err := pt.storage.DownloadFile(ctx, latest.FilePath)
+	if err != nil {
+		return nil, Retryable(fmt.Errorf("failed to download checklist report %s: %w", latest.Filename, err))
+	}
+	defer fileReader.Close()
+
+	var report struct {
+		Results []ChecklistItem `json:"results"`
+	}
+	if err := json.NewDecoder(fileReader).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to parse checklist report %s: %w", latest.Filename, err)
+	}
+
+	return report.Results, nil
 }
 
-// saveRemarksToDB сохраняет замечания в базу данных
+// buildFinalReport группирует замечания по разделам и группам в порядке их появления
+// Замечания приходят от новых к старым, в отчет они попадают в хронологическом порядке
+func buildFinalReport(project *db.Project, remarks []db.Remark, checklist []ChecklistItem) FinalReport {
+	report := FinalReport{
+		ProjectName: project.Name,
+		Checklist:   checklist,
+	}
+
+	sectionIndex := make(map[string]int)
+	groupIndex := make(map[[2]string]int)
+	for i := len(remarks) - 1; i >= 0; i-- {
+		remark := remarks[i]
+
+		si, ok := sectionIndex[remark.Section]
+		if !ok {
+			si = len(report.Sections)
+			sectionIndex[remark.Section] = si
+			report.Sections = append(report.Sections, FinalReportSection{Section: remark.Section})
+		}
+		section := &report.Sections[si]
+
+		key := [2]string{remark.Section, remark.Subsection}
+		gi, ok := groupIndex[key]
+		if !ok {
+			gi = len(section.Groups)
+			groupIndex[key] = gi
+			section.Groups = append(section.Groups, FinalReportGroup{Name: remark.Subsection})
+		}
+		group := &section.Groups[gi]
+		group.Remarks = append(group.Remarks, remark.Content)
+		group.Duplicates = append(group.Duplicates, int(remark.Duplicates))
+	}
+
+	// Разделы выводятся в порядке таксономии, внутри одной позиции - по названию
+	sort.SliceStable(report.Sections, func(i, j int) bool {
+		oi, oj := utils.SectionOrder(report.Sections[i].Section), utils.SectionOrder(report.Sections[j].Section)
+		if oi != oj {
+			return oi < oj
+		}
+		return utils.SectionTitle(report.Sections[i].Section) < utils.SectionTitle(report.Sections[j].Section)
+	})
+
+	return report
+}
+
+// generateDOCXFromFinalReport генерирует итоговый отчет в формате DOCX для редактирования в Word
+func (pt *ProjectProcessorTask) generateDOCXFromFinalReport(template *reports.Template, report FinalReport) (*bytes.Buffer, error) {
+	doc := utils.NewDocxDocument()
+
+	doc.BoldParagraph(template.Organization)
+	doc.Heading(1, "Итоговый отчёт по проекту")
+	doc.Paragraph(fmt.Sprintf("Проект: %s", report.ProjectName))
+	doc.Paragraph(fmt.Sprintf("Дата: %s", time.Now().Format("02.01.2006")))
+
+	if template.FinalReportIntro != "" {
+		doc.PageBreak()
+		doc.Heading(1, "Введение")
+		doc.Paragraph(template.FinalReportIntro)
+	}
+
+	// Раздел 1: кластеризованные замечания
+	doc.PageBreak()
+	doc.Heading(1, "1. Замечания")
+	if len(report.Sections) == 0 {
+		doc.Paragraph("Замечания по проекту не обработаны.")
+	}
+	for _, section := range report.Sections {
+		doc.Heading(2, utils.SectionTitle(section.Section))
+		for _, group := range section.Groups {
+			if group.Name != "" {
+				doc.Heading(3, group.Name)
+			}
+			rows := make([][]string, 0, len(group.Remarks))
+			for i, remark := range group.Remarks {
+				rows = append(rows, []string{fmt.Sprintf("%d", i+1), remark})
+			}
+			doc.Table([]string{"№", "Замечание"}, []int{10, 90}, rows)
+		}
+	}
+
+	// Раздел 2: результаты проверки чек-листа
+	doc.PageBreak()
+	doc.Heading(1, "2. Проверка документации по чек-листу")
+	if len(report.Checklist) == 0 {
+		doc.Paragraph("Проверка документации по чек-листу не выполнялась.")
+	} else {
+		rows := make([][]string, 0, len(report.Checklist))
+		for i, item := range report.Checklist {
+			title, ok := checklistStatusTitles[item.Status]
+			if !ok {
+				title = item.Status
+			}
+			rows = append(rows, []string{fmt.Sprintf("%d", i+1), item.Criterion, title, item.Answer})
+		}
+		doc.Table([]string{"№", "Критерий", "Статус", "Обоснование"}, []int{6, 30, 14, 50}, rows)
+	}
+
+	if template.FinalReportConclusion != "" {
+		doc.Heading(1, "Заключение")
+		doc.Paragraph(template.FinalReportConclusion)
+	}
+
+	return doc.Bytes()
+}
+
+// checklistStatusTitles названия статусов проверки критериев чек-листа
+var checklistStatusTitles = map[string]string{
+	"confirmed":             "Подтверждено",
+	"partial":               "Частично",
+	"indirect":              "Косвенно",
+	"not_found":             "Не найдено",
+	"requires_confirmation": "Требует подтверждения",
+}
+
+// generatePDFFromFinalReport генерирует PDF итогового отчета в стиле отчета по замечаниям
+func (pt *ProjectProcessorTask) generatePDFFromFinalReport(template *reports.Template, report FinalReport) (*bytes.Buffer, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+
+	// Устанавливаем шрифт с поддержкой кириллицы
+	reports.AddFonts(pdf)
+	pdf.SetMargins(30, 20, 10)
+
+	// Титульная страница
+	pdf.AddPage()
+	writeTitleOrganization(pdf, template)
+
+	pdf.SetFont(reports.FontFamily, "B", 16)
+	pdf.Cell(0, 20, "Итоговый отчёт по проекту")
+	pdf.Ln(15)
+
+	pdf.SetFont(reports.FontFamily, "", 14)
+	pdf.Cell(0, 20, fmt.Sprintf("Проект: %s", report.ProjectName))
+	pdf.Ln(15)
+
+	pdf.SetFont(reports.FontFamily, "", 12)
+	pdf.Cell(0, 20, fmt.Sprintf("Дата: %s", time.Now().Format("02.01.2006")))
+	pdf.Ln(30)
+
+	writeText := func(text string, height float64) {
+		for _, line := range pdf.SplitText(text, 150) {
+			pdf.Cell(0, height, line)
+			pdf.Ln(height)
+		}
+	}
+
+	if template.FinalReportIntro != "" {
+		pdf.AddPage()
+		pdf.SetFont(reports.FontFamily, "B", 14)
+		pdf.Cell(0, 15, "ВВЕДЕНИЕ")
+		pdf.Ln(15)
+
+		pdf.SetFont(reports.FontFamily, "", 12)
+		writeText(template.FinalReportIntro, 8)
+	}
+
+	// Раздел 1: кластеризованные замечания
+	pdf.AddPage()
+	pdf.SetFont(reports.FontFamily, "B", 14)
+	pdf.Cell(0, 15, "1. ЗАМЕЧАНИЯ")
+	pdf.Ln(15)
+
+	if len(report.Sections) == 0 {
+		pdf.SetFont(reports.FontFamily, "", 12)
+		writeText("Замечания по проекту не обработаны.", 8)
+	}
+	for _, section := range report.Sections {
+		pdf.SetFont(reports.FontFamily, "B", 13)
+		writeText(utils.SectionTitle(section.Section), 12)
+
+		for _, group := range section.Groups {
+			if group.Name != "" {
+				pdf.SetFont(reports.FontFamily, "B", 11)
+				writeText(group.Name, 10)
+			}
+
+			pdf.SetFont(reports.FontFamily, "", 11)
+			for _, remark := range group.Remarks {
+				writeText("• "+remark, 8)
+			}
+			pdf.Ln(5)
+		}
+	}
+
+	// Раздел 2: результаты проверки чек-листа
+	pdf.AddPage()
+	pdf.SetFont(reports.FontFamily, "B", 14)
+	pdf.Cell(0, 15, "2. ПРОВЕРКА ДОКУМЕНТАЦИИ ПО ЧЕК-ЛИСТУ")
+	pdf.Ln(15)
+
+	if len(report.Checklist) == 0 {
+		pdf.SetFont(reports.FontFamily, "", 12)
+		writeText("Проверка документации по чек-листу не выполнялась.", 8)
+	}
+
+	// Сводка по статусам критериев
+	counts := make(map[string]int)
+	for _, item := range report.Checklist {
+		counts[item.Status]++
+	}
+	for _, status := range []string{"confirmed", "partial", "indirect", "not_found", "requires_confirmation"} {
+		if counts[status] > 0 {
+			pdf.SetFont(reports.FontFamily, "", 12)
+			writeText(fmt.Sprintf("%s: %d", checklistStatusTitles[status], counts[status]), 8)
+		}
+	}
+	pdf.Ln(5)
+
+	for i, item := range report.Checklist {
+		title, ok := checklistStatusTitles[item.Status]
+		if !ok {
+			title = item.Status
+		}
+
+		pdf.SetFont(reports.FontFamily, "B", 11)
+		writeText(fmt.Sprintf("%d. %s — %s", i+1, item.Criterion, title), 10)
+
+		pdf.SetFont(reports.FontFamily, "", 11)
+		writeText(item.Answer, 8)
+		pdf.Ln(5)
+	}
+
+	if template.FinalReportConclusion != "" {
+		pdf.SetFont(reports.FontFamily, "B", 14)
+		pdf.Cell(0, 15, "ЗАКЛЮЧЕНИЕ")
+		pdf.Ln(15)
+
+		pdf.SetFont(reports.FontFamily, "", 12)
+		writeText(template.FinalReportConclusion, 8)
+	}
+
+	// Сохраняем в буфер
+	buffer := new(bytes.Buffer)
+	if err := pdf.Output(buffer); err != nil {
+		return nil, fmt.Errorf("failed to generate PDF: %w", err)
+	}
+
+	return buffer, nil
+}
+
+// saveRemarksToDB заменяет замечания проекта в базе данных результатом обработки
+// Повторная обработка (новая загрузка или повтор задачи) не добавляет замечания к прежним
 func (pt *ProjectProcessorTask) saveRemarksToDB(ctx context.Context, projectID int32, remarksResponse RemarksResponse) error {
-	for section, items := range remarksResponse {
-		for _, item := range items {
-			// Создаем замечание в БД
-			_, err := pt.repo.CreateRemark(ctx, db.CreateRemarkParams{
+	var remarks []db.CreateRemarkParams
+	for _, section := range remarksResponse.Sections() {
+		for _, item := range remarksResponse[section] {
+			remarks = append(remarks, db.CreateRemarkParams{
 				ProjectID:  projectID,
 				Direction:  "",
 				Section:    section,
 				Subsection: item.GroupName, // Пока оставляем пустым, можно добавить логику для подразделов
 				Content:    item.SynthesizedRemark,
+				Duplicates: int32(len(item.OriginalDuplicates)),
 			})
-			if err != nil {
-				return fmt.Errorf("failed to create remark for direction %s, section %s: %w", section, item.GroupName, err)
-			}
 		}
 	}
-	return nil
+	return pt.repo.ReplaceRemarks(ctx, projectID, remarks)
 }
 
+// remarksSheetHeaders заголовки столбцов таблицы кластеризованных замечаний
+var remarksSheetHeaders = []string{"Раздел", "Группа", "Синтезированное замечание", "Оригинальные замечания"}
+
 // generateExcelFromRemarks генерирует Excel файл из замечаний
+// Каждый раздел выводится на отдельный лист с закрепленной строкой заголовков и переносом текста
 func (pt *ProjectProcessorTask) generateExcelFromRemarks(remarksResponse RemarksResponse) (*bytes.Buffer, error) {
-	// Создаем новый Excel файл
 	f := excelize.NewFile()
 	defer f.Close()
 
-	// Создаем лист для замечаний
-	sheetName := "Замечания"
-	f.NewSheet(sheetName)
-
-	// Устанавливаем заголовки
-	headers := []string{"Раздел", "Подраздел", "Синтезированное замечание", "Оригинальные замечания"}
-	for i, header := range headers {
-		cell := fmt.Sprintf("%c1", 'A'+i)
-		f.SetCellValue(sheetName, cell, header)
-	}
-
-	// Заполняем данными
-	row := 2
-	for section, items := range remarksResponse {
-		for _, item := range items {
-			// Раздел
-			f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), section)
-			// Подраздел
-			f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), item.GroupName)
-			// Синтезированное замечание
-			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), item.SynthesizedRemark)
-			// Оригинальные замечания (объединяем в одну строку)
-			originalRemarks := ""
-			for i, remark := range item.OriginalDuplicates {
-				if i > 0 {
-					originalRemarks += "; "
-				}
-				originalRemarks += remark
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"F0F0F0"}},
+		Alignment: &excelize.Alignment{WrapText: true, Vertical: "center"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create header style: %w", err)
+	}
+	cellStyle, err := f.NewStyle(&excelize.Style{
+		Alignment: &excelize.Alignment{WrapText: true, Vertical: "top"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cell style: %w", err)
+	}
+
+	sections := remarksResponse.Sections()
+
+	// Без замечаний оставляем один пустой лист с заголовками
+	if len(sections) == 0 {
+		sections = append(sections, "")
+	}
+
+	// Имя листа по умолчанию занято до его удаления в конце
+	defaultSheet := f.GetSheetName(0)
+	usedNames := map[string]bool{strings.ToLower(defaultSheet): true}
+	for _, section := range sections {
+		sheetName := excelSheetName(utils.SectionTitle(section), usedNames)
+		if _, err := f.NewSheet(sheetName); err != nil {
+			return nil, fmt.Errorf("failed to create sheet %q: %w", sheetName, err)
+		}
+
+		for i, header := range remarksSheetHeaders {
+			cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+			f.SetCellValue(sheetName, cell, header)
+		}
+
+		row := 2
+		for _, item := range remarksResponse[section] {
+			values := []string{utils.SectionTitle(section), item.GroupName, item.SynthesizedRemark, strings.Join(item.OriginalDuplicates, "\n")}
+			for i, value := range values {
+				cell, _ := excelize.CoordinatesToCellName(i+1, row)
+				f.SetCellValue(sheetName, cell, value)
 			}
-			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), originalRemarks)
 			row++
 		}
+
+		lastColumn, _ := excelize.ColumnNumberToName(len(remarksSheetHeaders))
+		f.SetCellStyle(sheetName, "A1", lastColumn+"1", headerStyle)
+		if row > 2 {
+			f.SetCellStyle(sheetName, "A2", fmt.Sprintf("%s%d", lastColumn, row-1), cellStyle)
+		}
+
+		f.SetColWidth(sheetName, "A", "B", 25)
+		f.SetColWidth(sheetName, "C", "D", 60)
+
+		// Закрепляем строку заголовков
+		if err := f.SetPanes(sheetName, &excelize.Panes{
+			Freeze:      true,
+			YSplit:      1,
+			TopLeftCell: "A2",
+			ActivePane:  "bottomLeft",
+		}); err != nil {
+			return nil, fmt.Errorf("failed to freeze header row on sheet %q: %w", sheetName, err)
+		}
 	}
 
-	// Автоматически подгоняем ширину столбцов
-	for i := 0; i < len(headers); i++ {
-		col := string(rune('A' + i))
-		f.SetColWidth(sheetName, col, col, 30)
+	// Удаляем лист, созданный по умолчанию
+	if err := f.DeleteSheet(defaultSheet); err != nil {
+		return nil, fmt.Errorf("failed to delete default sheet: %w", err)
 	}
+	f.SetActiveSheet(0)
 
 	// Сохраняем в буфер
 	buffer := new(bytes.Buffer)
@@ -835,82 +1474,424 @@ func (pt *ProjectProcessorTask) generateExcelFromRemarks(remarksResponse Remarks
 	return buffer, nil
 }
 
+// excelSheetName приводит название раздела к допустимому имени листа Excel:
+// не длиннее 31 символа, без символов []:*?/\ и уникальное в пределах книги
+func excelSheetName(section string, used map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(section))
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = "Замечания"
+	}
+
+	base := []rune(name)
+	if len(base) > 31 {
+		base = base[:31]
+	}
+	name = string(base)
+
+	for i := 2; used[strings.ToLower(name)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		trimmed := base
+		if len(trimmed)+len(suffix) > 31 {
+			trimmed = trimmed[:31-len(suffix)]
+		}
+		name = string(trimmed) + suffix
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// generateDOCXFromRemarks генерирует отчет по замечаниям в формате DOCX для редактирования в Word
+// Структура совпадает с PDF отчетом: категории - главы, группы - подразделы с таблицей исходных замечаний
+func (pt *ProjectProcessorTask) generateDOCXFromRemarks(template *reports.Template, data reports.ReportData, remarksResponse RemarksResponse) (*bytes.Buffer, error) {
+	doc := utils.NewDocxDocument()
+
+	// Титульная страница
+	doc.BoldParagraph(template.Organization)
+	doc.Heading(1, "Отчёт по результатам анализа замечаний")
+	doc.Paragraph(fmt.Sprintf("Проект: %s", data.ProjectName))
+	doc.Paragraph(fmt.Sprintf("Дата: %s", data.GeneratedAt.Format("02.01.2006")))
+
+	// Сводка по замечаниям и диаграмма распределения по разделам
+	doc.Heading(2, "Сводка по замечаниям")
+	doc.Table(remarksSummaryHeaders, []int{55, 15, 15, 15}, remarksSummaryRows(data))
+
+	doc.Heading(2, "Распределение замечаний по разделам")
+	labels := make([]string, 0, len(data.Sections))
+	values := make([]int, 0, len(data.Sections))
+	for _, section := range data.Sections {
+		labels = append(labels, section.Title)
+		values = append(values, section.Remarks)
+	}
+	doc.BarChart(labels, values)
+
+	doc.PageBreak()
+	doc.Heading(1, "Введение")
+	doc.Paragraph(template.RemarksIntro)
+
+	// Основные разделы
+	sections := remarksResponse.Sections()
+
+	for _, section := range sections {
+		doc.Heading(1, utils.SectionTitle(section))
+
+		for _, item := range remarksResponse[section] {
+			doc.Heading(2, item.GroupName)
+
+			if item.SynthesizedRemark != "" {
+				doc.BoldParagraph("Краткая сводка:")
+				doc.Paragraph(item.SynthesizedRemark)
+			}
+
+			if len(item.OriginalDuplicates) > 0 {
+				doc.BoldParagraph("Оригинальные замечания:")
+				rows := make([][]string, 0, len(item.OriginalDuplicates))
+				for i, remark := range item.OriginalDuplicates {
+					rows = append(rows, []string{fmt.Sprintf("%d", i+1), remark})
+				}
+				doc.Table([]string{"№", "Замечание"}, []int{10, 90}, rows)
+			}
+		}
+	}
+
+	doc.Heading(1, "Заключение")
+	doc.Paragraph(template.RemarksConclusion)
+
+	return doc.Bytes()
+}
+
+// pdfTOCEntry пункт оглавления PDF отчета
+type pdfTOCEntry struct {
+	Title string
+	Level int // 1 - раздел, 2 - группа
+	Page  int
+}
+
+// pdfReportLayout параметры оформления PDF отчета, общие для обоих проходов рендеринга
+type pdfReportLayout struct {
+	template   *reports.Template
+	data       reports.ReportData
+	totalPages int // 0 на первом проходе, пока число страниц неизвестно
+}
+
 // generatePDFFromRemarks генерирует PDF отчет в стиле ГОСТ из замечаний
-func (pt *ProjectProcessorTask) generatePDFFromRemarks(remarksResponse RemarksResponse) (*bytes.Buffer, error) {
-	// Создаем новый PDF документ
+// Отчет рендерится дважды: первый проход определяет страницы разделов и общее число страниц,
+// второй заполняет ими оглавление и колонтитулы. Оглавление занимает одинаковое место в обоих
+// проходах, поэтому разбиение на страницы совпадает
+func (pt *ProjectProcessorTask) generatePDFFromRemarks(template *reports.Template, data reports.ReportData, remarksResponse RemarksResponse) (*bytes.Buffer, error) {
+	sections := remarksResponse.Sections()
+
+	var toc []pdfTOCEntry
+	for _, section := range sections {
+		toc = append(toc, pdfTOCEntry{Title: utils.SectionTitle(section), Level: 1})
+		for _, item := range remarksResponse[section] {
+			toc = append(toc, pdfTOCEntry{Title: item.GroupName, Level: 2})
+		}
+	}
+
+	layout := pdfReportLayout{template: template, data: data}
+	pdf := pt.renderRemarksPDF(layout, sections, remarksResponse, toc)
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("failed to generate PDF: %w", err)
+	}
+
+	layout.totalPages = pdf.PageNo()
+	pdf = pt.renderRemarksPDF(layout, sections, remarksResponse, toc)
+
+	// Сохраняем в буфер
+	buffer := new(bytes.Buffer)
+	if err := pdf.Output(buffer); err != nil {
+		return nil, fmt.Errorf("failed to generate PDF: %w", err)
+	}
+
+	return buffer, nil
+}
+
+// writeTitleOrganization выводит на титульной странице логотип и название организации из шаблона
+func writeTitleOrganization(pdf *gofpdf.Fpdf, template *reports.Template) {
+	if logo, logoType := template.Logo(); logo != nil {
+		options := gofpdf.ImageOptions{ImageType: logoType, ReadDpi: true}
+		pdf.RegisterImageOptionsReader("logo", options, bytes.NewReader(logo))
+		pdf.ImageOptions("logo", pdf.GetX(), pdf.GetY(), 40, 0, true, options, 0, "")
+		pdf.Ln(5)
+	}
+
+	pdf.SetFont(reports.FontFamily, "B", 18)
+	pdf.Cell(0, 20, template.Organization)
+	pdf.Ln(15)
+}
+
+// newReportPDF создает PDF документ с кириллическими шрифтами, полями по ГОСТ и колонтитулами:
+// название проекта сверху и "стр. N из M" снизу на всех страницах, кроме титульной
+func newReportPDF(layout pdfReportLayout) *gofpdf.Fpdf {
 	pdf := gofpdf.New("P", "mm", "A4", "")
 
 	// Устанавливаем шрифт с поддержкой кириллицы
-	pdf.AddUTF8Font("DejaVu", "", "DejaVuSans.ttf")
-	pdf.AddUTF8Font("DejaVu", "B", "DejaVuSans-Bold.ttf")
+	reports.AddFonts(pdf)
 
 	// Устанавливаем отступы (ГОСТ-подобные)
 	pdf.SetMargins(30, 20, 10) // left, top, right
+	pdf.SetAutoPageBreak(true, 20)
+
+	pdf.SetHeaderFunc(func() {
+		if pdf.PageNo() == 1 {
+			return
+		}
+		pdf.SetFont(reports.FontFamily, "", 9)
+		pdf.SetTextColor(100, 100, 100)
+		pdf.SetY(8)
+		pdf.CellFormat(0, 6, layout.data.ProjectName, "B", 1, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetY(20)
+	})
+
+	pdf.SetFooterFunc(func() {
+		if pdf.PageNo() == 1 {
+			return
+		}
+		pdf.SetY(-15)
+		pdf.SetFont(reports.FontFamily, "", 9)
+		pdf.CellFormat(0, 10, fmt.Sprintf("стр. %d из %d", pdf.PageNo(), layout.totalPages), "", 0, "C", false, 0, "")
+	})
+
+	return pdf
+}
+
+// remarksSummaryHeaders заголовки таблицы сводки по замечаниям
+var remarksSummaryHeaders = []string{"Раздел", "Групп", "Замечаний", "Объединено дубликатов"}
+
+// remarksSummaryRows формирует строки сводки по замечаниям: разделы и итоговая строка
+func remarksSummaryRows(data reports.ReportData) [][]string {
+	rows := make([][]string, 0, len(data.Sections)+1)
+	for _, section := range data.Sections {
+		rows = append(rows, []string{section.Title, strconv.Itoa(section.Groups), strconv.Itoa(section.Remarks), strconv.Itoa(section.Merged)})
+	}
+	return append(rows, []string{"Итого", strconv.Itoa(data.Groups), strconv.Itoa(data.Remarks), strconv.Itoa(data.Merged)})
+}
+
+// pdfChartWidth длина самого длинного столбца диаграммы в PDF в миллиметрах
+const pdfChartWidth = 80.0
+
+// maxSectionRemarks возвращает наибольшее количество замечаний в разделе - масштаб диаграммы
+func maxSectionRemarks(data reports.ReportData) int {
+	maxRemarks := 0
+	for _, section := range data.Sections {
+		maxRemarks = max(maxRemarks, section.Remarks)
+	}
+	return maxRemarks
+}
+
+// fitPDFLine обрезает текст до одной строки заданной ширины, добавляя многоточие
+func fitPDFLine(pdf *gofpdf.Fpdf, text string, width float64) string {
+	if lines := pdf.SplitText(text, width); len(lines) <= 1 {
+		return text
+	}
+	return strings.TrimSpace(pdf.SplitText(text, width-pdf.GetStringWidth("…"))[0]) + "…"
+}
+
+// writeRemarksSummaryPDF выводит сводку по замечаниям: таблицу количеств по разделам
+// и горизонтальную диаграмму распределения замечаний по разделам
+// Строки имеют фиксированную высоту, чтобы сводка занимала одинаковое место в обоих проходах рендеринга
+func writeRemarksSummaryPDF(pdf *gofpdf.Fpdf, data reports.ReportData) {
+	const rowHeight = 7.0
+	colWidths := []float64{95, 20, 25, 30}
+
+	pdf.SetFont(reports.FontFamily, "B", 12)
+	pdf.Cell(0, 10, "Сводка по замечаниям")
+	pdf.Ln(10)
+
+	pdf.SetFont(reports.FontFamily, "B", 8)
+	pdf.SetFillColor(240, 240, 240)
+	for i, header := range remarksSummaryHeaders {
+		align := "C"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(colWidths[i], rowHeight, header, "1", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	rows := remarksSummaryRows(data)
+	for r, row := range rows {
+		style := ""
+		if r == len(rows)-1 {
+			style = "B"
+		}
+		pdf.SetFont(reports.FontFamily, style, 9)
+		for i, cell := range row {
+			align := "C"
+			if i == 0 {
+				align = "L"
+				cell = fitPDFLine(pdf, cell, colWidths[i]-2)
+			}
+			pdf.CellFormat(colWidths[i], rowHeight, cell, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(8)
+
+	if len(data.Sections) == 0 {
+		return
+	}
+
+	pdf.SetFont(reports.FontFamily, "B", 12)
+	pdf.Cell(0, 10, "Распределение замечаний по разделам")
+	pdf.Ln(10)
+
+	const labelWidth = 70.0
+	maxRemarks := maxSectionRemarks(data)
+	left, _, _, _ := pdf.GetMargins()
+	pdf.SetFillColor(70, 130, 180)
+	for _, section := range data.Sections {
+		y := pdf.GetY()
+
+		pdf.SetFont(reports.FontFamily, "", 8)
+		pdf.CellFormat(labelWidth, rowHeight, fitPDFLine(pdf, section.Title, labelWidth-2), "", 0, "L", false, 0, "")
+
+		// Длина столбца в миллиметрах пропорциональна количеству замечаний раздела
+		barWidth := 0.0
+		if maxRemarks > 0 {
+			barWidth = pdfChartWidth * float64(section.Remarks) / float64(maxRemarks)
+		}
+		if barWidth > 0 {
+			pdf.Rect(left+labelWidth, y+1, barWidth, rowHeight-2, "F")
+		}
+
+		pdf.SetXY(left+labelWidth+barWidth+2, y)
+		pdf.CellFormat(15, rowHeight, strconv.Itoa(section.Remarks), "", 1, "L", false, 0, "")
+	}
+	pdf.SetFillColor(240, 240, 240)
+	pdf.Ln(5)
+}
+
+// renderRemarksPDF выполняет один проход рендеринга отчета по замечаниям
+// Номера страниц разделов и групп записываются в toc
+func (pt *ProjectProcessorTask) renderRemarksPDF(layout pdfReportLayout, sections []string, remarksResponse RemarksResponse, toc []pdfTOCEntry) *gofpdf.Fpdf {
+	pdf := newReportPDF(layout)
+
+	// Внутренние ссылки из оглавления на разделы
+	links := make([]int, len(toc))
+	for i := range links {
+		links[i] = pdf.AddLink()
+	}
 
 	// Добавляем первую страницу
 	pdf.AddPage()
 
 	// Титульная страница
-	pdf.SetFont("DejaVu", "B", 18)
-	pdf.Cell(0, 20, "ПАО «Газпром»")
-	pdf.Ln(15)
+	writeTitleOrganization(pdf, layout.template)
 
-	pdf.SetFont("DejaVu", "B", 16)
+	pdf.SetFont(reports.FontFamily, "B", 16)
 	pdf.Cell(0, 20, "Отчёт по результатам анализа замечаний")
 	pdf.Ln(15)
 
-	pdf.SetFont("DejaVu", "", 14)
-	pdf.Cell(0, 20, fmt.Sprintf("Проект: %d", pt.projectID))
+	pdf.SetFont(reports.FontFamily, "", 14)
+	pdf.Cell(0, 20, fmt.Sprintf("Проект: %s", layout.data.ProjectName))
 	pdf.Ln(15)
 
-	pdf.SetFont("DejaVu", "", 12)
-	pdf.Cell(0, 20, fmt.Sprintf("Дата: %s", time.Now().Format("02.01.2006")))
-	pdf.Ln(30)
+	pdf.SetFont(reports.FontFamily, "", 12)
+	pdf.Cell(0, 20, fmt.Sprintf("Дата: %s", layout.data.GeneratedAt.Format("02.01.2006")))
+	pdf.Ln(25)
+
+	writeRemarksSummaryPDF(pdf, layout.data)
 
 	// Оглавление
 	pdf.AddPage()
-	pdf.SetFont("DejaVu", "B", 16)
+	pdf.SetFont(reports.FontFamily, "B", 16)
 	pdf.Cell(0, 20, "СОДЕРЖАНИЕ")
 	pdf.Ln(20)
 
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	const pageColumn = 15.0
+	for i, entry := range toc {
+		indent := 0.0
+		if entry.Level == 1 {
+			pdf.SetFont(reports.FontFamily, "B", 11)
+		} else {
+			pdf.SetFont(reports.FontFamily, "", 11)
+			indent = 8
+		}
+
+		// Пункт оглавления всегда занимает одну строку, чтобы разбиение на страницы не зависело от прохода
+		titleWidth := pageWidth - left - right - indent - pageColumn
+		title := fitPDFLine(pdf, entry.Title, titleWidth)
+
+		page := ""
+		if entry.Page > 0 {
+			page = strconv.Itoa(entry.Page)
+		}
+
+		pdf.SetX(left + indent)
+		pdf.CellFormat(titleWidth, 8, title, "", 0, "L", false, links[i], "")
+		pdf.CellFormat(pageColumn, 8, page, "", 1, "R", false, links[i], "")
+	}
+	pdf.Ln(10)
+
 	// Введение
-	pdf.SetFont("DejaVu", "B", 14)
+	pdf.SetFont(reports.FontFamily, "B", 14)
 	pdf.Cell(0, 15, "ВВЕДЕНИЕ")
 	pdf.Ln(15)
 
-	pdf.SetFont("DejaVu", "", 12)
-	introText := "Настоящий отчёт подготовлен на основании предоставленных данных. " +
-		"Категории данных сформированы как главы, группы — как подразделы. " +
-		"Для каждого подраздела приведены синтезированное описание и исходные замечания."
-
+	pdf.SetFont(reports.FontFamily, "", 12)
 	// Разбиваем текст на строки для корректного отображения
-	lines := pdf.SplitText(introText, 150)
+	lines := pdf.SplitText(layout.template.RemarksIntro, 150)
 	for _, line := range lines {
 		pdf.Cell(0, 8, line)
 		pdf.Ln(8)
 	}
 	pdf.Ln(10)
 
+	// Место, которое должно поместиться на странице вместе с заголовком: заголовок группы,
+	// подпись и первая строка текста; заголовок раздела - еще и заголовок первой группы
+	const (
+		groupHeadingKeep   = 12 + 10 + 8
+		sectionHeadingKeep = 15 + groupHeadingKeep
+	)
+
+	// markHeading запоминает страницу заголовка и ставит на него ссылку из оглавления
+	// Если заголовок вместе с keep миллиметрами текста не помещается на странице, он начинает новую:
+	// иначе автоматический перенос сдвинул бы заголовок на страницу после записанной в оглавлении
+	// или оставил бы его одного внизу страницы
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	entry := 0
+	markHeading := func(keep float64) {
+		if pdf.GetY()+keep > pageHeight-bottom {
+			pdf.AddPage()
+		}
+		toc[entry].Page = pdf.PageNo()
+		pdf.SetLink(links[entry], pdf.GetY(), -1)
+		entry++
+	}
+
 	// Основные разделы
-	for section, items := range remarksResponse {
+	for _, section := range sections {
 		// Заголовок раздела
-		pdf.SetFont("DejaVu", "B", 14)
-		pdf.Cell(0, 15, section)
+		markHeading(sectionHeadingKeep)
+		pdf.SetFont(reports.FontFamily, "B", 14)
+		pdf.Cell(0, 15, utils.SectionTitle(section))
 		pdf.Ln(15)
 
-		for _, item := range items {
+		for _, item := range remarksResponse[section] {
 			// Подзаголовок
-			pdf.SetFont("DejaVu", "B", 12)
+			markHeading(groupHeadingKeep)
+			pdf.SetFont(reports.FontFamily, "B", 12)
 			pdf.Cell(0, 12, item.GroupName)
 			pdf.Ln(12)
 
 			// Синтезированное замечание
 			if item.SynthesizedRemark != "" {
-				pdf.SetFont("DejaVu", "B", 11)
+				pdf.SetFont(reports.FontFamily, "B", 11)
 				pdf.Cell(0, 10, "Краткая сводка:")
 				pdf.Ln(10)
 
-				pdf.SetFont("DejaVu", "", 11)
+				pdf.SetFont(reports.FontFamily, "", 11)
 				synthLines := pdf.SplitText(item.SynthesizedRemark, 150)
 				for _, line := range synthLines {
 					pdf.Cell(0, 8, line)
@@ -921,7 +1902,7 @@ func (pt *ProjectProcessorTask) generatePDFFromRemarks(remarksResponse RemarksRe
 
 			// Таблица с оригинальными замечаниями
 			if len(item.OriginalDuplicates) > 0 {
-				pdf.SetFont("DejaVu", "B", 11)
+				pdf.SetFont(reports.FontFamily, "B", 11)
 				pdf.Cell(0, 10, "Оригинальные замечания:")
 				pdf.Ln(10)
 
@@ -930,14 +1911,14 @@ func (pt *ProjectProcessorTask) generatePDFFromRemarks(remarksResponse RemarksRe
 				rowHeight := 8.0
 
 				// Заголовок таблицы
-				pdf.SetFont("DejaVu", "B", 10)
+				pdf.SetFont(reports.FontFamily, "B", 10)
 				pdf.SetFillColor(240, 240, 240)
 				pdf.CellFormat(colWidths[0], rowHeight, "№", "1", 0, "C", true, 0, "")
 				pdf.CellFormat(colWidths[1], rowHeight, "Замечание", "1", 0, "L", true, 0, "")
 				pdf.Ln(-1)
 
 				// Строки таблицы
-				pdf.SetFont("DejaVu", "", 10)
+				pdf.SetFont(reports.FontFamily, "", 10)
 				for i, remark := range item.OriginalDuplicates {
 					// Номер
 					pdf.CellFormat(colWidths[0], rowHeight, fmt.Sprintf("%d", i+1), "1", 0, "C", false, 0, "")
@@ -968,27 +1949,16 @@ func (pt *ProjectProcessorTask) generatePDFFromRemarks(remarksResponse RemarksRe
 
 	// Заключение
 	pdf.AddPage()
-	pdf.SetFont("DejaVu", "B", 14)
+	pdf.SetFont(reports.FontFamily, "B", 14)
 	pdf.Cell(0, 15, "ЗАКЛЮЧЕНИЕ")
 	pdf.Ln(15)
 
-	pdf.SetFont("DejaVu", "", 12)
-	conclusionText := "Предложенные мероприятия направлены на снижение неопределённостей и повышение качества " +
-		"прогнозов. Рекомендовано согласовать план доизучения и актуализировать модели по итогам " +
-		"получения новых данных."
-
-	conclusionLines := pdf.SplitText(conclusionText, 150)
+	pdf.SetFont(reports.FontFamily, "", 12)
+	conclusionLines := pdf.SplitText(layout.template.RemarksConclusion, 150)
 	for _, line := range conclusionLines {
 		pdf.Cell(0, 8, line)
 		pdf.Ln(8)
 	}
 
-	// Сохраняем в буфер
-	buffer := new(bytes.Buffer)
-	err := pdf.Output(buffer)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate PDF: %w", err)
-	}
-
-	return buffer, nil
+	return pdf
 }
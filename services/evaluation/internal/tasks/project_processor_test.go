@@ -0,0 +1,465 @@
+package tasks
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"evaluation/internal/extract"
+	db "evaluation/internal/postgres/sqlc"
+	"evaluation/internal/reports"
+	"evaluation/internal/utils"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestBuildFinalReport(t *testing.T) {
+	project := &db.Project{ID: 1, Name: "Месторождение"}
+	// Замечания приходят от новых к старым
+	remarks := []db.Remark{
+		{Section: "Геология", Subsection: "Керн", Content: "третье", Duplicates: 1},
+		{Section: "Разработка", Subsection: "Фонд скважин", Content: "второе", Duplicates: 1},
+		{Section: "Геология", Subsection: "Керн", Content: "первое", Duplicates: 3},
+	}
+	checklist := []ChecklistItem{{Criterion: "Наличие технического задания", Status: "confirmed"}}
+
+	report := buildFinalReport(project, remarks, checklist)
+
+	assert.Equal(t, "Месторождение", report.ProjectName)
+	assert.Equal(t, checklist, report.Checklist)
+	require.Len(t, report.Sections, 2)
+	assert.Equal(t, "Геология", report.Sections[0].Section)
+	require.Len(t, report.Sections[0].Groups, 1)
+	assert.Equal(t, FinalReportGroup{Name: "Керн", Remarks: []string{"первое", "третье"}, Duplicates: []int{3, 1}}, report.Sections[0].Groups[0])
+	assert.Equal(t, "Разработка", report.Sections[1].Section)
+	assert.Equal(t, []string{"второе"}, report.Sections[1].Groups[0].Remarks)
+}
+
+func TestBuildFinalReport_Empty(t *testing.T) {
+	report := buildFinalReport(&db.Project{Name: "Пустой"}, nil, nil)
+
+	assert.Empty(t, report.Sections)
+	assert.Empty(t, report.Checklist)
+}
+
+func TestProjectProcessorTask_ReportFormatPayload(t *testing.T) {
+	task := NewProjectProcessorTask(1, TaskKindFinalReport, PriorityNormal, nil, nil)
+	assert.Equal(t, ReportFormatPDF, task.reportFormat())
+
+	payload, err := task.WithReportFormat(ReportFormatDOCX).GetPayload()
+	require.NoError(t, err)
+
+	// Формат переживает сохранение задачи в очереди
+	template := &reports.Template{Organization: "ООО «Недра»"}
+	factory := NewProjectProcessorTaskFactory(nil, nil, nil, template, RAGConfig{})
+	restored, err := factory(&db.Job{ProjectID: 1, Kind: TaskKindFinalReport, Payload: payload})
+	require.NoError(t, err)
+	assert.Equal(t, ReportFormatDOCX, restored.(*ProjectProcessorTask).reportFormat())
+	assert.Same(t, template, restored.(*ProjectProcessorTask).template)
+
+	assert.True(t, IsReportFormat(ReportFormatPDF))
+	assert.True(t, IsReportFormat(ReportFormatDOCX))
+	assert.False(t, IsReportFormat("odt"))
+}
+
+func TestGenerateDOCXFromRemarks(t *testing.T) {
+	task := NewProjectProcessorTask(1, TaskKindRemarks, PriorityNormal, nil, nil)
+	remarks := RemarksResponse{
+		"Геология": {{GroupName: "Керн", SynthesizedRemark: "Сводка", OriginalDuplicates: []string{"первое", "второе"}}},
+	}
+
+	project := &db.Project{ID: 1, Name: "Месторождение"}
+	data := remarksReportData(project, remarks)
+	template, err := reports.DefaultTemplate().With(reports.Template{
+		RemarksIntro: "Проект {{.ProjectName}}: {{.Remarks}} замечания в {{len .Sections}} разделе",
+	}).Execute(data)
+	require.NoError(t, err)
+
+	buffer, err := task.generateDOCXFromRemarks(template, data, remarks)
+	require.NoError(t, err)
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	require.NoError(t, err)
+
+	var document string
+	for _, f := range archive.File {
+		if f.Name == "word/document.xml" {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			content, _ := io.ReadAll(rc)
+			rc.Close()
+			document = string(content)
+		}
+	}
+	assert.Contains(t, document, "Геология")
+	assert.Contains(t, document, "Керн")
+	assert.Contains(t, document, "второе")
+	// Введение заполнено данными проекта
+	assert.Contains(t, document, "Проект Месторождение: 2 замечания в 1 разделе")
+	// Сводка и диаграмма на первой странице
+	assert.Contains(t, document, "Сводка по замечаниям")
+	assert.Contains(t, document, "Объединено дубликатов")
+	assert.Contains(t, document, "Итого")
+	// Диаграмма - залитые ячейки таблицы, а не символы шрифта
+	assert.Contains(t, document, `w:fill="4682B4"`)
+	assert.NotContains(t, document, "█")
+}
+
+func TestGenerateExcelFromRemarks(t *testing.T) {
+	task := NewProjectProcessorTask(1, TaskKindRemarks, PriorityNormal, nil, nil)
+	remarks := RemarksResponse{
+		"Разработка":   {{GroupName: "Фонд скважин", SynthesizedRemark: "Сводка", OriginalDuplicates: []string{"первое", "второе"}}},
+		"Геология/ГИС": {{GroupName: "Керн", SynthesizedRemark: "Нет керна"}},
+	}
+
+	buffer, err := task.generateExcelFromRemarks(remarks)
+	require.NoError(t, err)
+
+	f, err := excelize.OpenReader(buffer)
+	require.NoError(t, err)
+	defer f.Close()
+
+	// Лист на каждый раздел, лист по умолчанию удален
+	assert.Equal(t, []string{"Геология_ГИС", "Разработка"}, f.GetSheetList())
+
+	rows, err := f.GetRows("Разработка")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, remarksSheetHeaders, rows[0])
+	assert.Equal(t, []string{"Разработка", "Фонд скважин", "Сводка", "первое\nвторое"}, rows[1])
+
+	panes, err := f.GetPanes("Разработка")
+	require.NoError(t, err)
+	assert.True(t, panes.Freeze)
+	assert.Equal(t, 1, panes.YSplit)
+}
+
+func TestExcelSheetName(t *testing.T) {
+	used := map[string]bool{"sheet1": true}
+
+	assert.Equal(t, "Замечания", excelSheetName("", used))
+	assert.Equal(t, "Замечания (2)", excelSheetName("  ", used))
+	assert.Equal(t, "Sheet1 (2)", excelSheetName("Sheet1", used))
+
+	long := excelSheetName("Очень длинное название раздела экспертизы проекта", used)
+	assert.Len(t, []rune(long), 31)
+	duplicate := excelSheetName("Очень длинное название раздела экспертизы проекта", used)
+	assert.Len(t, []rune(duplicate), 31)
+	assert.NotEqual(t, long, duplicate)
+}
+
+func TestGeneratePDFFromRemarks_TableOfContents(t *testing.T) {
+	remarks := RemarksResponse{}
+	for _, section := range []string{"Разработка", "Геология", "Экономика"} {
+		for g := 1; g <= 4; g++ {
+			item := RemarkItem{GroupName: fmt.Sprintf("%s: группа %d", section, g), SynthesizedRemark: "Сводка по группе"}
+			for r := 1; r <= 8; r++ {
+				item.OriginalDuplicates = append(item.OriginalDuplicates, fmt.Sprintf("Замечание %d", r))
+			}
+			remarks[section] = append(remarks[section], item)
+		}
+	}
+
+	task := NewProjectProcessorTask(1, TaskKindRemarks, PriorityNormal, nil, nil)
+	data := remarksReportData(&db.Project{ID: 1, Name: "Месторождение"}, remarks)
+	buffer, err := task.generatePDFFromRemarks(reports.DefaultTemplate(), data, remarks)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(buffer.Bytes(), []byte("%PDF")))
+
+	// Оба прохода дают одинаковое разбиение на страницы
+	var toc []pdfTOCEntry
+	for _, section := range remarks.Sections() {
+		toc = append(toc, pdfTOCEntry{Title: section, Level: 1})
+		for _, item := range remarks[section] {
+			toc = append(toc, pdfTOCEntry{Title: item.GroupName, Level: 2})
+		}
+	}
+	layout := pdfReportLayout{template: reports.DefaultTemplate(), data: data}
+	first := task.renderRemarksPDF(layout, remarks.Sections(), remarks, toc)
+	firstPages := append([]pdfTOCEntry(nil), toc...)
+	layout.totalPages = first.PageNo()
+	second := task.renderRemarksPDF(layout, remarks.Sections(), remarks, toc)
+
+	assert.Equal(t, first.PageNo(), second.PageNo())
+	assert.Equal(t, firstPages, toc)
+	assert.Greater(t, second.PageNo(), 3)
+
+	// Разделы начинаются после оглавления и идут по возрастанию страниц
+	assert.Equal(t, "Геология", toc[0].Title)
+	assert.GreaterOrEqual(t, toc[0].Page, 2)
+	for i := 1; i < len(toc); i++ {
+		assert.GreaterOrEqual(t, toc[i].Page, toc[i-1].Page, "entry %q", toc[i].Title)
+	}
+}
+
+// pdfPages выводит документ без сжатия и возвращает содержимое его страниц по порядку
+func pdfPages(t *testing.T, pdf *gofpdf.Fpdf) []string {
+	t.Helper()
+	pdf.SetCompression(false)
+	var buffer bytes.Buffer
+	require.NoError(t, pdf.Output(&buffer))
+	return strings.Split(buffer.String(), "<</Type /Page\n")[1:]
+}
+
+// pdfText возвращает текст в том виде, в котором gofpdf записывает строку шрифта UTF-8 на страницу
+func pdfText(text string) string {
+	var encoded strings.Builder
+	for _, unit := range utf16.Encode([]rune(text)) {
+		encoded.WriteByte(byte(unit >> 8))
+		encoded.WriteByte(byte(unit))
+	}
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", `\r`).Replace(encoded.String())
+}
+
+func TestRenderRemarksPDF_HeadingAtPageBoundary(t *testing.T) {
+	task := NewProjectProcessorTask(1, TaskKindRemarks, PriorityNormal, nil, nil)
+
+	// Количество замечаний первой группы сдвигает следующие заголовки вдоль страницы,
+	// так что заголовки попадают и к нижнему краю страницы
+	for duplicates := 1; duplicates <= 30; duplicates++ {
+		remarks := RemarksResponse{}
+		for _, section := range []string{"geological", "development"} {
+			for g := 1; g <= 3; g++ {
+				item := RemarkItem{GroupName: fmt.Sprintf("%s group %d", section, g), SynthesizedRemark: "Summary"}
+				count := 3
+				if section == "geological" && g == 1 {
+					count = duplicates
+				}
+				for r := 1; r <= count; r++ {
+					item.OriginalDuplicates = append(item.OriginalDuplicates, fmt.Sprintf("Remark %d", r))
+				}
+				remarks[section] = append(remarks[section], item)
+			}
+		}
+
+		var toc []pdfTOCEntry
+		for _, section := range remarks.Sections() {
+			toc = append(toc, pdfTOCEntry{Title: utils.SectionTitle(section), Level: 1})
+			for _, item := range remarks[section] {
+				toc = append(toc, pdfTOCEntry{Title: item.GroupName, Level: 2})
+			}
+		}
+		layout := pdfReportLayout{template: reports.DefaultTemplate(), data: remarksReportData(&db.Project{ID: 1, Name: "Field"}, remarks)}
+		layout.totalPages = task.renderRemarksPDF(layout, remarks.Sections(), remarks, toc).PageNo()
+		pages := pdfPages(t, task.renderRemarksPDF(layout, remarks.Sections(), remarks, toc))
+
+		// Заголовок выведен на странице из оглавления: это последняя страница с его текстом,
+		// раньше текст встречается только в самом оглавлении
+		for _, entry := range toc {
+			drawn := 0
+			for i, page := range pages {
+				if strings.Contains(page, pdfText(entry.Title)) {
+					drawn = i + 1
+				}
+			}
+			assert.Equal(t, entry.Page, drawn, "duplicates %d, heading %q", duplicates, entry.Title)
+		}
+	}
+}
+
+func TestRemarksResponse_SectionTitles(t *testing.T) {
+	remarks := RemarksResponse{
+		"None":          {{GroupName: "Прочее"}},
+		"development":   {{GroupName: "Фонд скважин"}},
+		"petrophysical": {{GroupName: "Керн", OriginalDuplicates: []string{"Нет керна"}}},
+	}
+
+	// Разделы идут в порядке таксономии, замечания без раздела - последними
+	assert.Equal(t, []string{"petrophysical", "development", "None"}, remarks.Sections())
+
+	task := NewProjectProcessorTask(1, TaskKindRemarks, PriorityNormal, nil, nil)
+	buffer, err := task.generateExcelFromRemarks(remarks)
+	require.NoError(t, err)
+
+	f, err := excelize.OpenReader(buffer)
+	require.NoError(t, err)
+	defer f.Close()
+
+	assert.Equal(t, []string{"Петрофизическая модель", "Разработка и прогноз технологич", "Прочее"}, f.GetSheetList())
+	rows, err := f.GetRows("Петрофизическая модель")
+	require.NoError(t, err)
+	assert.Equal(t, "Петрофизическая модель", rows[1][0])
+}
+
+func TestFinalReportData(t *testing.T) {
+	report := FinalReport{
+		ProjectName: "Месторождение",
+		Sections: []FinalReportSection{
+			{Section: "geological", Groups: []FinalReportGroup{{Name: "Керн", Remarks: []string{"первое", "второе"}, Duplicates: []int{3, 1}}}},
+			{Section: "development", Groups: []FinalReportGroup{{Name: "Фонд", Remarks: []string{"третье"}, Duplicates: []int{1}}, {Name: "Добыча"}}},
+		},
+		Checklist: []ChecklistItem{{Criterion: "Наличие технического задания", Status: "confirmed"}},
+	}
+
+	data := finalReportData(&db.Project{ID: 1, Name: "Месторождение"}, report)
+
+	assert.Equal(t, "Месторождение", data.ProjectName)
+	// Замечания считаются по исходным дубликатам, а не по числу синтезированных замечаний
+	assert.Equal(t, 3, data.Groups)
+	assert.Equal(t, 5, data.Remarks)
+	assert.Equal(t, 2, data.Merged)
+	assert.Equal(t, 1, data.ChecklistCriteria)
+	assert.Equal(t, []reports.SectionSummary{
+		{Title: "Геологическая модель", Groups: 1, Remarks: 4, Merged: 2},
+		{Title: "Разработка и прогноз технологических показателей добычи", Groups: 2, Remarks: 1},
+	}, data.Sections)
+}
+
+func TestRemarksReportData_Summary(t *testing.T) {
+	remarks := RemarksResponse{
+		"development": {
+			{GroupName: "Фонд скважин", OriginalDuplicates: []string{"первое", "второе", "третье"}},
+			{GroupName: "Добыча", OriginalDuplicates: []string{"четвертое"}},
+		},
+		"geological": {{GroupName: "Керн"}},
+	}
+
+	data := remarksReportData(&db.Project{ID: 1, Name: "Месторождение"}, remarks)
+
+	assert.Equal(t, 3, data.Groups)
+	assert.Equal(t, 4, data.Remarks)
+	assert.Equal(t, 2, data.Merged)
+	assert.Equal(t, [][]string{
+		{"Геологическая модель", "1", "0", "0"},
+		{"Разработка и прогноз технологических показателей добычи", "2", "4", "2"},
+		{"Итого", "3", "4", "2"},
+	}, remarksSummaryRows(data))
+
+	// Масштаб диаграммы - наибольшее количество замечаний в разделе
+	assert.Equal(t, 4, maxSectionRemarks(data))
+}
+
+func TestRAGSystem_SplitTextIntoChunks_Location(t *testing.T) {
+	rag, err := NewRAGSystem(RAGConfig{MaxChunkSize: 700, ChunkOverlap: 150, TopK: 5})
+	require.NoError(t, err)
+	chunks := rag.splitTextIntoChunks([]extract.Section{
+		{Page: 1, Location: "стр. 1", Text: "Техническое задание утверждено. Да."},
+		{Page: 2, Location: "стр. 2", Text: "Проектная документация согласована"},
+		{Location: "лист Запасы", Text: "Пласт | Запасы, млн т\nЮ1 | 0.3"},
+	}, "doc.pdf")
+
+	require.Len(t, chunks, 3)
+	assert.Equal(t, "Техническое задание утверждено. Да.", chunks[0].Content)
+	assert.Equal(t, "1", chunks[0].Metadata["page"])
+	assert.Equal(t, "2", chunks[1].Metadata["page"])
+	assert.Equal(t, "doc.pdf, стр. 2", chunkSource(chunks[1]))
+	assert.Equal(t, "2", chunks[2].Metadata["chunk_id"])
+	assert.Empty(t, chunks[2].Metadata["page"])
+	assert.Equal(t, "лист Запасы", chunks[2].Metadata["location"])
+}
+
+// remarksRepository репозиторий в памяти для обработки замечаний
+type remarksRepository struct {
+	*fakeIndexStore
+	project db.Project
+	files   []db.ProjectFile
+	remarks []db.Remark
+}
+
+func (r *remarksRepository) GetProject(context.Context, int32) (*db.Project, error) {
+	return &r.project, nil
+}
+
+func (r *remarksRepository) GetProjectFilesByType(_ context.Context, _ int32, fileType db.FileType) ([]db.ProjectFile, error) {
+	var files []db.ProjectFile
+	for _, file := range r.files {
+		if file.FileType == fileType {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+func (r *remarksRepository) ReplaceRemarks(_ context.Context, projectID int32, remarks []db.CreateRemarkParams) error {
+	r.remarks = nil
+	for i, remark := range remarks {
+		r.remarks = append(r.remarks, db.Remark{
+			ID:         int32(i + 1),
+			ProjectID:  projectID,
+			Section:    remark.Section,
+			Subsection: remark.Subsection,
+			Content:    remark.Content,
+			Duplicates: remark.Duplicates,
+		})
+	}
+	return nil
+}
+
+// GetRemarksByProject возвращает замечания от новых к старым, как запрос GetRemarksByProject
+func (r *remarksRepository) GetRemarksByProject(context.Context, int32) ([]db.Remark, error) {
+	remarks := make([]db.Remark, 0, len(r.remarks))
+	for i := len(r.remarks) - 1; i >= 0; i-- {
+		remarks = append(remarks, r.remarks[i])
+	}
+	return remarks, nil
+}
+
+func (r *remarksRepository) GetEffectiveReportTemplate(context.Context, int32) (*db.ReportTemplate, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (r *remarksRepository) CreateProjectFile(_ context.Context, projectID int32, filename, originalName, filePath string, fileSize int64, extension string, fileType db.FileType) (*db.ProjectFile, error) {
+	return &db.ProjectFile{ProjectID: projectID, Filename: filename, OriginalName: originalName, FilePath: filePath, FileType: fileType}, nil
+}
+
+func (r *remarksRepository) TransitionPipelineStatus(_ context.Context, projectID int32, pipeline string, _, to db.ProjectStatus, _, _ string) (*db.ProjectPipeline, error) {
+	return &db.ProjectPipeline{ProjectID: projectID, Pipeline: pipeline, Status: to}, nil
+}
+
+func TestProcessRemarks_RerunReplacesRemarks(t *testing.T) {
+	sheet := excelize.NewFile()
+	sheet.SetSheetName("Sheet1", "Лист1")
+	require.NoError(t, sheet.SetSheetRow("Лист1", "A1", &[]string{"№", "Проект", "Направление", "Раздел", "Замечание", "Срочность"}))
+	require.NoError(t, sheet.SetSheetRow("Лист1", "A2", &[]string{"1", "Проект", "Геология", "ПЗ", "Нет подписи", "Высокая"}))
+	content, err := sheet.WriteToBuffer()
+	require.NoError(t, err)
+
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(RemarksResponse{
+			"ПЗ":  {{GroupName: "Оформление", SynthesizedRemark: "Документ не подписан", OriginalDuplicates: []string{"Нет подписи", "Не подписано"}}},
+			"ИГИ": {{GroupName: "Изыскания", SynthesizedRemark: "Не приложен отчет", OriginalDuplicates: []string{"Нет отчета"}}},
+		})
+	}))
+	defer service.Close()
+
+	repo := &remarksRepository{
+		fakeIndexStore: newFakeIndexStore(),
+		project:        db.Project{ID: 1, Name: "Проект"},
+		files:          []db.ProjectFile{{ID: 1, ProjectID: 1, Filename: "remarks.xlsx", FileType: db.FileTypeRemarks}},
+	}
+	storage := &fakeFileStorage{files: map[string]string{"remarks.xlsx": content.String()}}
+	task := NewProjectProcessorTask(1, TaskKindRemarks, PriorityNormal, repo, storage)
+	task.remarksURL = service.URL
+
+	// Повтор обработки (новая загрузка или повтор задачи) заменяет замечания, а не добавляет их
+	for run := 0; run < 2; run++ {
+		require.NoError(t, task.processRemarks(context.Background(), &repo.project))
+	}
+
+	remarks, err := repo.GetRemarksByProject(context.Background(), 1)
+	require.NoError(t, err)
+	report := buildFinalReport(&repo.project, remarks, nil)
+	require.Len(t, report.Sections, 2)
+	for _, section := range report.Sections {
+		require.Len(t, section.Groups, 1, section.Section)
+		assert.Len(t, section.Groups[0].Remarks, 1, section.Section)
+	}
+
+	// Итоговый отчет считает объединенные дубликаты так же, как отчет по замечаниям
+	data := finalReportData(&repo.project, report)
+	assert.Equal(t, 3, data.Remarks)
+	assert.Equal(t, 1, data.Merged)
+}
@@ -0,0 +1,295 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Способы поиска чанков документации
+const (
+	RetrieverKeyword   = "keyword"   // BM25 по словам
+	RetrieverEmbedding = "embedding" // косинусная близость эмбеддингов
+	RetrieverHybrid    = "hybrid"    // объединение keyword и embedding методом reciprocal rank fusion
+)
+
+// Retriever индекс чанков документации для поиска по критериям чек-листа
+type Retriever interface {
+	// Index добавляет чанки в индекс
+	Index(ctx context.Context, chunks []DocumentChunk) error
+	// Retrieve возвращает до topK чанков по убыванию релевантности запросу
+	Retrieve(ctx context.Context, query string, topK int) ([]DocumentChunk, error)
+}
+
+// ranker поиск, возвращающий позиции чанков в порядке индексации - основа для объединения результатов
+type ranker interface {
+	Retriever
+	rank(ctx context.Context, query string, limit int) ([]int, error)
+}
+
+// NewRetriever создает поиск выбранного в конфигурации способа, пустое значение - keyword
+func NewRetriever(config RAGConfig) (Retriever, error) {
+	embedder, err := newEmbedder(config)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.Retriever {
+	case RetrieverEmbedding:
+		return newEmbeddingRetriever(embedder), nil
+	case RetrieverHybrid:
+		return newHybridRetriever(newKeywordRetriever(), newEmbeddingRetriever(embedder)), nil
+	default:
+		return newKeywordRetriever(), nil
+	}
+}
+
+// newEmbedder создает клиент эмбеддингов для способа поиска из конфигурации, nil - эмбеддинги не нужны
+func newEmbedder(config RAGConfig) (Embedder, error) {
+	switch config.Retriever {
+	case "", RetrieverKeyword:
+		return nil, nil
+	case RetrieverEmbedding, RetrieverHybrid:
+		if config.EmbeddingAPIURL == "" {
+			return nil, fmt.Errorf("%s retriever requires an embeddings API URL", config.Retriever)
+		}
+		return NewEmbeddingClient(config.EmbeddingAPIURL, config.EmbeddingModel, config.EmbeddingAPIKey), nil
+	default:
+		return nil, fmt.Errorf("unknown retriever %q", config.Retriever)
+	}
+}
+
+// chunksAt возвращает чанки по позициям
+func chunksAt(documents []DocumentChunk, positions []int) []DocumentChunk {
+	chunks := make([]DocumentChunk, 0, len(positions))
+	for _, i := range positions {
+		chunks = append(chunks, documents[i])
+	}
+	return chunks
+}
+
+// keywordRetriever поиск по словам с ранжированием BM25
+type keywordRetriever struct {
+	documents []DocumentChunk
+	index     *bm25Index // строится по documents при первом поиске
+}
+
+func newKeywordRetriever() *keywordRetriever {
+	return &keywordRetriever{}
+}
+
+// Index добавляет чанки, индекс BM25 перестраивается при следующем поиске
+func (r *keywordRetriever) Index(_ context.Context, chunks []DocumentChunk) error {
+	r.documents = append(r.documents, chunks...)
+	r.index = nil
+	return nil
+}
+
+// Retrieve возвращает чанки с наибольшей оценкой BM25
+func (r *keywordRetriever) Retrieve(ctx context.Context, query string, topK int) ([]DocumentChunk, error) {
+	positions, err := r.rank(ctx, query, topK)
+	if err != nil {
+		return nil, err
+	}
+	return chunksAt(r.documents, positions), nil
+}
+
+func (r *keywordRetriever) rank(_ context.Context, query string, limit int) ([]int, error) {
+	if r.index == nil {
+		texts := make([]string, len(r.documents))
+		for i, chunk := range r.documents {
+			texts[i] = chunk.Content
+		}
+		r.index = newBM25Index(texts)
+	}
+
+	results := r.index.Search(query, limit)
+	positions := make([]int, 0, len(results))
+	for _, result := range results {
+		positions = append(positions, result.Index)
+	}
+	return positions, nil
+}
+
+// embeddingBatchSize количество чанков в одном запросе к API эмбеддингов
+const embeddingBatchSize = 32
+
+// embeddingRetriever поиск по косинусной близости эмбеддингов запроса и чанков
+type embeddingRetriever struct {
+	embedder  Embedder
+	documents []DocumentChunk
+	vectors   [][]float64
+}
+
+func newEmbeddingRetriever(embedder Embedder) *embeddingRetriever {
+	return &embeddingRetriever{embedder: embedder}
+}
+
+// Index добавляет чанки, эмбеддинги вычисляются только для чанков без сохраненного эмбеддинга
+func (r *embeddingRetriever) Index(ctx context.Context, chunks []DocumentChunk) error {
+	chunks = append([]DocumentChunk(nil), chunks...)
+	if err := embedChunks(ctx, r.embedder, chunks); err != nil {
+		return err
+	}
+
+	for _, chunk := range chunks {
+		r.documents = append(r.documents, chunk)
+		r.vectors = append(r.vectors, chunk.Embedding)
+	}
+	return nil
+}
+
+// embedChunks вычисляет эмбеддинги чанков без эмбеддинга пакетами по embeddingBatchSize
+func embedChunks(ctx context.Context, embedder Embedder, chunks []DocumentChunk) error {
+	var missing []int
+	for i, chunk := range chunks {
+		if len(chunk.Embedding) == 0 {
+			missing = append(missing, i)
+		}
+	}
+
+	for start := 0; start < len(missing); start += embeddingBatchSize {
+		batch := missing[start:min(start+embeddingBatchSize, len(missing))]
+		texts := make([]string, len(batch))
+		for i, position := range batch {
+			texts[i] = chunks[position].Content
+		}
+
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to embed chunks: %w", err)
+		}
+		if len(vectors) != len(batch) {
+			return fmt.Errorf("embeddings API returned %d vectors for %d chunks", len(vectors), len(batch))
+		}
+
+		for i, position := range batch {
+			chunks[position].Embedding = vectors[i]
+		}
+	}
+	return nil
+}
+
+// Retrieve возвращает чанки, ближайшие к запросу
+func (r *embeddingRetriever) Retrieve(ctx context.Context, query string, topK int) ([]DocumentChunk, error) {
+	positions, err := r.rank(ctx, query, topK)
+	if err != nil {
+		return nil, err
+	}
+	return chunksAt(r.documents, positions), nil
+}
+
+// rank возвращает позиции чанков с положительной близостью к запросу по ее убыванию
+func (r *embeddingRetriever) rank(ctx context.Context, query string, limit int) ([]int, error) {
+	if len(r.documents) == 0 {
+		return nil, nil
+	}
+
+	vectors, err := r.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embeddings API returned %d vectors for a query", len(vectors))
+	}
+
+	type scored struct {
+		position   int
+		similarity float64
+	}
+	var results []scored
+	for i, vector := range r.vectors {
+		if similarity := cosineSimilarity(vectors[0], vector); similarity > 0 {
+			results = append(results, scored{position: i, similarity: similarity})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].similarity > results[j].similarity
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	positions := make([]int, len(results))
+	for i, result := range results {
+		positions[i] = result.position
+	}
+	return positions, nil
+}
+
+// cosineSimilarity косинус угла между векторами, 0 для векторов разной длины или нулевых
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Параметры reciprocal rank fusion: сглаживающая константа и число кандидатов от каждого поиска на один результат
+const (
+	rrfK              = 60
+	rrfCandidateRatio = 4
+)
+
+// hybridRetriever объединяет результаты нескольких поисков методом reciprocal rank fusion:
+// оценка чанка - сумма 1/(rrfK + место) по всем поискам, в выдачу которых он попал
+type hybridRetriever struct {
+	rankers   []ranker
+	documents []DocumentChunk
+}
+
+func newHybridRetriever(rankers ...ranker) *hybridRetriever {
+	return &hybridRetriever{rankers: rankers}
+}
+
+// Index добавляет чанки во все поиски, позиции чанков у них совпадают
+func (r *hybridRetriever) Index(ctx context.Context, chunks []DocumentChunk) error {
+	for _, ranker := range r.rankers {
+		if err := ranker.Index(ctx, chunks); err != nil {
+			return err
+		}
+	}
+	r.documents = append(r.documents, chunks...)
+	return nil
+}
+
+// Retrieve возвращает чанки с наибольшей суммарной оценкой RRF
+func (r *hybridRetriever) Retrieve(ctx context.Context, query string, topK int) ([]DocumentChunk, error) {
+	candidates := 0
+	if topK > 0 {
+		candidates = topK * rrfCandidateRatio
+	}
+
+	scores := make(map[int]float64)
+	var order []int
+	for _, ranker := range r.rankers {
+		positions, err := ranker.rank(ctx, query, candidates)
+		if err != nil {
+			return nil, err
+		}
+		for place, position := range positions {
+			if _, seen := scores[position]; !seen {
+				order = append(order, position)
+			}
+			scores[position] += 1.0 / float64(rrfK+place+1)
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+	if topK > 0 && len(order) > topK {
+		order = order[:topK]
+	}
+	return chunksAt(r.documents, order), nil
+}
@@ -0,0 +1,174 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// embeddingsStub сервер эмбеддингов: вектор текста - количество вхождений ключевых слов
+// Отвечает в формате OpenAI или Ollama
+func embeddingsStub(t *testing.T, ollama bool) *httptest.Server {
+	keywords := []string{"керн", "скважин", "запас"}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, "bge-m3", request.Model)
+
+		vectors := make([][]float64, len(request.Input))
+		for i, text := range request.Input {
+			vectors[i] = make([]float64, len(keywords))
+			for k, keyword := range keywords {
+				vectors[i][k] = float64(strings.Count(strings.ToLower(text), keyword))
+			}
+		}
+
+		if ollama {
+			json.NewEncoder(w).Encode(map[string]any{"embeddings": vectors})
+			return
+		}
+		// OpenAI возвращает элементы data с индексами, порядок не гарантирован
+		var data []map[string]any
+		for i := len(vectors) - 1; i >= 0; i-- {
+			data = append(data, map[string]any{"index": i, "embedding": vectors[i]})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+}
+
+func TestEmbeddingClient_Formats(t *testing.T) {
+	for _, ollama := range []bool{false, true} {
+		server := embeddingsStub(t, ollama)
+		client := NewEmbeddingClient(server.URL, "bge-m3", "secret")
+
+		vectors, err := client.Embed(context.Background(), []string{"керн", "скважина скважина"})
+		require.NoError(t, err)
+		assert.Equal(t, [][]float64{{1, 0, 0}, {0, 2, 0}}, vectors, "ollama=%v", ollama)
+		server.Close()
+	}
+}
+
+func TestEmbeddingClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := NewEmbeddingClient(server.URL, "bge-m3", "secret").Embed(context.Background(), []string{"керн"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 404")
+}
+
+var retrieverChunks = []DocumentChunk{
+	{Content: "Запасы нефти подсчитаны объемным методом"},
+	{Content: "Описание керна скважины 101"},
+	{Content: "Керн отобран в интервале пласта, керн описан"},
+	{Content: "Конструкция скважины и фонд скважин"},
+}
+
+func chunkContents(chunks []DocumentChunk) []string {
+	contents := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		contents[i] = chunk.Content
+	}
+	return contents
+}
+
+func TestKeywordRetriever(t *testing.T) {
+	ctx := context.Background()
+	retriever, err := NewRetriever(RAGConfig{})
+	require.NoError(t, err)
+
+	require.NoError(t, retriever.Index(ctx, retrieverChunks[:1]))
+	chunks, err := retriever.Retrieve(ctx, "керн", 2)
+	require.NoError(t, err)
+	assert.Empty(t, chunks)
+
+	// Добавленные после поиска чанки попадают в индекс
+	require.NoError(t, retriever.Index(ctx, retrieverChunks[1:]))
+	chunks, err = retriever.Retrieve(ctx, "керн", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{retrieverChunks[2].Content, retrieverChunks[1].Content}, chunkContents(chunks))
+}
+
+func TestEmbeddingRetriever(t *testing.T) {
+	server := embeddingsStub(t, true)
+	defer server.Close()
+
+	ctx := context.Background()
+	retriever, err := NewRetriever(RAGConfig{Retriever: RetrieverEmbedding, EmbeddingAPIURL: server.URL, EmbeddingModel: "bge-m3"})
+	require.NoError(t, err)
+	require.NoError(t, retriever.Index(ctx, retrieverChunks))
+
+	chunks, err := retriever.Retrieve(ctx, "скважины", 5)
+	require.NoError(t, err)
+	// Близость не зависит от числа вхождений, только от направления вектора
+	assert.Equal(t, []string{retrieverChunks[3].Content, retrieverChunks[1].Content}, chunkContents(chunks))
+}
+
+func TestEmbeddingRetriever_Batches(t *testing.T) {
+	requests := 0
+	server := embeddingsStub(t, false)
+	defer server.Close()
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		proxy, err := http.Post(server.URL, "application/json", r.Body)
+		require.NoError(t, err)
+		defer proxy.Body.Close()
+		var body any
+		require.NoError(t, json.NewDecoder(proxy.Body).Decode(&body))
+		json.NewEncoder(w).Encode(body)
+	}))
+	defer counting.Close()
+
+	var chunks []DocumentChunk
+	for i := 0; i < embeddingBatchSize+1; i++ {
+		chunks = append(chunks, DocumentChunk{Content: "керн"})
+	}
+	retriever := newEmbeddingRetriever(NewEmbeddingClient(counting.URL, "bge-m3", ""))
+	require.NoError(t, retriever.Index(context.Background(), chunks))
+
+	assert.Equal(t, 2, requests)
+	assert.Len(t, retriever.vectors, embeddingBatchSize+1)
+}
+
+func TestHybridRetriever_ReciprocalRankFusion(t *testing.T) {
+	server := embeddingsStub(t, false)
+	defer server.Close()
+
+	ctx := context.Background()
+	retriever, err := NewRetriever(RAGConfig{Retriever: RetrieverHybrid, EmbeddingAPIURL: server.URL, EmbeddingModel: "bge-m3"})
+	require.NoError(t, err)
+	require.NoError(t, retriever.Index(ctx, retrieverChunks))
+
+	// Чанк 1 найден обоими поисками и поднимается выше чанков, найденных только одним
+	chunks, err := retriever.Retrieve(ctx, "керн скважины 101", 3)
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+	assert.Equal(t, retrieverChunks[1].Content, chunks[0].Content)
+}
+
+func TestNewRetriever_Config(t *testing.T) {
+	_, err := NewRetriever(RAGConfig{Retriever: "vector"})
+	assert.Error(t, err)
+
+	_, err = NewRetriever(RAGConfig{Retriever: RetrieverEmbedding})
+	assert.Error(t, err)
+
+	_, err = NewRetriever(RAGConfig{Retriever: RetrieverHybrid})
+	assert.Error(t, err)
+
+	retriever, err := NewRetriever(DefaultRAGConfig())
+	require.NoError(t, err)
+	assert.IsType(t, &keywordRetriever{}, retriever)
+}
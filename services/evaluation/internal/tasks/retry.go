@@ -0,0 +1,85 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// RetryPolicy политика повтора задач одного типа
+type RetryPolicy struct {
+	MaxAttempts    int              // максимальное количество попыток, включая первую
+	InitialBackoff time.Duration    // задержка перед первым повтором
+	MaxBackoff     time.Duration    // максимальная задержка между повторами
+	Multiplier     float64          // множитель задержки для каждой следующей попытки
+	Retryable      func(error) bool // какие ошибки повторять, по умолчанию IsRetryable
+}
+
+// noRetryPolicy политика для типов задач без зарегистрированной политики - без повторов
+var noRetryPolicy = RetryPolicy{MaxAttempts: 1}
+
+// isRetryableError сообщает, является ли ошибка временной по правилам политики
+func (p RetryPolicy) isRetryableError(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// backoff вычисляет задержку перед повтором после неудачной попытки attempt (начиная с 1)
+func (p RetryPolicy) backoff(attempt int32) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	delay := float64(p.InitialBackoff)
+	for i := int32(1); i < attempt; i++ {
+		delay *= multiplier
+		if p.MaxBackoff > 0 && delay >= float64(p.MaxBackoff) {
+			return p.MaxBackoff
+		}
+	}
+
+	if p.MaxBackoff > 0 && time.Duration(delay) > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return time.Duration(delay)
+}
+
+// retryableError ошибка, помеченная как временная
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// Retryable помечает ошибку как временную - задача с такой ошибкой будет повторена
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryableError{err: err}
+}
+
+// IsRetryable сообщает, является ли ошибка временной:
+// помеченной через Retryable, сетевой ошибкой или истечением таймаута
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var re *retryableError
+	if errors.As(err, &re) {
+		return true
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,39 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+	}
+
+	assert.Equal(t, time.Second, policy.backoff(1))
+	assert.Equal(t, 2*time.Second, policy.backoff(2))
+	assert.Equal(t, 4*time.Second, policy.backoff(3))
+	assert.Equal(t, 8*time.Second, policy.backoff(4))
+	assert.Equal(t, 10*time.Second, policy.backoff(5))
+	assert.Equal(t, 10*time.Second, policy.backoff(50))
+}
+
+func TestIsRetryable(t *testing.T) {
+	transient := Retryable(errors.New("service unavailable"))
+
+	assert.True(t, IsRetryable(transient))
+	assert.True(t, IsRetryable(fmt.Errorf("wrapped: %w", transient)))
+	assert.True(t, IsRetryable(context.DeadlineExceeded))
+	assert.False(t, IsRetryable(errors.New("invalid file")))
+	assert.False(t, IsRetryable(context.Canceled))
+	assert.False(t, IsRetryable(nil))
+	assert.Nil(t, Retryable(nil))
+}
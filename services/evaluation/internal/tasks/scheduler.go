@@ -0,0 +1,111 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	db "evaluation/internal/postgres/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// SetScheduleHandler задает обработчик наступивших расписаний
+func (tm *taskManager) SetScheduleHandler(handler ScheduleHandler) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tm.onSchedule = handler
+}
+
+// scheduler периодически запускает задачи по наступившим расписаниям
+func (tm *taskManager) scheduler(ctx context.Context) {
+	defer tm.wg.Done()
+
+	ticker := time.NewTicker(tm.scheduleTick)
+	defer ticker.Stop()
+
+	for {
+		tm.runDueSchedules(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tm.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDueSchedules запускает задачи по расписаниям, время которых наступило
+// Пропущенные во время простоя сервиса запуски не наверстываются: расписание выполняется один раз
+// и переносится на ближайшее время после текущего
+func (tm *taskManager) runDueSchedules(ctx context.Context) {
+	schedules, err := tm.store.ListDueSchedules(ctx)
+	if err != nil {
+		log.Printf("Failed to list due schedules: %v", err)
+		return
+	}
+
+	for i := range schedules {
+		schedule := &schedules[i]
+
+		cron, err := ParseCron(schedule.CronExpr)
+		if err != nil {
+			log.Printf("Skipping schedule %d of project %d: %v", schedule.ID, schedule.ProjectID, err)
+			continue
+		}
+		next := cron.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("Skipping schedule %d of project %d: expression %q never fires", schedule.ID, schedule.ProjectID, schedule.CronExpr)
+			continue
+		}
+
+		// Запускает задачу только экземпляр, успевший перенести расписание
+		advanced, err := tm.store.AdvanceSchedule(ctx, schedule.ID, schedule.NextRunAt, next)
+		if err != nil {
+			log.Printf("Failed to advance schedule %d: %v", schedule.ID, err)
+			continue
+		}
+		if !advanced {
+			continue
+		}
+
+		tm.mu.RLock()
+		handler := tm.onSchedule
+		tm.mu.RUnlock()
+		if handler == nil {
+			handler = tm.enqueueScheduled
+		}
+
+		if err := handler(ctx, schedule); err != nil {
+			log.Printf("Failed to run schedule %d (%s) for project %d: %v", schedule.ID, schedule.Kind, schedule.ProjectID, err)
+			continue
+		}
+		log.Printf("Schedule %d (%s) for project %d fired, next run at %s",
+			schedule.ID, schedule.Kind, schedule.ProjectID, next.Format(time.RFC3339))
+	}
+}
+
+// enqueueScheduled ставит в очередь задачу типа расписания с пустыми параметрами
+func (tm *taskManager) enqueueScheduled(ctx context.Context, schedule *db.JobSchedule) error {
+	_, err := tm.store.EnqueueJob(ctx, db.EnqueueJobParams{
+		ID:        uuid.New(),
+		ProjectID: schedule.ProjectID,
+		Kind:      schedule.Kind,
+		Payload:   []byte("{}"),
+		Priority:  PriorityLow,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue scheduled task: %w", err)
+	}
+
+	tm.mu.Lock()
+	tm.stats.TotalTasks++
+	tm.mu.Unlock()
+
+	tm.notifyWorker()
+	return nil
+}
@@ -2,6 +2,25 @@ package tasks
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	db "evaluation/internal/postgres/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// ErrTaskCancelled причина отмены контекста задачи, отмененной пользователем
+var ErrTaskCancelled = errors.New("task cancelled")
+
+// ErrTaskNotCancellable задача не найдена среди ожидающих или выполняющихся
+var ErrTaskNotCancellable = errors.New("task is not queued or running")
+
+// Приоритеты задач (меньше = выше приоритет)
+const (
+	PriorityHigh   = 0 // интерактивные задачи, результат которых ждет пользователь
+	PriorityNormal = 1 // обычная обработка проекта
+	PriorityLow    = 2 // массовые и долгие задачи
 )
 
 // Task интерфейс для фоновых задач
@@ -14,21 +33,70 @@ type Task interface {
 
 	// GetPriority возвращает приоритет задачи (меньше = выше приоритет)
 	GetPriority() int
+
+	// GetKind возвращает тип задачи, по которому она восстанавливается из очереди
+	GetKind() string
+
+	// GetPayload возвращает параметры задачи для сохранения в очереди
+	GetPayload() ([]byte, error)
+}
+
+// FailureHandler необязательный интерфейс задачи: OnFailure вызывается,
+// когда задача провалилась окончательно (без повтора или после исчерпания попыток)
+type FailureHandler interface {
+	OnFailure(ctx context.Context, err error)
 }
 
+// ProgressReporter получает сообщения о ходе выполнения задачи проекта
+// current и total задают номер текущего шага и общее число шагов, 0 - если шаги не считаются
+type ProgressReporter interface {
+	ReportProgress(ctx context.Context, projectID int32, message string, current, total int)
+}
+
+// TaskFactory восстанавливает задачу из записи персистентной очереди
+type TaskFactory func(job *db.Job) (Task, error)
+
+// ScheduleHandler запускает задачу по наступившему расписанию проекта
+type ScheduleHandler func(ctx context.Context, schedule *db.JobSchedule) error
+
 // TaskResult результат выполнения задачи
 type TaskResult struct {
-	TaskID    string
-	ProjectID int32
-	Success   bool
-	Error     error
-	Duration  int64 // в миллисекундах
+	TaskID     string
+	ProjectID  int32
+	Kind       string
+	Attempt    int32
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Success    bool
+	Error      error
+	Duration   int64 // в миллисекундах
 }
 
 // TaskManager управляет выполнением фоновых задач
 type TaskManager interface {
-	// SubmitTask добавляет задачу в очередь
-	SubmitTask(task Task) error
+	// RegisterTaskFactory регистрирует фабрику для восстановления задач указанного типа
+	RegisterTaskFactory(kind string, factory TaskFactory)
+
+	// SetRetryPolicy задает политику повтора для задач указанного типа
+	SetRetryPolicy(kind string, policy RetryPolicy)
+
+	// SubmitTask добавляет задачу в очередь и возвращает ее ID
+	SubmitTask(ctx context.Context, task Task) (string, error)
+
+	// SubmitDelayedTask добавляет задачу в очередь с отложенным на delay запуском и возвращает ее ID
+	SubmitDelayedTask(ctx context.Context, task Task, delay time.Duration) (string, error)
+
+	// ResubmitJob ставит в очередь новую задачу с типом, параметрами и приоритетом записи job
+	// Задача восстанавливается зарегистрированной фабрикой, как при захвате из очереди
+	ResubmitJob(ctx context.Context, job *db.Job) (string, error)
+
+	// SetScheduleHandler задает обработчик наступивших расписаний;
+	// по умолчанию задача типа расписания ставится в очередь с пустыми параметрами
+	SetScheduleHandler(handler ScheduleHandler)
+
+	// CancelTask отменяет задачу: ожидающая задача не будет выполнена,
+	// у выполняющейся отменяется контекст
+	CancelTask(ctx context.Context, taskID string) error
 
 	// Start запускает обработчик задач
 	Start(ctx context.Context) error
@@ -40,11 +108,70 @@ type TaskManager interface {
 	GetStats() TaskStats
 }
 
+// JobStore персистентное хранилище очереди задач
+type JobStore interface {
+	EnqueueJob(ctx context.Context, arg db.EnqueueJobParams) (*db.Job, error)
+	ClaimJob(ctx context.Context, workerID string, lockTimeout, agingInterval time.Duration, excludedKinds []string) (*db.Job, error)
+	ExtendJobLock(ctx context.Context, jobID uuid.UUID, workerID string, lockTimeout time.Duration) (bool, error)
+	CompleteJob(ctx context.Context, jobID uuid.UUID, workerID string) error
+	FailJob(ctx context.Context, jobID uuid.UUID, workerID string, errText string) error
+	RetryJob(ctx context.Context, jobID uuid.UUID, workerID string, errText string, delay time.Duration) error
+	MarkJobDead(ctx context.Context, jobID uuid.UUID, workerID string, errText string) error
+	CancelJob(ctx context.Context, jobID uuid.UUID, reason string) (*db.Job, error)
+	GetJob(ctx context.Context, jobID uuid.UUID) (*db.Job, error)
+	GetQueueDepth(ctx context.Context) (*db.GetQueueDepthRow, error)
+	RecordJobRun(ctx context.Context, arg db.CreateJobRunParams) error
+	ListDueSchedules(ctx context.Context) ([]db.JobSchedule, error)
+	AdvanceSchedule(ctx context.Context, scheduleID int32, expectedRunAt, nextRunAt time.Time) (bool, error)
+}
+
 // TaskStats статистика выполнения задач
+// Счетчики, выполняющиеся задачи и задержки относятся к этому процессу, Queue - к общей очереди
 type TaskStats struct {
-	TotalTasks     int64
-	CompletedTasks int64
-	FailedTasks    int64
-	PendingTasks   int
-	IsRunning      bool
+	TotalTasks     int64                `json:"total_tasks"`
+	CompletedTasks int64                `json:"completed_tasks"`
+	FailedTasks    int64                `json:"failed_tasks"`
+	CancelledTasks int64                `json:"cancelled_tasks"`
+	RetriedTasks   int64                `json:"retried_tasks"`
+	DeadTasks      int64                `json:"dead_tasks"`
+	PendingTasks   int                  `json:"pending_tasks"`
+	IsRunning      bool                 `json:"is_running"`
+	WorkerID       string               `json:"worker_id"`
+	WorkerCount    int                  `json:"worker_count"`
+	Queue          QueueStats           `json:"queue"`
+	Running        []RunningTask        `json:"running"`
+	Kinds          map[string]KindStats `json:"kinds"`
+}
+
+// QueueStats глубина общей очереди задач всех экземпляров сервиса
+type QueueStats struct {
+	Ready             int64   `json:"ready"`               // готовы к выполнению и ждут воркера
+	Delayed           int64   `json:"delayed"`             // ждут повтора или отложенного запуска
+	Running           int64   `json:"running"`             // выполняются
+	Dead              int64   `json:"dead"`                // исчерпали попытки повтора
+	OldestWaitSeconds float64 `json:"oldest_wait_seconds"` // ожидание самой старой готовой задачи
+}
+
+// RunningTask задача, выполняющаяся в этом процессе
+type RunningTask struct {
+	JobID     string    `json:"job_id"`
+	ProjectID int32     `json:"project_id"`
+	Kind      string    `json:"kind"`
+	Attempt   int32     `json:"attempt"`
+	Worker    int       `json:"worker"`
+	StartedAt time.Time `json:"started_at"`
+	ElapsedMs int64     `json:"elapsed_ms"`
+}
+
+// KindStats результаты выполнения задач одного типа в этом процессе
+// Перцентили длительности считаются по последним запускам (не больше latencyWindow)
+type KindStats struct {
+	Completed    int64 `json:"completed"`
+	Failed       int64 `json:"failed"`
+	Retried      int64 `json:"retried"`
+	Dead         int64 `json:"dead"`
+	Samples      int   `json:"samples"`
+	LatencyP50Ms int64 `json:"latency_p50_ms"`
+	LatencyP90Ms int64 `json:"latency_p90_ms"`
+	LatencyP99Ms int64 `json:"latency_p99_ms"`
 }
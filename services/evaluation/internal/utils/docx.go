@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DocxDocument документ Word (DOCX) из заголовков, абзацев и таблиц
+// Формирует минимальный пакет Office Open XML, который открывается в Word и LibreOffice
+type DocxDocument struct {
+	body strings.Builder
+}
+
+// NewDocxDocument создает пустой документ
+func NewDocxDocument() *DocxDocument {
+	return &DocxDocument{}
+}
+
+// Heading добавляет заголовок уровня 1-3, попадающий в навигацию и оглавление Word
+func (d *DocxDocument) Heading(level int, text string) {
+	if level < 1 {
+		level = 1
+	}
+	if level > 3 {
+		level = 3
+	}
+	fmt.Fprintf(&d.body, `<w:p><w:pPr><w:pStyle w:val="Heading%d"/></w:pPr>%s</w:p>`, level, docxRun(text, false))
+}
+
+// Paragraph добавляет абзац обычного текста
+func (d *DocxDocument) Paragraph(text string) {
+	fmt.Fprintf(&d.body, `<w:p>%s</w:p>`, docxRun(text, false))
+}
+
+// BoldParagraph добавляет абзац полужирного текста
+func (d *DocxDocument) BoldParagraph(text string) {
+	fmt.Fprintf(&d.body, `<w:p>%s</w:p>`, docxRun(text, true))
+}
+
+// PageBreak начинает новую страницу
+func (d *DocxDocument) PageBreak() {
+	d.body.WriteString(`<w:p><w:r><w:br w:type="page"/></w:r></w:p>`)
+}
+
+// Table добавляет таблицу с рамками и строкой заголовков
+// widths задает ширину столбцов в процентах, при несовпадении длины ширина делится поровну
+func (d *DocxDocument) Table(headers []string, widths []int, rows [][]string) {
+	if len(widths) != len(headers) {
+		widths = make([]int, len(headers))
+		for i := range widths {
+			widths[i] = 100 / len(headers)
+		}
+	}
+
+	d.body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="5000" w:type="pct"/></w:tblPr><w:tblGrid>`)
+	for _, width := range widths {
+		// Ширина сетки в twips при ширине текста страницы A4 около 9638 twips
+		fmt.Fprintf(&d.body, `<w:gridCol w:w="%d"/>`, 9638*width/100)
+	}
+	d.body.WriteString(`</w:tblGrid>`)
+
+	d.tableRow(headers, widths, true)
+	for _, row := range rows {
+		d.tableRow(row, widths, false)
+	}
+	d.body.WriteString(`</w:tbl>`)
+	// Word требует абзац между таблицей и следующим элементом
+	d.body.WriteString(`<w:p/>`)
+}
+
+// tableRow добавляет строку таблицы
+func (d *DocxDocument) tableRow(cells []string, widths []int, header bool) {
+	d.body.WriteString(`<w:tr>`)
+	if header {
+		d.body.WriteString(`<w:trPr><w:tblHeader/></w:trPr>`)
+	}
+	for i, width := range widths {
+		text := ""
+		if i < len(cells) {
+			text = cells[i]
+		}
+		fmt.Fprintf(&d.body, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="pct"/>`, width*50)
+		if header {
+			d.body.WriteString(`<w:shd w:val="clear" w:color="auto" w:fill="F0F0F0"/>`)
+		}
+		fmt.Fprintf(&d.body, `</w:tcPr><w:p>%s</w:p></w:tc>`, docxRun(text, header))
+	}
+	d.body.WriteString(`</w:tr>`)
+}
+
+// docxChartColumns количество столбцов сетки, на которые делится область столбцов диаграммы:
+// самый длинный столбец занимает их все
+const docxChartColumns = 40
+
+// BarChart добавляет горизонтальную столбчатую диаграмму: таблицу без рамок с подписью, столбцом
+// и значением в каждой строке. Столбец - залитые ячейки сетки, их число пропорционально значению,
+// поэтому вид диаграммы не зависит от шрифтов, установленных у читателя
+func (d *DocxDocument) BarChart(labels []string, values []int) {
+	maxValue := 0
+	for _, value := range values {
+		maxValue = max(maxValue, value)
+	}
+
+	// Ширина подписи, области столбцов и значения в twips при ширине текста страницы около 9638 twips
+	const labelWidth, barsWidth, valueWidth = 3374, 4800, 1464
+	columnWidth := barsWidth / docxChartColumns
+
+	d.body.WriteString(`<w:tbl><w:tblPr><w:tblW w:w="0" w:type="auto"/><w:tblLayout w:type="fixed"/></w:tblPr><w:tblGrid>`)
+	fmt.Fprintf(&d.body, `<w:gridCol w:w="%d"/>`, labelWidth)
+	for range docxChartColumns {
+		fmt.Fprintf(&d.body, `<w:gridCol w:w="%d"/>`, columnWidth)
+	}
+	fmt.Fprintf(&d.body, `<w:gridCol w:w="%d"/>`, valueWidth)
+	d.body.WriteString(`</w:tblGrid>`)
+
+	for i, label := range labels {
+		value := 0
+		if i < len(values) {
+			value = values[i]
+		}
+		filled := docxBarColumns(value, maxValue)
+
+		d.body.WriteString(`<w:tr><w:trPr><w:trHeight w:val="360"/></w:trPr>`)
+		fmt.Fprintf(&d.body, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/></w:tcPr><w:p>%s</w:p></w:tc>`, labelWidth, docxRun(label, false))
+		if filled > 0 {
+			fmt.Fprintf(&d.body, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/><w:gridSpan w:val="%d"/><w:shd w:val="clear" w:color="auto" w:fill="4682B4"/></w:tcPr><w:p/></w:tc>`, filled*columnWidth, filled)
+		}
+		if empty := docxChartColumns - filled; empty > 0 {
+			fmt.Fprintf(&d.body, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/><w:gridSpan w:val="%d"/></w:tcPr><w:p/></w:tc>`, empty*columnWidth, empty)
+		}
+		fmt.Fprintf(&d.body, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/></w:tcPr><w:p>%s</w:p></w:tc>`, valueWidth, docxRun(fmt.Sprint(value), false))
+		d.body.WriteString(`</w:tr>`)
+	}
+	d.body.WriteString(`</w:tbl>`)
+	d.body.WriteString(`<w:p/>`)
+}
+
+// docxBarColumns возвращает число залитых столбцов сетки для значения, непустое значение всегда видно
+func docxBarColumns(value, maxValue int) int {
+	if value <= 0 || maxValue <= 0 {
+		return 0
+	}
+	return max(value*docxChartColumns/maxValue, 1)
+}
+
+// Write записывает документ в формате DOCX
+func (d *DocxDocument) Write(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	document := docxDocumentHeader + d.body.String() + docxDocumentFooter
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRels},
+		{"word/_rels/document.xml.rels", docxDocumentRels},
+		{"word/styles.xml", docxStyles},
+		{"word/document.xml", document},
+	}
+
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", part.name, err)
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize DOCX: %w", err)
+	}
+	return nil
+}
+
+// Bytes возвращает документ в формате DOCX
+func (d *DocxDocument) Bytes() (*bytes.Buffer, error) {
+	buffer := new(bytes.Buffer)
+	if err := d.Write(buffer); err != nil {
+		return nil, err
+	}
+	return buffer, nil
+}
+
+// docxRun формирует фрагмент текста, переводы строк превращаются в разрывы строк
+func docxRun(text string, bold bool) string {
+	var b strings.Builder
+	b.WriteString(`<w:r>`)
+	if bold {
+		b.WriteString(`<w:rPr><w:b/></w:rPr>`)
+	}
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			b.WriteString(`<w:br/>`)
+		}
+		b.WriteString(`<w:t xml:space="preserve">`)
+		xml.EscapeText(&b, []byte(line))
+		b.WriteString(`</w:t>`)
+	}
+	b.WriteString(`</w:r>`)
+	return b.String()
+}
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/><Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/></Types>`
+
+const docxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/></Relationships>`
+
+const docxDocumentRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Times New Roman" w:hAnsi="Times New Roman" w:cs="Times New Roman"/><w:sz w:val="24"/><w:lang w:val="ru-RU"/></w:rPr></w:rPrDefault><w:pPrDefault><w:pPr><w:spacing w:after="120"/></w:pPr></w:pPrDefault></w:docDefaults><w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style><w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="360" w:after="240"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="32"/></w:rPr></w:style><w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="28"/></w:rPr></w:style><w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="200" w:after="100"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="26"/></w:rPr></w:style><w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders><w:top w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:left w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:bottom w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:right w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideH w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="auto"/></w:tblBorders></w:tblPr></w:style></w:styles>`
+
+const docxDocumentHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`
+
+// Поля страницы A4 по ГОСТ: слева 30 мм, справа 10 мм, сверху и снизу 20 мм
+const docxDocumentFooter = `<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1134" w:right="567" w:bottom="1134" w:left="1701" w:header="709" w:footer="709" w:gutter="0"/></w:sectPr></w:body></w:document>`
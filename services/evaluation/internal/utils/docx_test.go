@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDocxDocument_Write(t *testing.T) {
+	doc := NewDocxDocument()
+	doc.Heading(1, "Раздел <1> & ко")
+	doc.Paragraph("первая строка\nвторая строка")
+	doc.Table([]string{"№", "Замечание"}, []int{10, 90}, [][]string{{"1", "Нет \"данных\""}})
+
+	buffer, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatalf("DOCX is not a valid zip archive: %v", err)
+	}
+
+	parts := make(map[string]string)
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml", "word/styles.xml"} {
+		content, ok := parts[name]
+		if !ok {
+			t.Fatalf("part %s is missing", name)
+		}
+		// Каждая часть должна быть корректным XML
+		decoder := xml.NewDecoder(strings.NewReader(content))
+		for {
+			_, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("part %s is not well-formed XML: %v", name, err)
+			}
+		}
+	}
+
+	document := parts["word/document.xml"]
+	if !strings.Contains(document, `<w:pStyle w:val="Heading1"/>`) {
+		t.Error("heading style is missing")
+	}
+	if !strings.Contains(document, "Раздел &lt;1&gt; &amp; ко") {
+		t.Error("heading text is not escaped")
+	}
+	if !strings.Contains(document, "<w:br/>") {
+		t.Error("line break is missing")
+	}
+	if strings.Count(document, "<w:tr>") != 2 {
+		t.Errorf("expected header and one data row, got %d rows", strings.Count(document, "<w:tr>"))
+	}
+}
+
+func TestDocxDocument_BarChart(t *testing.T) {
+	doc := NewDocxDocument()
+	doc.BarChart([]string{"Геология", "Разработка", "Экономика"}, []int{4, 1, 0})
+	document := doc.body.String()
+
+	// Столбец - залитые ячейки сетки, самый длинный занимает всю область диаграммы
+	if !strings.Contains(document, `<w:gridSpan w:val="40"/><w:shd w:val="clear" w:color="auto" w:fill="4682B4"/>`) {
+		t.Error("longest bar must span all chart columns")
+	}
+	if !strings.Contains(document, `<w:gridSpan w:val="10"/><w:shd w:val="clear" w:color="auto" w:fill="4682B4"/>`) {
+		t.Error("bar must be proportional to the value")
+	}
+	if got := strings.Count(document, `w:fill="4682B4"`); got != 2 {
+		t.Errorf("expected 2 filled bars, got %d", got)
+	}
+	if got := strings.Count(document, "<w:tr>"); got != 3 {
+		t.Errorf("expected 3 chart rows, got %d", got)
+	}
+
+	// Непустое значение видно при любом масштабе
+	if got := docxBarColumns(1, 1000); got != 1 {
+		t.Errorf("docxBarColumns(1, 1000) = %d, want 1", got)
+	}
+	if got := docxBarColumns(0, 4); got != 0 {
+		t.Errorf("docxBarColumns(0, 4) = %d, want 0", got)
+	}
+}
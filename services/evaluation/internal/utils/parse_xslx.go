@@ -99,17 +99,6 @@ func ParseExcel(filePath string) (string, error) {
 		}
 	}
 
-	type TranslationMap map[string]string
-
-	translations := TranslationMap{
-		"Программа доизучения (ГРР и ОПР)":                        "reassessment",
-		"Сейсмогеологическая модель":                              "seismogeological",
-		"Петрофизическая модель":                                  "petrophysical",
-		"Геологическая модель":                                    "geological",
-		"Разработка и прогноз технологических показателей добычи": "development",
-		"Гидродинамическая и интегрированная модели":              "hydrodynamic_integrated",
-	}
-
 	// Обработка данных
 	var modelList []map[string]string
 
@@ -148,12 +137,9 @@ func ParseExcel(filePath string) (string, error) {
 			continue
 		}
 
-		if translated, ok := translations[val]; ok {
-			if translated == val {
-				modelList[i]["expertise_section"] = "None"
-			} else {
-				modelList[i]["expertise_section"] = translated
-			}
+		// Названия разделов переводятся в ключи таксономии, см. ExpertiseSections
+		if key, ok := SectionKey(val); ok {
+			modelList[i]["expertise_section"] = key
 		} else {
 			// если нет перевода - оставить как есть или присвоить None по желанию
 			if val == "" {
@@ -255,17 +241,6 @@ func ParseExcelFromBytes(fileContent []byte) ([]byte, error) {
 		}
 	}
 
-	type TranslationMap map[string]string
-
-	translations := TranslationMap{
-		"Программа доизучения (ГРР и ОПР)":                        "reassessment",
-		"Сейсмогеологическая модель":                              "seismogeological",
-		"Петрофизическая модель":                                  "petrophysical",
-		"Геологическая модель":                                    "geological",
-		"Разработка и прогноз технологических показателей добычи": "development",
-		"Гидродинамическая и интегрированная модели":              "hydrodynamic_integrated",
-	}
-
 	// Обработка данных
 	var modelList []map[string]string
 
@@ -305,12 +280,9 @@ func ParseExcelFromBytes(fileContent []byte) ([]byte, error) {
 			continue
 		}
 
-		if translated, ok := translations[val]; ok {
-			if translated == val {
-				modelList[i]["expertise_section"] = "None"
-			} else {
-				modelList[i]["expertise_section"] = translated
-			}
+		// Названия разделов переводятся в ключи таксономии, см. ExpertiseSections
+		if key, ok := SectionKey(val); ok {
+			modelList[i]["expertise_section"] = key
 		} else {
 			// если нет перевода - оставить как есть или присвоить None по желанию
 			if val == "" {
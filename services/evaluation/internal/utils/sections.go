@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+)
+
+// ExpertiseSection раздел экспертизы: машинный ключ, с которым работает ML сервис, и название для отчетов
+type ExpertiseSection struct {
+	Key   string
+	Title string
+}
+
+// ExpertiseSections разделы экспертизы в порядке их следования в отчетах
+// Порядок совпадает с major_categories в themes.json ML сервиса
+var ExpertiseSections = []ExpertiseSection{
+	{Key: "geological", Title: "Геологическая модель"},
+	{Key: "seismogeological", Title: "Сейсмогеологическая модель"},
+	{Key: "petrophysical", Title: "Петрофизическая модель"},
+	{Key: "hydrodynamic_integrated", Title: "Гидродинамическая и интегрированная модели"},
+	{Key: "development", Title: "Разработка и прогноз технологических показателей добычи"},
+	{Key: "reassessment", Title: "Программа доизучения (ГРР и ОПР)"},
+}
+
+// Замечания без раздела экспертизы
+const (
+	UnclassifiedSectionKey   = "None"
+	UnclassifiedSectionTitle = "Прочее"
+)
+
+// sectionCategorySeparator разделяет раздел и подкатегорию в названиях, которые формирует ML сервис
+const sectionCategorySeparator = " / "
+
+// SectionKey возвращает машинный ключ раздела по его названию
+func SectionKey(title string) (string, bool) {
+	title = strings.TrimSpace(title)
+	for _, section := range ExpertiseSections {
+		if section.Title == title {
+			return section.Key, true
+		}
+	}
+	return "", false
+}
+
+// SectionTitle возвращает название раздела для отчетов
+// Принимает ключ или название раздела, в том числе вида "раздел / подкатегория";
+// неизвестные разделы возвращаются без изменений
+func SectionTitle(section string) string {
+	major, sub, found := strings.Cut(section, sectionCategorySeparator)
+	title := major
+	if major == UnclassifiedSectionKey {
+		title = UnclassifiedSectionTitle
+	}
+	for _, known := range ExpertiseSections {
+		if known.Key == major {
+			title = known.Title
+			break
+		}
+	}
+
+	if found {
+		return title + sectionCategorySeparator + sub
+	}
+	return title
+}
+
+// SectionOrder возвращает позицию раздела в отчетах
+// Неизвестные разделы идут после известных, замечания без раздела - последними
+func SectionOrder(section string) int {
+	major, _, _ := strings.Cut(section, sectionCategorySeparator)
+	if major == UnclassifiedSectionKey || major == UnclassifiedSectionTitle {
+		return len(ExpertiseSections) + 1
+	}
+	for i, known := range ExpertiseSections {
+		if known.Key == major || known.Title == major {
+			return i
+		}
+	}
+	return len(ExpertiseSections)
+}
+
+// SortSections упорядочивает разделы по таксономии, а разделы с одинаковой позицией - по названию
+func SortSections(sections []string) {
+	sort.SliceStable(sections, func(i, j int) bool {
+		oi, oj := SectionOrder(sections[i]), SectionOrder(sections[j])
+		if oi != oj {
+			return oi < oj
+		}
+		return SectionTitle(sections[i]) < SectionTitle(sections[j])
+	})
+}
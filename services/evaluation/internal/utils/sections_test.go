@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSectionTitle(t *testing.T) {
+	tests := []struct {
+		section string
+		want    string
+	}{
+		{"petrophysical", "Петрофизическая модель"},
+		{"Петрофизическая модель", "Петрофизическая модель"},
+		{"None", "Прочее"},
+		{"None / Флюидные контакты", "Прочее / Флюидные контакты"},
+		{"geological / Запасы и ресурсы", "Геологическая модель / Запасы и ресурсы"},
+		{"unknown", "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := SectionTitle(tt.section); got != tt.want {
+			t.Errorf("SectionTitle(%q) = %q, want %q", tt.section, got, tt.want)
+		}
+	}
+}
+
+func TestSectionKey(t *testing.T) {
+	for _, section := range ExpertiseSections {
+		key, ok := SectionKey(section.Title)
+		if !ok || key != section.Key {
+			t.Errorf("SectionKey(%q) = %q, %v, want %q", section.Title, key, ok, section.Key)
+		}
+	}
+
+	if _, ok := SectionKey("Прочее"); ok {
+		t.Error("SectionKey() must not resolve unknown titles")
+	}
+}
+
+func TestSortSections(t *testing.T) {
+	sections := []string{
+		"None",
+		"reassessment",
+		"Экономика",
+		"Петрофизическая модель / Керн",
+		"geological",
+		"Бурение",
+		"petrophysical",
+	}
+
+	SortSections(sections)
+
+	want := []string{
+		"geological",
+		"petrophysical",
+		"Петрофизическая модель / Керн",
+		"reassessment",
+		"Бурение",
+		"Экономика",
+		"None",
+	}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("SortSections() = %v, want %v", sections, want)
+	}
+}